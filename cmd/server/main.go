@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -9,12 +10,20 @@ import (
 	"syscall"
 	"time"
 
+	"gin-service/internal/category"
 	"gin-service/internal/health"
 	"gin-service/internal/product"
+	"gin-service/pkg/audit"
+	"gin-service/pkg/common"
 	"gin-service/pkg/config"
+	"gin-service/pkg/constants"
+	"gin-service/pkg/database"
 	"gin-service/pkg/logger"
 	"gin-service/pkg/middleware"
+	"gin-service/pkg/money"
 	"gin-service/pkg/server"
+	"gin-service/pkg/validation"
+	"gin-service/pkg/version"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,6 +35,14 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Run the migrate subcommand instead of the server when requested
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
 	// Convert string level to logger.Level
 	var logLevel logger.Level
 	switch cfg.Log.Level {
@@ -67,25 +84,136 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Report validation failures by JSON field name and register domain rules
+	if err := validation.Setup(); err != nil {
+		appLogger.Fatal(context.Background(), "Failed to set up request validation", err, logger.Fields{})
+	}
+
+	// Prices sent as bare numbers are in the default currency, so it has to
+	// pass the currency rule as well
+	err = money.SetDefaultCurrency(cfg.Products.DefaultCurrency)
+	if err == nil && !validation.IsAllowedCurrency(money.DefaultCurrency()) {
+		err = fmt.Errorf("currency %s is not allowed", money.DefaultCurrency())
+	}
+	if err != nil {
+		appLogger.Fatal(context.Background(), "Invalid default currency", err, logger.Fields{
+			"currency": cfg.Products.DefaultCurrency,
+		})
+	}
+
 	// Initialize router
 	router := gin.New()
 
 	// Add middleware
 	router.Use(logger.RequestLogger(appLogger))
 	router.Use(middleware.Recovery())
+	router.Use(middleware.ErrorHandler())
+	if cfg.Products.PriceFormat == "number" {
+		router.Use(middleware.NumberPrices())
+	}
+	router.Use(middleware.Actor())
 	router.Use(middleware.CORS())
 
 	// Initialize repositories
 	healthRepo := health.NewHealthRepository()
-	productRepo := product.NewProductRepository()
+	healthRegistry := health.NewRegistry(cfg.Health.HistorySize, appLogger)
+
+	var categoryRepo category.CategoryRepository
+	var productRepo product.ProductRepository
+	var reservationRepo product.ReservationRepository
+	var variantRepo product.VariantRepository
+	var txManager database.TransactionManager
+	var auditStore audit.Store
+	// migrate brings the schema up to date; it runs once the server is
+	// listening so that the startup probe can report progress meanwhile
+	var migrate func(ctx context.Context) error
+	switch cfg.Database.Type {
+	case constants.DBTypePostgreSQL:
+		dbManager, err := database.NewManager(&cfg.Database)
+		if err != nil {
+			appLogger.Fatal(context.Background(), "Failed to create database manager", err, logger.Fields{
+				"type": cfg.Database.Type,
+			})
+		}
+
+		if err := dbManager.Connect(context.Background()); err != nil {
+			appLogger.Fatal(context.Background(), "Failed to connect to database", err, logger.Fields{
+				"host":     cfg.Database.Host,
+				"database": cfg.Database.Database,
+			})
+		}
+		defer dbManager.Close(context.Background())
+
+		if err := healthRegistry.Register(health.Check{
+			Name:             "database",
+			Timeout:          cfg.Health.CheckTimeout,
+			Criticality:      health.Critical,
+			Interval:         cfg.Health.CheckInterval,
+			SuccessThreshold: cfg.Health.SuccessThreshold,
+			FailureThreshold: cfg.Health.FailureThreshold,
+			MaxBackoff:       cfg.Health.MaxBackoff,
+			Run:              health.ProbeCheck(dbManager.IsHealthy),
+		}); err != nil {
+			appLogger.Fatal(context.Background(), "Failed to register database health check", err, logger.Fields{})
+		}
+
+		if cfg.Database.AutoMigrate {
+			migrate = dbManager.Migrate
+		}
+
+		categoryRepo, err = category.NewPostgreSQLCategoryRepository(dbManager.GetConnection())
+		if err != nil {
+			appLogger.Fatal(context.Background(), "Failed to create category repository", err, logger.Fields{})
+		}
+		productRepo, err = product.NewPostgreSQLProductRepository(dbManager.GetConnection())
+		if err != nil {
+			appLogger.Fatal(context.Background(), "Failed to create product repository", err, logger.Fields{})
+		}
+		reservationRepo, err = product.NewPostgreSQLReservationRepository(dbManager.GetConnection())
+		if err != nil {
+			appLogger.Fatal(context.Background(), "Failed to create reservation repository", err, logger.Fields{})
+		}
+		variantRepo, err = product.NewPostgreSQLVariantRepository(dbManager.GetConnection())
+		if err != nil {
+			appLogger.Fatal(context.Background(), "Failed to create variant repository", err, logger.Fields{})
+		}
+		txManager = dbManager
+
+		auditStore, err = audit.NewPostgreSQLStore(dbManager.GetConnection())
+		if err != nil {
+			appLogger.Fatal(context.Background(), "Failed to create audit store", err, logger.Fields{})
+		}
+	default:
+		appLogger.Warn(context.Background(), "Using in-memory product repository, data will not persist", logger.Fields{
+			"type": cfg.Database.Type,
+		})
+		categoryRepo = category.NewCategoryRepository()
+		productRepo = product.NewProductRepository()
+		reservationRepo = product.NewReservationRepository()
+		variantRepo = product.NewVariantRepository()
+		txManager = database.NewNoopTransactionManager()
+		auditStore = audit.NewMemoryStore()
+	}
 
 	// Initialize services
-	healthService := health.NewHealthService(healthRepo, appLogger)
-	productService := product.NewProductService(productRepo)
+	healthService := health.NewHealthService(healthRepo, healthRegistry, appLogger)
+	categoryService := category.NewCategoryService(categoryRepo, productRepo)
+	productService := product.NewProductService(productRepo, variantRepo, reservationRepo, categoryRepo, txManager, auditStore)
+	stockService := product.NewStockService(productRepo, variantRepo, reservationRepo, txManager, auditStore, cfg.Products.ReservationTTL)
+	variantService := product.NewVariantService(productRepo, variantRepo, txManager, auditStore)
 
 	// Initialize handlers
 	healthHandler := health.NewHealthHandler(healthService)
+	categoryHandler := category.NewCategoryHandler(categoryService)
 	productHandler := product.NewProductHandler(productService)
+	stockHandler := product.NewStockHandler(stockService)
+	variantHandler := product.NewVariantHandler(variantService)
+
+	// Product responses honor fields, exclude and expand query parameters
+	shapeProducts := middleware.ShapeResponse(common.NewShaper(
+		category.Expansion(categoryRepo),
+		product.VariantsExpansion(variantRepo),
+	))
 
 	// Setup routes
 	api := router.Group("/api/v1")
@@ -96,27 +224,75 @@ func main() {
 			healthGroup.GET("", healthHandler.GetHealth)
 			healthGroup.GET("/ready", healthHandler.GetReadiness)
 			healthGroup.GET("/live", healthHandler.GetLiveness)
+			healthGroup.GET("/startup", healthHandler.GetStartup)
+			healthGroup.GET("/history", healthHandler.GetHistory)
+		}
+
+		// Build metadata
+		api.GET("/version", healthHandler.GetVersion)
+
+		// Everything else needs migrations to have run
+		app := api.Group("", middleware.RequireStarted(healthService.Started))
+
+		// Category endpoints
+		categoryGroup := app.Group("/categories")
+		{
+			categoryGroup.POST("", categoryHandler.CreateCategory)
+			categoryGroup.GET("", categoryHandler.GetCategories)
+			categoryGroup.GET("/tree", categoryHandler.GetCategoryTree)
+			categoryGroup.GET("/:id", categoryHandler.GetCategory)
+			categoryGroup.PUT("/:id", categoryHandler.UpdateCategory)
+			categoryGroup.DELETE("/:id", categoryHandler.DeleteCategory)
 		}
 
 		// Product endpoints
-		productGroup := api.Group("/products")
+		productGroup := app.Group("/products")
 		{
-			productGroup.POST("", productHandler.CreateProduct)
-			productGroup.GET("", productHandler.GetAllProducts)
-			productGroup.GET("/:id", productHandler.GetProduct)
-			productGroup.PUT("/:id", productHandler.UpdateProduct)
+			productGroup.POST("", shapeProducts, productHandler.CreateProduct)
+			productGroup.GET("", shapeProducts, productHandler.GetAllProducts)
+			productGroup.GET("/export", productHandler.ExportProducts)
+			productGroup.POST("/import", productHandler.ImportProducts)
+			productGroup.GET("/trash", shapeProducts, productHandler.GetTrashedProducts)
+			productGroup.GET("/search", productHandler.SearchProducts)
+			productGroup.GET("/suggest", productHandler.SuggestProducts)
+			productGroup.GET("/:id", shapeProducts, productHandler.GetProduct)
+			productGroup.PUT("/:id", shapeProducts, productHandler.UpdateProduct)
+			productGroup.PATCH("/:id", shapeProducts, productHandler.PatchProduct)
 			productGroup.DELETE("/:id", productHandler.DeleteProduct)
+			productGroup.POST("/:id/restore", shapeProducts, productHandler.RestoreProduct)
+			productGroup.GET("/:id/history", productHandler.GetProductHistory)
+			productGroup.POST("/:id/stock/reserve", stockHandler.ReserveStock)
+			productGroup.POST("/:id/stock/release", stockHandler.ReleaseStock)
+			productGroup.POST("/:id/stock/commit", stockHandler.CommitStock)
+			productGroup.POST("/:id/variants", variantHandler.CreateVariant)
+			productGroup.GET("/:id/variants", variantHandler.GetVariants)
+			productGroup.GET("/:id/variants/:variant_id", variantHandler.GetVariant)
+			productGroup.PUT("/:id/variants/:variant_id", variantHandler.UpdateVariant)
+			productGroup.DELETE("/:id/variants/:variant_id", variantHandler.DeleteVariant)
+			productGroup.GET("/:id/variants/:variant_id/history", variantHandler.GetVariantHistory)
+		}
+		app.POST("/products:action", productHandler.ProductAction)
+
+		// Admin endpoints
+		adminGroup := app.Group("/admin", middleware.AdminAuth(cfg.Admin.Token))
+		{
+			adminGroup.DELETE("/products/:id", productHandler.PurgeProduct)
 		}
 	}
 
 	// Create server
 	srv := server.New(cfg.Server.Port, router)
 
-	// Start server in a goroutine
+	// Start server in a goroutine. Until startup has finished below, the
+	// startup probe reports "starting", the readiness probe keeps traffic
+	// away and every route outside the health group answers 503.
 	go func() {
+		build := version.Get()
 		appLogger.Info(context.Background(), "Starting server", logger.Fields{
-			"port": cfg.Server.Port,
-			"mode": cfg.Server.Mode,
+			"port":       cfg.Server.Port,
+			"mode":       cfg.Server.Mode,
+			"version":    build.Version,
+			"git_commit": build.GitCommit,
 		})
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			appLogger.Fatal(context.Background(), "Failed to start server", err, logger.Fields{
@@ -125,12 +301,56 @@ func main() {
 		}
 	}()
 
+	if migrate != nil {
+		if err := migrate(context.Background()); err != nil {
+			appLogger.Fatal(context.Background(), "Failed to migrate database", err, logger.Fields{
+				"migrations_path": cfg.Database.MigrationsPath,
+			})
+		}
+		appLogger.Info(context.Background(), "Database migrations are up to date", logger.Fields{})
+	}
+
+	// Start background jobs; they stop when the server shuts down
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	if cfg.Products.TrashRetention > 0 && cfg.Products.PurgeInterval > 0 {
+		purger := product.NewTrashPurger(productService, cfg.Products.TrashRetention, cfg.Products.PurgeInterval, appLogger)
+		go purger.Run(backgroundCtx)
+	}
+
+	if cfg.Products.ReservationSweepInterval > 0 {
+		expirer := product.NewReservationExpirer(stockService, cfg.Products.ReservationSweepInterval, appLogger)
+		go expirer.Run(backgroundCtx)
+	}
+
+	// Warm up by running every health check once; with migrations done, the
+	// startup probe can pass from here on
+	healthRegistry.Start(backgroundCtx)
+	healthService.MarkStarted()
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	appLogger.Info(context.Background(), "Shutting down server", logger.Fields{})
 
+	// Report not ready right away and keep serving for the pre-stop delay so
+	// that load balancers stop routing here before connections are closed.
+	// A second signal skips the wait.
+	healthService.MarkDraining()
+	if cfg.Server.PreStopDelay > 0 {
+		appLogger.Info(context.Background(), "Draining before shutdown", logger.Fields{
+			"pre_stop_delay": cfg.Server.PreStopDelay.String(),
+		})
+		select {
+		case <-time.After(cfg.Server.PreStopDelay):
+		case <-quit:
+		}
+	}
+	stopBackground()
+	healthRegistry.Stop()
+
 	// Create a deadline for server shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
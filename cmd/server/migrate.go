@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"gin-service/pkg/config"
+	"gin-service/pkg/constants"
+	"gin-service/pkg/database"
+	"gin-service/pkg/database/migrate"
+)
+
+const migrateUsage = `usage: server migrate <command> [arguments]
+
+commands:
+  up              apply all pending migrations
+  down [steps]    revert the last applied migration(s), default 1
+  status          list migrations and whether they are applied
+  create <name>   create an empty up/down migration pair`
+
+// runMigrate executes the migrate subcommand with the given arguments
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command\n%s", migrateUsage)
+	}
+
+	switch args[0] {
+	case "up", "down", "status", "create":
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+
+	migrationsPath := cfg.Database.MigrationsPath
+	if migrationsPath == "" {
+		migrationsPath = constants.MigrationsPath
+	}
+
+	// create only touches the filesystem, so it does not need a database
+	if args[0] == "create" {
+		if len(args) < 2 {
+			return fmt.Errorf("missing migration name\n%s", migrateUsage)
+		}
+
+		upPath, downPath, err := migrate.Create(migrationsPath, args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created %s\nCreated %s\n", upPath, downPath)
+		return nil
+	}
+
+	dbManager, err := database.NewManager(&cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	if err := dbManager.Connect(ctx); err != nil {
+		return err
+	}
+	defer dbManager.Close(ctx)
+
+	migrator := dbManager.Migrator()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("Applied %06d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid steps %q: %w", args[1], err)
+			}
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Printf("Reverted %06d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No applied migrations to revert")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, status := range statuses {
+			state, appliedAt := "pending", ""
+			if status.Applied {
+				state = "applied"
+				appliedAt = status.AppliedAt.Format(constants.TimeFormatRFC3339)
+			}
+			fmt.Fprintf(w, "%06d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+		w.Flush()
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package category
+
+import "errors"
+
+// ErrCategoryNotFound is returned by repositories when no category has the requested ID
+var ErrCategoryNotFound = errors.New("category not found")
+
+// ErrSlugTaken is returned when another category already uses a slug
+var ErrSlugTaken = errors.New("category slug is already taken")
+
+// ErrCategoryInUse is returned when deleting a category that still has
+// subcategories or products
+var ErrCategoryInUse = errors.New("category is in use")
@@ -0,0 +1,29 @@
+package category
+
+import (
+	"context"
+
+	"gin-service/pkg/common"
+)
+
+// Expansion embeds the category referenced by the category_id field of a
+// resource under "category", for responses shaped with expand=category. The
+// categories of a whole page are looked up in one repository call.
+func Expansion(repository CategoryRepository) common.Expansion {
+	return common.Expansion{
+		Name: "category",
+		Key:  "category_id",
+		Resolve: func(ctx context.Context, ids []string) (map[string]interface{}, error) {
+			found, err := repository.GetByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			categories := make(map[string]interface{}, len(found))
+			for _, category := range found {
+				categories[category.ID] = category
+			}
+			return categories, nil
+		},
+	}
+}
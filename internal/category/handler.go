@@ -0,0 +1,132 @@
+package category
+
+import (
+	"net/http"
+
+	"gin-service/pkg/common"
+	"gin-service/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CategoryHandler handles HTTP requests for category endpoints. Errors are
+// attached with c.Error and rendered by middleware.ErrorHandler.
+type CategoryHandler struct {
+	service CategoryService
+}
+
+// NewCategoryHandler creates a new category handler instance
+func NewCategoryHandler(service CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		service: service,
+	}
+}
+
+// CreateCategory handles POST /api/v1/categories requests
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(validation.Translate(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	response, err := h.service.CreateCategory(ctx, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetCategory handles GET /api/v1/categories/:id requests
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.Error(common.NewValidationError("Category ID is required"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	response, err := h.service.GetCategory(ctx, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetCategories handles GET /api/v1/categories requests
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	var req GetCategoriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(validation.Translate(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	response, err := h.service.GetCategories(ctx, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetCategoryTree handles GET /api/v1/categories/tree requests
+func (h *CategoryHandler) GetCategoryTree(c *gin.Context) {
+	ctx := c.Request.Context()
+	response, err := h.service.GetCategoryTree(ctx)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateCategory handles PUT /api/v1/categories/:id requests
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.Error(common.NewValidationError("Category ID is required"))
+		return
+	}
+
+	var req UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(validation.Translate(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	response, err := h.service.UpdateCategory(ctx, id, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteCategory handles DELETE /api/v1/categories/:id requests
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.Error(common.NewValidationError("Category ID is required"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.DeleteCategory(ctx, id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Category deleted successfully",
+	})
+}
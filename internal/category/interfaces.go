@@ -0,0 +1,35 @@
+package category
+
+import "context"
+
+// CategoryService defines the interface for category business logic
+type CategoryService interface {
+	CreateCategory(ctx context.Context, req *CreateCategoryRequest) (*CategoryResponse, error)
+	GetCategory(ctx context.Context, id string) (*CategoryResponse, error)
+	GetCategories(ctx context.Context, req *GetCategoriesRequest) (*GetCategoriesResponse, error)
+	GetCategoryTree(ctx context.Context) (*CategoryTreeResponse, error)
+	UpdateCategory(ctx context.Context, id string, req *UpdateCategoryRequest) (*CategoryResponse, error)
+	DeleteCategory(ctx context.Context, id string) error
+}
+
+// CategoryRepository defines the interface for category data access. Create
+// and Update fail with ErrSlugTaken when the slug is not unique. GetAll
+// lists every category, or only the children of parentID when it is set,
+// ordered by name. GetByIDs looks up several categories at once and skips
+// unknown IDs.
+type CategoryRepository interface {
+	Create(ctx context.Context, category *Category) error
+	GetByID(ctx context.Context, id string) (*Category, error)
+	GetByIDs(ctx context.Context, ids []string) ([]*Category, error)
+	GetAll(ctx context.Context, parentID string) ([]*Category, error)
+	Update(ctx context.Context, category *Category) error
+	Delete(ctx context.Context, id string) error
+	Exists(ctx context.Context, id string) (bool, error)
+	CountChildren(ctx context.Context, id string) (int64, error)
+}
+
+// ProductCounter counts the products filed under a category, including
+// trashed ones, so that categories in use are not deleted
+type ProductCounter interface {
+	CountByCategory(ctx context.Context, categoryID string) (int64, error)
+}
@@ -0,0 +1,63 @@
+package category
+
+import "time"
+
+// Category represents a product category. Categories form a tree through
+// ParentID; top-level categories have none.
+type Category struct {
+	ID          string    `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Slug        string    `json:"slug" db:"slug"`
+	Description string    `json:"description" db:"description"`
+	ParentID    *string   `json:"parent_id" db:"parent_id"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateCategoryRequest represents the request for creating a category. The
+// slug is generated from the name unless one is given.
+type CreateCategoryRequest struct {
+	Name        string  `json:"name" binding:"required,max=255"`
+	Slug        string  `json:"slug" binding:"omitempty,max=255"`
+	Description string  `json:"description"`
+	ParentID    *string `json:"parent_id"`
+}
+
+// UpdateCategoryRequest represents the request for updating a category. An
+// empty ParentID moves the category to the top level. Renaming a category
+// keeps its slug unless a new one is given.
+type UpdateCategoryRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Slug        *string `json:"slug" binding:"omitempty,min=1,max=255"`
+	Description *string `json:"description"`
+	ParentID    *string `json:"parent_id"`
+}
+
+// CategoryResponse represents the response for category operations
+type CategoryResponse struct {
+	Category *Category `json:"category"`
+	Message  string    `json:"message,omitempty"`
+}
+
+// GetCategoriesRequest represents the request for listing categories,
+// optionally only the direct children of ParentID
+type GetCategoriesRequest struct {
+	ParentID string `form:"parent_id"`
+}
+
+// GetCategoriesResponse represents the response for listing categories
+type GetCategoriesResponse struct {
+	Categories []*Category `json:"categories"`
+	Total      int         `json:"total"`
+}
+
+// CategoryNode is a category together with its subcategories
+type CategoryNode struct {
+	*Category
+	Children []*CategoryNode `json:"children"`
+}
+
+// CategoryTreeResponse represents every category arranged as a tree
+type CategoryTreeResponse struct {
+	Categories []*CategoryNode `json:"categories"`
+}
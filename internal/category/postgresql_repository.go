@@ -0,0 +1,184 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gin-service/pkg/database/postgresql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// categoriesTable is the table categories are persisted in
+const categoriesTable = "categories"
+
+// PostgreSQL error codes mapped to category errors
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// postgreSQLCategoryRepository implements CategoryRepository backed by PostgreSQL
+type postgreSQLCategoryRepository struct {
+	conn    postgresql.Connection
+	base    postgresql.Repository[Category]
+	columns string
+}
+
+// NewPostgreSQLCategoryRepository creates a new PostgreSQL-backed category repository
+func NewPostgreSQLCategoryRepository(conn postgresql.Connection) (CategoryRepository, error) {
+	base, err := postgresql.NewPostgreSQLRepository[Category](conn, categoriesTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create category repository: %w", err)
+	}
+
+	columns, err := postgresql.Columns[Category]()
+	if err != nil {
+		return nil, fmt.Errorf("failed to map category columns: %w", err)
+	}
+
+	return &postgreSQLCategoryRepository{
+		conn:    conn,
+		base:    base,
+		columns: strings.Join(columns, ", "),
+	}, nil
+}
+
+// Create inserts a new category
+func (r *postgreSQLCategoryRepository) Create(ctx context.Context, category *Category) error {
+	if category.ID == "" {
+		category.ID = uuid.New().String()
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	category.CreatedAt = now
+	category.UpdatedAt = now
+
+	if err := r.base.Create(ctx, category); err != nil {
+		return categoryError("failed to insert category", category, err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a category by ID
+func (r *postgreSQLCategoryRepository) GetByID(ctx context.Context, id string) (*Category, error) {
+	category, err := r.base.GetByID(ctx, id)
+	if errors.Is(err, postgresql.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrCategoryNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	return category, nil
+}
+
+// GetByIDs retrieves the categories with the given IDs, skipping unknown IDs
+func (r *postgreSQLCategoryRepository) GetByIDs(ctx context.Context, ids []string) ([]*Category, error) {
+	statement := fmt.Sprintf("SELECT %s FROM %s WHERE id = ANY($1)", r.columns, categoriesTable)
+
+	rows, err := postgresql.ExecutorFromContext(ctx, r.conn.GetDB()).QueryContext(ctx, statement, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := make([]*Category, 0, len(ids))
+	for rows.Next() {
+		var category Category
+		if err := postgresql.ScanStruct(rows, &category); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		categories = append(categories, &category)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+// GetAll lists every category, or only the children of parentID, by name
+func (r *postgreSQLCategoryRepository) GetAll(ctx context.Context, parentID string) ([]*Category, error) {
+	var (
+		categories []*Category
+		err        error
+	)
+	if parentID == "" {
+		count, countErr := r.base.Count(ctx, nil)
+		if countErr != nil {
+			return nil, fmt.Errorf("failed to count categories: %w", countErr)
+		}
+		categories, err = r.base.GetAll(ctx, int(count), 0)
+	} else {
+		categories, err = r.base.FindBy(ctx, map[string]interface{}{"parent_id": parentID})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	sortByName(categories)
+	return categories, nil
+}
+
+// Update replaces a stored category
+func (r *postgreSQLCategoryRepository) Update(ctx context.Context, category *Category) error {
+	category.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	err := r.base.Update(ctx, category)
+	if errors.Is(err, postgresql.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrCategoryNotFound, category.ID)
+	}
+	if err != nil {
+		return categoryError("failed to update category", category, err)
+	}
+
+	return nil
+}
+
+// Delete removes a category. The foreign keys on subcategories and products
+// reject deleting a category that is still referenced.
+func (r *postgreSQLCategoryRepository) Delete(ctx context.Context, id string) error {
+	err := r.base.Delete(ctx, id)
+	if errors.Is(err, postgresql.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrCategoryNotFound, id)
+	}
+	if err != nil {
+		return categoryError("failed to delete category", &Category{ID: id}, err)
+	}
+
+	return nil
+}
+
+// Exists checks if a category exists by ID
+func (r *postgreSQLCategoryRepository) Exists(ctx context.Context, id string) (bool, error) {
+	return r.base.Exists(ctx, id)
+}
+
+// CountChildren returns the number of direct subcategories of a category
+func (r *postgreSQLCategoryRepository) CountChildren(ctx context.Context, id string) (int64, error) {
+	return r.base.Count(ctx, map[string]interface{}{"parent_id": id})
+}
+
+// categoryError maps constraint violations to category errors
+func categoryError(message string, category *Category, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case uniqueViolation:
+			return fmt.Errorf("%w: %s", ErrSlugTaken, category.Slug)
+		case foreignKeyViolation:
+			if category.ParentID != nil && pqErr.Constraint == "categories_parent_id_fkey" {
+				return fmt.Errorf("%w: parent %s", ErrCategoryNotFound, *category.ParentID)
+			}
+			return fmt.Errorf("%w: %s", ErrCategoryInUse, category.ID)
+		}
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
@@ -0,0 +1,183 @@
+package category
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// categoryRepository implements CategoryRepository in memory
+type categoryRepository struct {
+	categories map[string]*Category
+	mutex      sync.RWMutex
+}
+
+// NewCategoryRepository creates a new in-memory category repository
+func NewCategoryRepository() CategoryRepository {
+	return &categoryRepository{
+		categories: make(map[string]*Category),
+	}
+}
+
+// Create stores a new category
+func (r *categoryRepository) Create(ctx context.Context, category *Category) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.slugTaken(category.Slug, "") {
+		return fmt.Errorf("%w: %s", ErrSlugTaken, category.Slug)
+	}
+
+	if category.ID == "" {
+		category.ID = uuid.New().String()
+	}
+
+	now := time.Now()
+	category.CreatedAt = now
+	category.UpdatedAt = now
+
+	r.categories[category.ID] = cloneCategory(category)
+	return nil
+}
+
+// GetByID retrieves a category by ID
+func (r *categoryRepository) GetByID(ctx context.Context, id string) (*Category, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	category, exists := r.categories[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCategoryNotFound, id)
+	}
+
+	return cloneCategory(category), nil
+}
+
+// GetByIDs retrieves the categories with the given IDs, skipping unknown IDs
+func (r *categoryRepository) GetByIDs(ctx context.Context, ids []string) ([]*Category, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	categories := make([]*Category, 0, len(ids))
+	for _, id := range ids {
+		if category, exists := r.categories[id]; exists {
+			categories = append(categories, cloneCategory(category))
+		}
+	}
+
+	return categories, nil
+}
+
+// GetAll lists every category, or only the children of parentID, by name
+func (r *categoryRepository) GetAll(ctx context.Context, parentID string) ([]*Category, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	categories := make([]*Category, 0, len(r.categories))
+	for _, category := range r.categories {
+		if parentID != "" && (category.ParentID == nil || *category.ParentID != parentID) {
+			continue
+		}
+		categories = append(categories, cloneCategory(category))
+	}
+
+	sortByName(categories)
+	return categories, nil
+}
+
+// Update replaces a stored category
+func (r *categoryRepository) Update(ctx context.Context, category *Category) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.categories[category.ID]; !exists {
+		return fmt.Errorf("%w: %s", ErrCategoryNotFound, category.ID)
+	}
+	if r.slugTaken(category.Slug, category.ID) {
+		return fmt.Errorf("%w: %s", ErrSlugTaken, category.Slug)
+	}
+
+	category.UpdatedAt = time.Now()
+	r.categories[category.ID] = cloneCategory(category)
+	return nil
+}
+
+// Delete removes a category, refusing while it still has subcategories
+func (r *categoryRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.categories[id]; !exists {
+		return fmt.Errorf("%w: %s", ErrCategoryNotFound, id)
+	}
+	if r.countChildren(id) > 0 {
+		return fmt.Errorf("%w: %s has subcategories", ErrCategoryInUse, id)
+	}
+
+	delete(r.categories, id)
+	return nil
+}
+
+// Exists checks if a category exists by ID
+func (r *categoryRepository) Exists(ctx context.Context, id string) (bool, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, exists := r.categories[id]
+	return exists, nil
+}
+
+// CountChildren returns the number of direct subcategories of a category
+func (r *categoryRepository) CountChildren(ctx context.Context, id string) (int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.countChildren(id), nil
+}
+
+// countChildren counts the direct subcategories of id. The caller must hold
+// the lock.
+func (r *categoryRepository) countChildren(id string) int64 {
+	var count int64
+	for _, category := range r.categories {
+		if category.ParentID != nil && *category.ParentID == id {
+			count++
+		}
+	}
+	return count
+}
+
+// slugTaken reports whether a category other than exceptID uses slug. The
+// caller must hold the lock.
+func (r *categoryRepository) slugTaken(slug, exceptID string) bool {
+	for _, category := range r.categories {
+		if category.Slug == slug && category.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// sortByName orders categories by name, breaking ties by ID
+func sortByName(categories []*Category) {
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Name != categories[j].Name {
+			return categories[i].Name < categories[j].Name
+		}
+		return categories[i].ID < categories[j].ID
+	})
+}
+
+// cloneCategory returns a copy of category
+func cloneCategory(category *Category) *Category {
+	clone := *category
+	if category.ParentID != nil {
+		parentID := *category.ParentID
+		clone.ParentID = &parentID
+	}
+	return &clone
+}
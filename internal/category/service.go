@@ -0,0 +1,271 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gin-service/pkg/common"
+	"gin-service/pkg/utils"
+)
+
+// maxSlugAttempts caps the numbered suffixes tried when a generated slug is taken
+const maxSlugAttempts = 100
+
+// categoryService implements CategoryService interface
+type categoryService struct {
+	repository CategoryRepository
+	products   ProductCounter
+	strings    *utils.StringUtils
+}
+
+// NewCategoryService creates a new category service instance. products is
+// consulted before deleting a category so that no product is left without one.
+func NewCategoryService(repository CategoryRepository, products ProductCounter) CategoryService {
+	return &categoryService{
+		repository: repository,
+		products:   products,
+		strings:    utils.NewStringUtils(),
+	}
+}
+
+// CreateCategory handles category creation business logic. A slug generated
+// from the name gets a numbered suffix when it is already taken; an explicit
+// slug that is taken is rejected.
+func (s *categoryService) CreateCategory(ctx context.Context, req *CreateCategoryRequest) (*CategoryResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, common.NewValidationError("category name is required")
+	}
+
+	category := &Category{
+		Name:        name,
+		Description: req.Description,
+	}
+
+	if req.ParentID != nil && *req.ParentID != "" {
+		if err := s.checkParent(ctx, "", *req.ParentID); err != nil {
+			return nil, err
+		}
+		parentID := *req.ParentID
+		category.ParentID = &parentID
+	}
+
+	explicit := req.Slug != ""
+	source := req.Slug
+	if !explicit {
+		source = name
+	}
+	slug, err := s.slug(source)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		category.Slug = slug
+		if attempt > 1 {
+			category.Slug = fmt.Sprintf("%s-%d", slug, attempt)
+		}
+
+		err = s.repository.Create(ctx, category)
+		if !errors.Is(err, ErrSlugTaken) || explicit || attempt == maxSlugAttempts {
+			break
+		}
+	}
+	if err != nil {
+		return nil, repositoryError("failed to create category", err)
+	}
+
+	return &CategoryResponse{
+		Category: category,
+		Message:  "Category created successfully",
+	}, nil
+}
+
+// GetCategory handles category retrieval business logic
+func (s *categoryService) GetCategory(ctx context.Context, id string) (*CategoryResponse, error) {
+	if id == "" {
+		return nil, common.NewValidationError("category ID is required")
+	}
+
+	category, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, repositoryError("failed to get category", err)
+	}
+
+	return &CategoryResponse{
+		Category: category,
+	}, nil
+}
+
+// GetCategories handles category listing business logic
+func (s *categoryService) GetCategories(ctx context.Context, req *GetCategoriesRequest) (*GetCategoriesResponse, error) {
+	categories, err := s.repository.GetAll(ctx, req.ParentID)
+	if err != nil {
+		return nil, repositoryError("failed to get categories", err)
+	}
+
+	return &GetCategoriesResponse{
+		Categories: categories,
+		Total:      len(categories),
+	}, nil
+}
+
+// GetCategoryTree arranges every category under its parent
+func (s *categoryService) GetCategoryTree(ctx context.Context) (*CategoryTreeResponse, error) {
+	categories, err := s.repository.GetAll(ctx, "")
+	if err != nil {
+		return nil, repositoryError("failed to get categories", err)
+	}
+
+	nodes := make(map[string]*CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &CategoryNode{Category: category, Children: []*CategoryNode{}}
+	}
+
+	// Categories are sorted by name, so children are appended in name order
+	roots := make([]*CategoryNode, 0)
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID != nil {
+			if parent, ok := nodes[*category.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	return &CategoryTreeResponse{
+		Categories: roots,
+	}, nil
+}
+
+// UpdateCategory handles category update business logic
+func (s *categoryService) UpdateCategory(ctx context.Context, id string, req *UpdateCategoryRequest) (*CategoryResponse, error) {
+	if id == "" {
+		return nil, common.NewValidationError("category ID is required")
+	}
+
+	category, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, repositoryError("failed to get category", err)
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, common.NewValidationError("category name cannot be empty")
+		}
+		category.Name = name
+	}
+	if req.Description != nil {
+		category.Description = *req.Description
+	}
+	if req.Slug != nil {
+		if category.Slug, err = s.slug(*req.Slug); err != nil {
+			return nil, err
+		}
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == "" {
+			category.ParentID = nil
+		} else {
+			if err := s.checkParent(ctx, id, *req.ParentID); err != nil {
+				return nil, err
+			}
+			parentID := *req.ParentID
+			category.ParentID = &parentID
+		}
+	}
+
+	if err := s.repository.Update(ctx, category); err != nil {
+		return nil, repositoryError("failed to update category", err)
+	}
+
+	return &CategoryResponse{
+		Category: category,
+		Message:  "Category updated successfully",
+	}, nil
+}
+
+// DeleteCategory handles category deletion business logic. Categories with
+// subcategories or products, including trashed ones, cannot be deleted.
+func (s *categoryService) DeleteCategory(ctx context.Context, id string) error {
+	if id == "" {
+		return common.NewValidationError("category ID is required")
+	}
+
+	if _, err := s.repository.GetByID(ctx, id); err != nil {
+		return repositoryError("failed to get category", err)
+	}
+
+	children, err := s.repository.CountChildren(ctx, id)
+	if err != nil {
+		return repositoryError("failed to count subcategories", err)
+	}
+	if children > 0 {
+		return common.NewConflictError("Category still has subcategories")
+	}
+
+	products, err := s.products.CountByCategory(ctx, id)
+	if err != nil {
+		return repositoryError("failed to count category products", err)
+	}
+	if products > 0 {
+		return common.NewConflictError("Category still has products")
+	}
+
+	if err := s.repository.Delete(ctx, id); err != nil {
+		return repositoryError("failed to delete category", err)
+	}
+
+	return nil
+}
+
+// slug turns source into a slug, rejecting sources without letters or digits
+func (s *categoryService) slug(source string) (string, error) {
+	slug := s.strings.Slugify(source)
+	if slug == "" {
+		return "", common.NewValidationError("category slug must contain letters or digits")
+	}
+	return slug, nil
+}
+
+// checkParent verifies that parentID exists and, when id is set, that it is
+// neither the category itself nor one of its descendants
+func (s *categoryService) checkParent(ctx context.Context, id, parentID string) error {
+	for ancestorID := parentID; ; {
+		if ancestorID == id {
+			return common.NewValidationError("category cannot be moved under itself or one of its subcategories")
+		}
+
+		ancestor, err := s.repository.GetByID(ctx, ancestorID)
+		if errors.Is(err, ErrCategoryNotFound) {
+			return common.NewValidationError(fmt.Sprintf("parent category not found: %s", parentID))
+		}
+		if err != nil {
+			return repositoryError("failed to get parent category", err)
+		}
+
+		if id == "" || ancestor.ParentID == nil {
+			return nil
+		}
+		ancestorID = *ancestor.ParentID
+	}
+}
+
+// repositoryError maps repository errors to application errors
+func repositoryError(message string, err error) error {
+	switch {
+	case errors.Is(err, ErrCategoryNotFound):
+		return common.NewNotFoundErrorWithErr("Category not found", err)
+	case errors.Is(err, ErrSlugTaken):
+		return common.NewConflictErrorWithErr(ErrSlugTaken.Error(), err)
+	case errors.Is(err, ErrCategoryInUse):
+		return common.NewConflictErrorWithErr(ErrCategoryInUse.Error(), err)
+	default:
+		return common.NewInternalErrorWithErr(message, err)
+	}
+}
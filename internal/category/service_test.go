@@ -0,0 +1,116 @@
+package category
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"gin-service/pkg/common"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// productCounts is a ProductCounter backed by a map of category IDs
+type productCounts map[string]int64
+
+func (c productCounts) CountByCategory(ctx context.Context, categoryID string) (int64, error) {
+	return c[categoryID], nil
+}
+
+func create(t *testing.T, service CategoryService, req *CreateCategoryRequest) *Category {
+	t.Helper()
+	response, err := service.CreateCategory(context.Background(), req)
+	require.NoError(t, err)
+	return response.Category
+}
+
+func TestCategoryService_GeneratesSlugs(t *testing.T) {
+	ctx := context.Background()
+	service := NewCategoryService(NewCategoryRepository(), productCounts{})
+
+	first := create(t, service, &CreateCategoryRequest{Name: "  Home & Garden!"})
+	assert.Equal(t, "Home & Garden!", first.Name)
+	assert.Equal(t, "home-garden", first.Slug)
+
+	second := create(t, service, &CreateCategoryRequest{Name: "Home - Garden"})
+	assert.Equal(t, "home-garden-2", second.Slug)
+
+	_, err := service.CreateCategory(ctx, &CreateCategoryRequest{Name: "Other", Slug: "Home Garden"})
+	assert.Equal(t, http.StatusConflict, common.GetHTTPStatus(err))
+
+	_, err = service.CreateCategory(ctx, &CreateCategoryRequest{Name: "???"})
+	assert.Equal(t, http.StatusBadRequest, common.GetHTTPStatus(err))
+}
+
+func TestCategoryService_Hierarchy(t *testing.T) {
+	ctx := context.Background()
+	service := NewCategoryService(NewCategoryRepository(), productCounts{})
+
+	home := create(t, service, &CreateCategoryRequest{Name: "Home"})
+	kitchen := create(t, service, &CreateCategoryRequest{Name: "Kitchen", ParentID: &home.ID})
+	cookware := create(t, service, &CreateCategoryRequest{Name: "Cookware", ParentID: &kitchen.ID})
+	create(t, service, &CreateCategoryRequest{Name: "Bath", ParentID: &home.ID})
+
+	missing := "missing"
+	_, err := service.CreateCategory(ctx, &CreateCategoryRequest{Name: "Orphan", ParentID: &missing})
+	assert.Equal(t, http.StatusBadRequest, common.GetHTTPStatus(err))
+
+	_, err = service.UpdateCategory(ctx, home.ID, &UpdateCategoryRequest{ParentID: &cookware.ID})
+	assert.Equal(t, http.StatusBadRequest, common.GetHTTPStatus(err))
+	_, err = service.UpdateCategory(ctx, home.ID, &UpdateCategoryRequest{ParentID: &home.ID})
+	assert.Equal(t, http.StatusBadRequest, common.GetHTTPStatus(err))
+
+	children, err := service.GetCategories(ctx, &GetCategoriesRequest{ParentID: home.ID})
+	require.NoError(t, err)
+	require.Len(t, children.Categories, 2)
+	assert.Equal(t, "Bath", children.Categories[0].Name)
+
+	tree, err := service.GetCategoryTree(ctx)
+	require.NoError(t, err)
+	require.Len(t, tree.Categories, 1)
+	require.Len(t, tree.Categories[0].Children, 2)
+	assert.Equal(t, "Kitchen", tree.Categories[0].Children[1].Name)
+	assert.Equal(t, "Cookware", tree.Categories[0].Children[1].Children[0].Name)
+
+	top := ""
+	moved, err := service.UpdateCategory(ctx, cookware.ID, &UpdateCategoryRequest{ParentID: &top})
+	require.NoError(t, err)
+	assert.Nil(t, moved.Category.ParentID)
+}
+
+func TestCategoryService_DeleteChecksReferences(t *testing.T) {
+	ctx := context.Background()
+	counts := productCounts{}
+	service := NewCategoryService(NewCategoryRepository(), counts)
+
+	home := create(t, service, &CreateCategoryRequest{Name: "Home"})
+	kitchen := create(t, service, &CreateCategoryRequest{Name: "Kitchen", ParentID: &home.ID})
+	counts[kitchen.ID] = 2
+
+	err := service.DeleteCategory(ctx, home.ID)
+	assert.Equal(t, http.StatusConflict, common.GetHTTPStatus(err))
+	err = service.DeleteCategory(ctx, kitchen.ID)
+	assert.Equal(t, http.StatusConflict, common.GetHTTPStatus(err))
+
+	delete(counts, kitchen.ID)
+	require.NoError(t, service.DeleteCategory(ctx, kitchen.ID))
+	require.NoError(t, service.DeleteCategory(ctx, home.ID))
+
+	_, err = service.GetCategory(ctx, home.ID)
+	assert.Equal(t, http.StatusNotFound, common.GetHTTPStatus(err))
+}
+
+func TestExpansion_ResolvesAPageAtOnce(t *testing.T) {
+	ctx := context.Background()
+	repository := NewCategoryRepository()
+	service := NewCategoryService(repository, productCounts{})
+	home := create(t, service, &CreateCategoryRequest{Name: "Home"})
+	garden := create(t, service, &CreateCategoryRequest{Name: "Garden"})
+
+	resolved, err := Expansion(repository).Resolve(ctx, []string{home.ID, "missing", garden.ID})
+	require.NoError(t, err)
+	assert.Len(t, resolved, 2)
+	assert.Equal(t, "Home", resolved[home.ID].(*Category).Name)
+	assert.Equal(t, "Garden", resolved[garden.ID].(*Category).Name)
+}
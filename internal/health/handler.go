@@ -67,3 +67,49 @@ func (h *HealthHandler) GetLiveness(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// GetStartup handles GET /api/v1/health/startup requests
+func (h *HealthHandler) GetStartup(c *gin.Context) {
+	ctx := c.Request.Context()
+	
+	response, err := h.service.GetStartup(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get startup status",
+		})
+		return
+	}
+
+	// Return appropriate status code based on startup
+	if response.Status == "started" {
+		c.JSON(http.StatusOK, response)
+	} else {
+		c.JSON(http.StatusServiceUnavailable, response)
+	}
+}
+
+// GetVersion handles GET /api/v1/version requests
+func (h *HealthHandler) GetVersion(c *gin.Context) {
+	response, err := h.service.GetVersion(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get version",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetHistory handles GET /api/v1/health/history requests
+func (h *HealthHandler) GetHistory(c *gin.Context) {
+	response, err := h.service.GetHistory(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get health history",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
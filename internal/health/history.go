@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"gin-service/pkg/logger"
+)
+
+// DefaultHistorySize is the number of transitions kept per check when the
+// registry is created without a size
+const DefaultHistorySize = 20
+
+// Subscriber is called with every status transition of a check. Subscribers
+// run on the goroutine that polled the check, one after another, so they
+// should hand slow work off rather than block polling. A subscriber that
+// panics is logged and does not keep the others from being called.
+type Subscriber func(transition Transition)
+
+// Subscribe registers a callback for status transitions, for example to
+// switch to maintenance mode or to raise an alert
+func (r *Registry) Subscribe(subscriber Subscriber) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, subscriber)
+}
+
+// History returns the recorded transitions of every check in registration
+// order, oldest transition first
+func (r *Registry) History() []CheckHistory {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	histories := make([]CheckHistory, 0, len(r.entries))
+	for _, e := range r.entries {
+		histories = append(histories, CheckHistory{
+			Name:        e.check.Name,
+			Transitions: e.history.items(),
+		})
+	}
+	return histories
+}
+
+// announce logs a transition and passes it to every subscriber
+func (r *Registry) announce(ctx context.Context, transition Transition) {
+	fields := logger.Fields{
+		"check":                transition.Check,
+		"criticality":          transition.Criticality,
+		"from":                 transition.From,
+		"to":                   transition.To,
+		"consecutive_failures": transition.ConsecutiveFailures,
+	}
+	if transition.Error != "" {
+		fields["error"] = transition.Error
+	}
+	if transition.To == CheckStatusFailing {
+		r.logger.Warn(ctx, "Health check status changed", fields)
+	} else {
+		r.logger.Info(ctx, "Health check status changed", fields)
+	}
+
+	r.mu.Lock()
+	subscribers := append([]Subscriber(nil), r.subscribers...)
+	r.mu.Unlock()
+
+	for _, subscriber := range subscribers {
+		r.notify(ctx, subscriber, transition)
+	}
+}
+
+// notify passes a transition to one subscriber, logging a panic instead of
+// letting it stop the polling goroutine
+func (r *Registry) notify(ctx context.Context, subscriber Subscriber, transition Transition) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			r.logger.Error(ctx, "Health check subscriber panicked", fmt.Errorf("%v", recovered), logger.Fields{
+				"check": transition.Check,
+				"to":    transition.To,
+			})
+		}
+	}()
+	subscriber(transition)
+}
+
+// transitionRing keeps the most recent transitions of a check, overwriting
+// the oldest one once it is full
+type transitionRing struct {
+	buffer []Transition
+	next   int
+	full   bool
+}
+
+// newTransitionRing creates a ring that holds size transitions
+func newTransitionRing(size int) *transitionRing {
+	return &transitionRing{buffer: make([]Transition, size)}
+}
+
+// add records a transition
+func (t *transitionRing) add(transition Transition) {
+	t.buffer[t.next] = transition
+	t.next = (t.next + 1) % len(t.buffer)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// items returns the recorded transitions, oldest first
+func (t *transitionRing) items() []Transition {
+	if !t.full {
+		return append([]Transition{}, t.buffer[:t.next]...)
+	}
+	return append(append([]Transition{}, t.buffer[t.next:]...), t.buffer[:t.next]...)
+}
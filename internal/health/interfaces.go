@@ -2,16 +2,22 @@ package health
 
 import "context"
 
-// HealthService defines the interface for health business logic
+// HealthService defines the interface for health business logic. The
+// service reports not ready until MarkStarted is called and again from the
+// moment MarkDraining is called.
 type HealthService interface {
 	GetHealth(ctx context.Context) (*HealthResponse, error)
 	GetReadiness(ctx context.Context) (*HealthResponse, error)
 	GetLiveness(ctx context.Context) (*HealthResponse, error)
+	GetStartup(ctx context.Context) (*HealthResponse, error)
+	GetVersion(ctx context.Context) (*VersionResponse, error)
+	GetHistory(ctx context.Context) (*HistoryResponse, error)
+	MarkStarted()
+	MarkDraining()
+	Started() bool
 }
 
 // HealthRepository defines the interface for health data access
 type HealthRepository interface {
 	GetSystemStatus(ctx context.Context) (*SystemStatus, error)
-	CheckDatabaseConnection(ctx context.Context) error
-	CheckExternalServices(ctx context.Context) error
 }
@@ -1,6 +1,10 @@
 package health
 
-import "time"
+import (
+	"time"
+
+	"gin-service/pkg/version"
+)
 
 // HealthResponse represents the health check response
 type HealthResponse struct {
@@ -8,21 +12,72 @@ type HealthResponse struct {
 	Timestamp time.Time `json:"timestamp"`
 	Service   string    `json:"service"`
 	Version   string    `json:"version"`
+	Build     version.Info `json:"build"`
 	Details   *HealthDetails `json:"details,omitempty"`
 }
 
 // HealthDetails contains detailed health information
 type HealthDetails struct {
-	Database   string `json:"database,omitempty"`
-	ExternalServices []string `json:"external_services,omitempty"`
-	Uptime     string `json:"uptime,omitempty"`
+	Checks []CheckResult `json:"checks"`
+	Uptime string        `json:"uptime,omitempty"`
+}
+
+// Check statuses
+const (
+	CheckStatusUnknown = "unknown"
+	CheckStatusPassing = "passing"
+	CheckStatusFailing = "failing"
+)
+
+// CheckResult reports the latest outcome of a registered check. LastError
+// holds the message of the most recent failure, even after a recovery.
+// ConsecutiveFailures counts the failed runs since the last success.
+type CheckResult struct {
+	Name                string      `json:"name"`
+	Criticality         Criticality `json:"criticality"`
+	Status              string      `json:"status"`
+	Latency             string      `json:"latency,omitempty"`
+	LastError           string      `json:"last_error,omitempty"`
+	CheckedAt           *time.Time  `json:"checked_at,omitempty"`
+	ConsecutiveFailures int         `json:"consecutive_failures,omitempty"`
 }
 
 // SystemStatus represents the overall system status
 type SystemStatus struct {
-	IsHealthy bool      `json:"is_healthy"`
-	Uptime    time.Time `json:"uptime"`
-	Version   string    `json:"version"`
+	IsHealthy bool         `json:"is_healthy"`
+	Uptime    time.Time    `json:"uptime"`
+	Build     version.Info `json:"build"`
+}
+
+// Transition records a change of a check's status. Error holds the failure
+// that caused a transition to failing.
+type Transition struct {
+	Check               string      `json:"check"`
+	Criticality         Criticality `json:"criticality"`
+	From                string      `json:"from"`
+	To                  string      `json:"to"`
+	At                  time.Time   `json:"at"`
+	Error               string      `json:"error,omitempty"`
+	ConsecutiveFailures int         `json:"consecutive_failures,omitempty"`
+}
+
+// CheckHistory lists the recorded transitions of one check, oldest first
+type CheckHistory struct {
+	Name        string       `json:"name"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// HistoryResponse represents the health history response
+type HistoryResponse struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Service   string         `json:"service"`
+	Checks    []CheckHistory `json:"checks"`
+}
+
+// VersionResponse describes the build of the running service
+type VersionResponse struct {
+	Service string `json:"service"`
+	version.Info
 }
 
 // HealthRequest represents health check request (for future use)
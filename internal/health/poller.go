@@ -0,0 +1,83 @@
+package health
+
+import (
+	"context"
+	"time"
+)
+
+// Start runs every check once and then keeps polling each check on its own
+// schedule in the background, so that handlers can serve cached results
+// instead of probing dependencies on every request. Polling ends when ctx is
+// cancelled or Stop is called. A registry is started at most once.
+func (r *Registry) Start(ctx context.Context) {
+	r.mu.Lock()
+	if r.cancel != nil {
+		r.mu.Unlock()
+		return
+	}
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.mu.Unlock()
+
+	r.Run(ctx)
+
+	for _, e := range r.snapshot() {
+		r.wg.Add(1)
+		go func(e *entry) {
+			defer r.wg.Done()
+			r.poll(ctx, e)
+		}(e)
+	}
+}
+
+// Stop ends background polling and waits for checks in flight to return
+func (r *Registry) Stop() {
+	r.mu.Lock()
+	cancel := r.cancel
+	r.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	r.wg.Wait()
+}
+
+// poll runs the check of an entry until ctx is cancelled, waiting the delay
+// its latest result calls for between runs
+func (r *Registry) poll(ctx context.Context, e *entry) {
+	timer := time.NewTimer(r.nextDelay(e))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		r.runEntry(ctx, e)
+		timer.Reset(r.nextDelay(e))
+	}
+}
+
+// nextDelay returns how long to wait before running the check of an entry
+// again: its interval, doubled for every failure in a row up to its maximum
+// backoff
+func (r *Registry) nextDelay(e *entry) time.Duration {
+	r.mu.Lock()
+	failures := e.result.ConsecutiveFailures
+	r.mu.Unlock()
+
+	return backoff(e.check.Interval, e.check.MaxBackoff, failures)
+}
+
+// backoff doubles interval once per failure without exceeding limit
+func backoff(interval, limit time.Duration, failures int) time.Duration {
+	delay := interval
+	for i := 0; i < failures && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		return limit
+	}
+	return delay
+}
@@ -0,0 +1,288 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gin-service/pkg/constants"
+	"gin-service/pkg/logger"
+)
+
+// Criticality tells how a failing check affects the overall status
+type Criticality string
+
+const (
+	// Critical checks make the service unhealthy and not ready when they fail
+	Critical Criticality = "critical"
+	// Degraded checks only degrade the service when they fail
+	Degraded Criticality = "degraded"
+)
+
+// Defaults for the fields of a check that are left zero
+const (
+	DefaultCheckTimeout  = 2 * time.Second
+	DefaultCheckInterval = 10 * time.Second
+	DefaultMaxBackoff    = time.Minute
+)
+
+// CheckFunc probes a dependency and returns nil when it is healthy
+type CheckFunc func(ctx context.Context) error
+
+// Check is a named dependency probe. While polling, it runs every Interval
+// and backs off exponentially up to MaxBackoff while it keeps failing. Its
+// status only flips to failing after FailureThreshold failures in a row and
+// back to passing after SuccessThreshold successes in a row, so a single
+// slow probe does not flap readiness.
+type Check struct {
+	Name             string
+	Timeout          time.Duration
+	Criticality      Criticality
+	Interval         time.Duration
+	SuccessThreshold int
+	FailureThreshold int
+	MaxBackoff       time.Duration
+	Run              CheckFunc
+}
+
+// ProbeCheck adapts a probe that reports health as a boolean, such as
+// database.Manager.IsHealthy, to a CheckFunc
+func ProbeCheck(probe func(ctx context.Context) (bool, error)) CheckFunc {
+	return func(ctx context.Context) error {
+		healthy, err := probe(ctx)
+		if err != nil {
+			return err
+		}
+		if !healthy {
+			return errors.New("probe reported unhealthy")
+		}
+		return nil
+	}
+}
+
+// Registry holds the checks components registered, the outcome of their
+// latest runs and a bounded history of their status transitions. Results
+// are reported in registration order.
+type Registry struct {
+	mu          sync.Mutex
+	entries     []*entry
+	byName      map[string]*entry
+	historySize int
+	subscribers []Subscriber
+	logger      logger.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// entry is a registered check with its current result, streaks and
+// transitions
+type entry struct {
+	check     Check
+	result    CheckResult
+	successes int
+	history   *transitionRing
+}
+
+// NewRegistry creates an empty check registry that keeps the last
+// historySize transitions of every check, DefaultHistorySize when zero, and
+// logs each transition
+func NewRegistry(historySize int, log logger.Logger) *Registry {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Registry{
+		byName:      make(map[string]*entry),
+		historySize: historySize,
+		logger:      log,
+	}
+}
+
+// Register adds a check. Names must be unique. A zero timeout, interval or
+// maximum backoff falls back to its default, zero thresholds to one and an
+// empty criticality to Critical.
+func (r *Registry) Register(check Check) error {
+	if check.Name == "" || check.Run == nil {
+		return errors.New("health check needs a name and a function")
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = DefaultCheckTimeout
+	}
+	if check.Interval <= 0 {
+		check.Interval = DefaultCheckInterval
+	}
+	if check.MaxBackoff <= 0 {
+		check.MaxBackoff = DefaultMaxBackoff
+	}
+	if check.MaxBackoff < check.Interval {
+		check.MaxBackoff = check.Interval
+	}
+	if check.SuccessThreshold <= 0 {
+		check.SuccessThreshold = 1
+	}
+	if check.FailureThreshold <= 0 {
+		check.FailureThreshold = 1
+	}
+	if check.Criticality == "" {
+		check.Criticality = Critical
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byName[check.Name]; ok {
+		return fmt.Errorf("health check %q is already registered", check.Name)
+	}
+	e := &entry{
+		check: check,
+		result: CheckResult{
+			Name:        check.Name,
+			Criticality: check.Criticality,
+			Status:      CheckStatusUnknown,
+		},
+		history: newTransitionRing(r.historySize),
+	}
+	r.entries = append(r.entries, e)
+	r.byName[check.Name] = e
+	return nil
+}
+
+// Run runs every check once, concurrently and each under its own timeout,
+// and returns the results in registration order
+func (r *Registry) Run(ctx context.Context) []CheckResult {
+	var wg sync.WaitGroup
+	for _, e := range r.snapshot() {
+		wg.Add(1)
+		go func(e *entry) {
+			defer wg.Done()
+			r.runEntry(ctx, e)
+		}(e)
+	}
+	wg.Wait()
+
+	return r.Results()
+}
+
+// Results returns the latest result of every check in registration order
+// without running any of them
+func (r *Registry) Results() []CheckResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make([]CheckResult, 0, len(r.entries))
+	for _, e := range r.entries {
+		results = append(results, e.result)
+	}
+	return results
+}
+
+// snapshot returns the registered entries
+func (r *Registry) snapshot() []*entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*entry(nil), r.entries...)
+}
+
+// runEntry runs the check of an entry, records the outcome and announces
+// the transition it causes, if any
+func (r *Registry) runEntry(ctx context.Context, e *entry) {
+	latency, err := runCheck(ctx, e.check)
+	if transition := r.record(e, latency, err); transition != nil {
+		r.announce(ctx, *transition)
+	}
+}
+
+// record stores the outcome of one run of a check and returns the
+// transition it causes, nil when the status stays the same. A check that
+// has not reported yet takes the status of its first run; afterwards the
+// status only changes once the check's threshold is reached. The last error
+// is kept after the check recovers so that the cause of a past failure
+// stays visible.
+func (r *Registry) record(e *entry, latency time.Duration, err error) *Transition {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := &e.result
+	previous := result.Status
+	checkedAt := time.Now()
+	result.Latency = latency.String()
+	result.CheckedAt = &checkedAt
+
+	if err != nil {
+		e.successes = 0
+		result.ConsecutiveFailures++
+		result.LastError = err.Error()
+		if result.Status == CheckStatusUnknown || result.ConsecutiveFailures >= e.check.FailureThreshold {
+			result.Status = CheckStatusFailing
+		}
+	} else {
+		e.successes++
+		result.ConsecutiveFailures = 0
+		if result.Status == CheckStatusUnknown || e.successes >= e.check.SuccessThreshold {
+			result.Status = CheckStatusPassing
+		}
+	}
+
+	if result.Status == previous {
+		return nil
+	}
+	transition := Transition{
+		Check:               e.check.Name,
+		Criticality:         e.check.Criticality,
+		From:                previous,
+		To:                  result.Status,
+		At:                  checkedAt,
+		ConsecutiveFailures: result.ConsecutiveFailures,
+	}
+	if err != nil {
+		transition.Error = err.Error()
+	}
+	e.history.add(transition)
+	return &transition
+}
+
+// runCheck runs one check under its timeout and measures how long it took
+func runCheck(ctx context.Context, check Check) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("check panicked: %v", recovered)
+			}
+		}()
+		done <- check.Run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("check timed out after %s", check.Timeout)
+	}
+	return time.Since(start), err
+}
+
+// overallStatus folds check results into healthy, degraded or unhealthy.
+// Checks that have not run yet do not count against the service.
+func overallStatus(results []CheckResult) string {
+	status := constants.HealthStatusHealthy
+	for _, result := range results {
+		if result.Status != CheckStatusFailing {
+			continue
+		}
+		if result.Criticality == Critical {
+			return constants.HealthStatusUnhealthy
+		}
+		status = constants.HealthStatusDegraded
+	}
+	return status
+}
@@ -3,6 +3,8 @@ package health
 import (
 	"context"
 	"time"
+
+	"gin-service/pkg/version"
 )
 
 // healthRepository implements HealthRepository interface
@@ -22,20 +24,6 @@ func (r *healthRepository) GetSystemStatus(ctx context.Context) (*SystemStatus,
 	return &SystemStatus{
 		IsHealthy: true,
 		Uptime:    r.startTime,
-		Version:   "1.0.0",
+		Build:     version.Get(),
 	}, nil
 }
-
-// CheckDatabaseConnection checks database connectivity
-func (r *healthRepository) CheckDatabaseConnection(ctx context.Context) error {
-	// TODO: Implement actual database health check
-	// For now, return nil (assume healthy)
-	return nil
-}
-
-// CheckExternalServices checks external service dependencies
-func (r *healthRepository) CheckExternalServices(ctx context.Context) error {
-	// TODO: Implement external service health checks
-	// For now, return nil (assume healthy)
-	return nil
-}
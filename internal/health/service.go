@@ -2,21 +2,28 @@ package health
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
+	"gin-service/pkg/constants"
 	"gin-service/pkg/logger"
 )
 
 // healthService implements HealthService interface
 type healthService struct {
 	repository HealthRepository
+	registry   *Registry
 	logger     logger.Logger
+	started    atomic.Bool
+	draining   atomic.Bool
 }
 
-// NewHealthService creates a new health service instance
-func NewHealthService(repository HealthRepository, log logger.Logger) HealthService {
+// NewHealthService creates a new health service instance that reports the
+// cached results of the checks of registry, which polls them once started
+func NewHealthService(repository HealthRepository, registry *Registry, log logger.Logger) HealthService {
 	return &healthService{
 		repository: repository,
+		registry:   registry,
 		logger:     log,
 	}
 }
@@ -32,48 +39,16 @@ func (s *healthService) GetHealth(ctx context.Context) (*HealthResponse, error)
 		return nil, err
 	}
 
-	// Check database connection
-	if err := s.repository.CheckDatabaseConnection(ctx); err != nil {
-		s.logger.Warn(ctx, "Database connection failed", logger.Fields{
-			"error": err.Error(),
-		})
-		return &HealthResponse{
-			Status:    "unhealthy",
-			Timestamp: time.Now(),
-			Service:   "gin-service",
-			Version:   systemStatus.Version,
-			Details: &HealthDetails{
-				Database: "unavailable",
-			},
-		}, nil
-	}
-
-	// Check external services
-	if err := s.repository.CheckExternalServices(ctx); err != nil {
-		s.logger.Warn(ctx, "External services check failed", logger.Fields{
-			"error": err.Error(),
-		})
-		return &HealthResponse{
-			Status:    "degraded",
-			Timestamp: time.Now(),
-			Service:   "gin-service",
-			Version:   systemStatus.Version,
-			Details: &HealthDetails{
-				Database:         "healthy",
-				ExternalServices: []string{"some services unavailable"},
-			},
-		}, nil
-	}
-
+	checks := s.registry.Results()
 	return &HealthResponse{
-		Status:    "healthy",
+		Status:    overallStatus(checks),
 		Timestamp: time.Now(),
 		Service:   "gin-service",
-		Version:   systemStatus.Version,
+		Version:   systemStatus.Build.Version,
+		Build:     systemStatus.Build,
 		Details: &HealthDetails{
-			Database:         "healthy",
-			ExternalServices: []string{"all services healthy"},
-			Uptime:           time.Since(systemStatus.Uptime).String(),
+			Checks: checks,
+			Uptime: time.Since(systemStatus.Uptime).String(),
 		},
 	}, nil
 }
@@ -86,21 +61,23 @@ func (s *healthService) GetReadiness(ctx context.Context) (*HealthResponse, erro
 		return nil, err
 	}
 
-	// Check database connection (critical for readiness)
-	if err := s.repository.CheckDatabaseConnection(ctx); err != nil {
-		return &HealthResponse{
-			Status:    "not ready",
-			Timestamp: time.Now(),
-			Service:   "gin-service",
-			Version:   systemStatus.Version,
-		}, nil
+	// Only critical checks keep a started service that is not shutting
+	// down from accepting traffic
+	checks := s.registry.Results()
+	status := "ready"
+	if !s.started.Load() || s.draining.Load() || overallStatus(checks) == constants.HealthStatusUnhealthy {
+		status = "not ready"
 	}
 
 	return &HealthResponse{
-		Status:    "ready",
+		Status:    status,
 		Timestamp: time.Now(),
 		Service:   "gin-service",
-		Version:   systemStatus.Version,
+		Version:   systemStatus.Build.Version,
+		Build:     systemStatus.Build,
+		Details: &HealthDetails{
+			Checks: checks,
+		},
 	}, nil
 }
 
@@ -116,6 +93,66 @@ func (s *healthService) GetLiveness(ctx context.Context) (*HealthResponse, error
 		Status:    "alive",
 		Timestamp: time.Now(),
 		Service:   "gin-service",
-		Version:   systemStatus.Version,
+		Version:   systemStatus.Build.Version,
+		Build:     systemStatus.Build,
+	}, nil
+}
+
+// GetStartup handles startup probe business logic
+func (s *healthService) GetStartup(ctx context.Context) (*HealthResponse, error) {
+	systemStatus, err := s.repository.GetSystemStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := "starting"
+	if s.started.Load() {
+		status = "started"
+	}
+
+	return &HealthResponse{
+		Status:    status,
+		Timestamp: time.Now(),
+		Service:   "gin-service",
+		Version:   systemStatus.Build.Version,
+		Build:     systemStatus.Build,
 	}, nil
 }
+
+// GetVersion handles build metadata business logic
+func (s *healthService) GetVersion(ctx context.Context) (*VersionResponse, error) {
+	systemStatus, err := s.repository.GetSystemStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VersionResponse{
+		Service: "gin-service",
+		Info:    systemStatus.Build,
+	}, nil
+}
+
+// GetHistory returns the recent status transitions of every check
+func (s *healthService) GetHistory(ctx context.Context) (*HistoryResponse, error) {
+	return &HistoryResponse{
+		Timestamp: time.Now(),
+		Service:   "gin-service",
+		Checks:    s.registry.History(),
+	}, nil
+}
+
+// MarkStarted records that migrations and warm-up have finished
+func (s *healthService) MarkStarted() {
+	s.started.Store(true)
+}
+
+// Started reports whether migrations and warm-up have finished
+func (s *healthService) Started() bool {
+	return s.started.Load()
+}
+
+// MarkDraining records that the service is shutting down, which turns it
+// not ready for good
+func (s *healthService) MarkDraining() {
+	s.draining.Store(true)
+}
@@ -2,13 +2,17 @@ package health
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	"gin-service/pkg/logger"
+	"gin-service/pkg/version"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockHealthRepository is a mock implementation of HealthRepository
@@ -24,38 +28,40 @@ func (m *MockLogger) Info(ctx context.Context, message string, fields logger.Fie
 func (m *MockLogger) Warn(ctx context.Context, message string, fields logger.Fields)             {}
 func (m *MockLogger) Error(ctx context.Context, message string, err error, fields logger.Fields) {}
 func (m *MockLogger) Fatal(ctx context.Context, message string, err error, fields logger.Fields) {}
+func (m *MockLogger) WithContext(ctx context.Context) logger.Logger                              { return m }
+func (m *MockLogger) WithFields(fields logger.Fields) logger.Logger                              { return m }
 
 func (m *MockHealthRepository) GetSystemStatus(ctx context.Context) (*SystemStatus, error) {
 	args := m.Called(ctx)
 	return args.Get(0).(*SystemStatus), args.Error(1)
 }
 
-func (m *MockHealthRepository) CheckDatabaseConnection(ctx context.Context) error {
-	args := m.Called(ctx)
-	return args.Error(0)
+// newTestRegistry returns a registry with a passing critical and a passing
+// degraded-only check that have run once
+func newTestRegistry(t *testing.T) *Registry {
+	registry := NewRegistry(DefaultHistorySize, new(MockLogger))
+	require.NoError(t, registry.Register(Check{Name: "database", Run: passingCheck}))
+	require.NoError(t, registry.Register(Check{Name: "cache", Criticality: Degraded, Run: passingCheck}))
+	registry.Run(context.Background())
+	return registry
 }
 
-func (m *MockHealthRepository) CheckExternalServices(ctx context.Context) error {
-	args := m.Called(ctx)
-	return args.Error(0)
-}
+func passingCheck(ctx context.Context) error { return nil }
 
 func TestHealthService_GetHealth(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockHealthRepository)
 	mockLogger := new(MockLogger)
-	service := NewHealthService(mockRepo, mockLogger)
+	service := NewHealthService(mockRepo, newTestRegistry(t), mockLogger)
 	ctx := context.Background()
 
 	expectedStatus := &SystemStatus{
 		IsHealthy: true,
 		Uptime:    time.Now(),
-		Version:   "1.0.0",
+		Build:     version.Info{Version: "1.0.0"},
 	}
 
 	mockRepo.On("GetSystemStatus", ctx).Return(expectedStatus, nil)
-	mockRepo.On("CheckDatabaseConnection", ctx).Return(nil)
-	mockRepo.On("CheckExternalServices", ctx).Return(nil)
 
 	// Act
 	response, err := service.GetHealth(ctx)
@@ -66,9 +72,14 @@ func TestHealthService_GetHealth(t *testing.T) {
 	assert.Equal(t, "healthy", response.Status)
 	assert.Equal(t, "gin-service", response.Service)
 	assert.Equal(t, "1.0.0", response.Version)
+	assert.Equal(t, version.Info{Version: "1.0.0"}, response.Build)
 	assert.NotNil(t, response.Details)
-	assert.Equal(t, "healthy", response.Details.Database)
-	assert.Equal(t, []string{"all services healthy"}, response.Details.ExternalServices)
+	if assert.Len(t, response.Details.Checks, 2) {
+		assert.Equal(t, "database", response.Details.Checks[0].Name)
+		assert.Equal(t, CheckStatusPassing, response.Details.Checks[0].Status)
+		assert.NotEmpty(t, response.Details.Checks[0].Latency)
+		assert.Empty(t, response.Details.Checks[0].LastError)
+	}
 
 	mockRepo.AssertExpectations(t)
 }
@@ -77,17 +88,17 @@ func TestHealthService_GetReadiness(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockHealthRepository)
 	mockLogger := new(MockLogger)
-	service := NewHealthService(mockRepo, mockLogger)
+	service := NewHealthService(mockRepo, newTestRegistry(t), mockLogger)
 	ctx := context.Background()
 
 	expectedStatus := &SystemStatus{
 		IsHealthy: true,
 		Uptime:    time.Now(),
-		Version:   "1.0.0",
+		Build:     version.Info{Version: "1.0.0"},
 	}
 
 	mockRepo.On("GetSystemStatus", ctx).Return(expectedStatus, nil)
-	mockRepo.On("CheckDatabaseConnection", ctx).Return(nil)
+	service.MarkStarted()
 
 	// Act
 	response, err := service.GetReadiness(ctx)
@@ -106,13 +117,13 @@ func TestHealthService_GetLiveness(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockHealthRepository)
 	mockLogger := new(MockLogger)
-	service := NewHealthService(mockRepo, mockLogger)
+	service := NewHealthService(mockRepo, newTestRegistry(t), mockLogger)
 	ctx := context.Background()
 
 	expectedStatus := &SystemStatus{
 		IsHealthy: true,
 		Uptime:    time.Now(),
-		Version:   "1.0.0",
+		Build:     version.Info{Version: "1.0.0"},
 	}
 
 	mockRepo.On("GetSystemStatus", ctx).Return(expectedStatus, nil)
@@ -129,3 +140,333 @@ func TestHealthService_GetLiveness(t *testing.T) {
 
 	mockRepo.AssertExpectations(t)
 }
+
+func TestHealthService_FailingChecks(t *testing.T) {
+	tests := []struct {
+		name        string
+		criticality Criticality
+		health      string
+		readiness   string
+	}{
+		{name: "critical check fails", criticality: Critical, health: "unhealthy", readiness: "not ready"},
+		{name: "degraded-only check fails", criticality: Degraded, health: "degraded", readiness: "ready"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockHealthRepository)
+			registry := NewRegistry(DefaultHistorySize, new(MockLogger))
+			require.NoError(t, registry.Register(Check{Name: "database", Run: passingCheck}))
+			require.NoError(t, registry.Register(Check{
+				Name:        "search",
+				Criticality: tt.criticality,
+				Run: func(ctx context.Context) error {
+					return errors.New("connection refused")
+				},
+			}))
+			service := NewHealthService(mockRepo, registry, new(MockLogger))
+			ctx := context.Background()
+			registry.Run(ctx)
+			service.MarkStarted()
+
+			mockRepo.On("GetSystemStatus", ctx).Return(&SystemStatus{Uptime: time.Now(), Build: version.Info{Version: "1.0.0"}}, nil)
+
+			health, err := service.GetHealth(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.health, health.Status)
+			assert.Equal(t, CheckStatusFailing, health.Details.Checks[1].Status)
+			assert.Equal(t, "connection refused", health.Details.Checks[1].LastError)
+
+			readiness, err := service.GetReadiness(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.readiness, readiness.Status)
+		})
+	}
+}
+
+func TestHealthService_Lifecycle(t *testing.T) {
+	mockRepo := new(MockHealthRepository)
+	service := NewHealthService(mockRepo, newTestRegistry(t), new(MockLogger))
+	ctx := context.Background()
+
+	mockRepo.On("GetSystemStatus", ctx).Return(&SystemStatus{Uptime: time.Now(), Build: version.Info{Version: "1.0.0"}}, nil)
+
+	statuses := func() (string, string) {
+		startup, err := service.GetStartup(ctx)
+		require.NoError(t, err)
+		readiness, err := service.GetReadiness(ctx)
+		require.NoError(t, err)
+		return startup.Status, readiness.Status
+	}
+
+	startup, readiness := statuses()
+	assert.Equal(t, "starting", startup)
+	assert.Equal(t, "not ready", readiness)
+
+	service.MarkStarted()
+	startup, readiness = statuses()
+	assert.Equal(t, "started", startup)
+	assert.Equal(t, "ready", readiness)
+
+	service.MarkDraining()
+	startup, readiness = statuses()
+	assert.Equal(t, "started", startup)
+	assert.Equal(t, "not ready", readiness)
+
+	liveness, err := service.GetLiveness(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "alive", liveness.Status)
+}
+
+func TestHealthService_GetVersion(t *testing.T) {
+	mockRepo := new(MockHealthRepository)
+	service := NewHealthService(mockRepo, newTestRegistry(t), new(MockLogger))
+	ctx := context.Background()
+
+	build := version.Info{Version: "v1.2.3", GitCommit: "abc123", BuildTime: "2024-01-01T12:00:00Z", GoVersion: "go1.21.0"}
+	mockRepo.On("GetSystemStatus", ctx).Return(&SystemStatus{Uptime: time.Now(), Build: build}, nil)
+
+	response, err := service.GetVersion(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, "gin-service", response.Service)
+	assert.Equal(t, build, response.Info)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry(DefaultHistorySize, new(MockLogger))
+
+	require.NoError(t, registry.Register(Check{Name: "database", Run: passingCheck}))
+	assert.Error(t, registry.Register(Check{Name: "database", Run: passingCheck}))
+	assert.Error(t, registry.Register(Check{Name: "cache"}))
+
+	results := registry.Results()
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, Critical, results[0].Criticality)
+		assert.Equal(t, CheckStatusUnknown, results[0].Status)
+	}
+}
+
+func TestRegistry_Run(t *testing.T) {
+	registry := NewRegistry(DefaultHistorySize, new(MockLogger))
+	require.NoError(t, registry.Register(Check{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}))
+	require.NoError(t, registry.Register(Check{
+		Name: "panics",
+		Run: func(ctx context.Context) error {
+			panic("boom")
+		},
+	}))
+	require.NoError(t, registry.Register(Check{
+		Name: "probe",
+		Run: ProbeCheck(func(ctx context.Context) (bool, error) {
+			return false, nil
+		}),
+	}))
+
+	results := registry.Run(context.Background())
+
+	require.Len(t, results, 3)
+	assert.Equal(t, "slow", results[0].Name)
+	assert.Contains(t, results[0].LastError, "timed out")
+	assert.Contains(t, results[1].LastError, "boom")
+	assert.Equal(t, "probe reported unhealthy", results[2].LastError)
+	for _, result := range results {
+		assert.Equal(t, CheckStatusFailing, result.Status)
+		assert.NotNil(t, result.CheckedAt)
+	}
+}
+
+func TestRegistry_Thresholds(t *testing.T) {
+	var failing bool
+	registry := NewRegistry(DefaultHistorySize, new(MockLogger))
+	require.NoError(t, registry.Register(Check{
+		Name:             "database",
+		SuccessThreshold: 2,
+		FailureThreshold: 3,
+		Run: func(ctx context.Context) error {
+			if failing {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	}))
+	ctx := context.Background()
+
+	// The first run decides the status of a check that has not reported yet
+	assert.Equal(t, CheckStatusPassing, registry.Run(ctx)[0].Status)
+
+	failing = true
+	assert.Equal(t, CheckStatusPassing, registry.Run(ctx)[0].Status)
+	assert.Equal(t, CheckStatusPassing, registry.Run(ctx)[0].Status)
+	result := registry.Run(ctx)[0]
+	assert.Equal(t, CheckStatusFailing, result.Status)
+	assert.Equal(t, 3, result.ConsecutiveFailures)
+
+	failing = false
+	result = registry.Run(ctx)[0]
+	assert.Equal(t, CheckStatusFailing, result.Status)
+	assert.Zero(t, result.ConsecutiveFailures)
+	result = registry.Run(ctx)[0]
+	assert.Equal(t, CheckStatusPassing, result.Status)
+	assert.Equal(t, "connection refused", result.LastError)
+}
+
+func TestRegistry_StartStop(t *testing.T) {
+	runs := make(chan struct{}, 10)
+	registry := NewRegistry(DefaultHistorySize, new(MockLogger))
+	require.NoError(t, registry.Register(Check{
+		Name:     "database",
+		Interval: time.Millisecond,
+		Run: func(ctx context.Context) error {
+			select {
+			case runs <- struct{}{}:
+			default:
+			}
+			return nil
+		},
+	}))
+
+	registry.Start(context.Background())
+	assert.Equal(t, CheckStatusPassing, registry.Results()[0].Status)
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-runs:
+		case <-time.After(time.Second):
+			t.Fatal("check was not polled")
+		}
+	}
+
+	registry.Stop()
+	for len(runs) > 0 {
+		<-runs
+	}
+	time.Sleep(10 * time.Millisecond)
+	assert.Empty(t, runs)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, backoff(time.Second, time.Minute, 0))
+	assert.Equal(t, 2*time.Second, backoff(time.Second, time.Minute, 1))
+	assert.Equal(t, 8*time.Second, backoff(time.Second, time.Minute, 3))
+	assert.Equal(t, time.Minute, backoff(time.Second, time.Minute, 10))
+	assert.Equal(t, time.Minute, backoff(time.Second, time.Minute, 1000))
+}
+
+// recordingLogger records the fields of every info and warning it logs and
+// every error
+type recordingLogger struct {
+	MockLogger
+	mu      sync.Mutex
+	entries []logger.Fields
+	errors  []error
+}
+
+func (l *recordingLogger) Error(ctx context.Context, message string, err error, fields logger.Fields) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, err)
+}
+
+func (l *recordingLogger) Info(ctx context.Context, message string, fields logger.Fields) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, fields)
+}
+
+func (l *recordingLogger) Warn(ctx context.Context, message string, fields logger.Fields) {
+	l.Info(ctx, message, fields)
+}
+
+func TestRegistry_History(t *testing.T) {
+	var failing bool
+	log := new(recordingLogger)
+	registry := NewRegistry(3, log)
+	require.NoError(t, registry.Register(Check{
+		Name: "database",
+		Run: func(ctx context.Context) error {
+			if failing {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	}))
+
+	var notified []Transition
+	registry.Subscribe(func(transition Transition) {
+		notified = append(notified, transition)
+	})
+
+	ctx := context.Background()
+	for _, fail := range []bool{false, true, true, false, true} {
+		failing = fail
+		registry.Run(ctx)
+	}
+
+	// Runs that keep the status record nothing; the first transition,
+	// unknown to passing, has been overwritten
+	history := registry.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, "database", history[0].Name)
+	transitions := history[0].Transitions
+	require.Len(t, transitions, 3)
+	assert.Equal(t, []string{CheckStatusPassing, CheckStatusFailing, CheckStatusPassing},
+		[]string{transitions[0].From, transitions[1].From, transitions[2].From})
+	assert.Equal(t, []string{CheckStatusFailing, CheckStatusPassing, CheckStatusFailing},
+		[]string{transitions[0].To, transitions[1].To, transitions[2].To})
+	assert.Equal(t, "connection refused", transitions[0].Error)
+	assert.Empty(t, transitions[1].Error)
+	assert.Equal(t, Critical, transitions[2].Criticality)
+
+	require.Len(t, notified, 4)
+	assert.Equal(t, CheckStatusUnknown, notified[0].From)
+	assert.Equal(t, transitions, notified[1:])
+
+	require.Len(t, log.entries, 4)
+	assert.Equal(t, "database", log.entries[1]["check"])
+	assert.Equal(t, CheckStatusFailing, log.entries[1]["to"])
+	assert.Equal(t, "connection refused", log.entries[1]["error"])
+}
+
+func TestHealthService_GetHistory(t *testing.T) {
+	service := NewHealthService(new(MockHealthRepository), newTestRegistry(t), new(MockLogger))
+
+	response, err := service.GetHistory(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, response.Checks, 2)
+	assert.Equal(t, "database", response.Checks[0].Name)
+	require.Len(t, response.Checks[0].Transitions, 1)
+	assert.Equal(t, CheckStatusUnknown, response.Checks[0].Transitions[0].From)
+	assert.Equal(t, CheckStatusPassing, response.Checks[0].Transitions[0].To)
+}
+
+func TestRegistry_SurvivesPanickingSubscriber(t *testing.T) {
+	log := new(recordingLogger)
+	registry := NewRegistry(DefaultHistorySize, log)
+	require.NoError(t, registry.Register(Check{Name: "database", Run: func(ctx context.Context) error { return nil }}))
+
+	var notified int
+	registry.Subscribe(func(transition Transition) {
+		panic("alerting is down")
+	})
+	registry.Subscribe(func(transition Transition) {
+		notified++
+	})
+
+	results := registry.Run(context.Background())
+
+	require.Len(t, results, 1)
+	assert.Equal(t, CheckStatusPassing, results[0].Status)
+	assert.Equal(t, 1, notified)
+	require.Len(t, log.errors, 1)
+	assert.EqualError(t, log.errors[0], "alerting is down")
+}
@@ -0,0 +1,398 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gin-service/pkg/audit"
+	"gin-service/pkg/common"
+	"gin-service/pkg/constants"
+	"gin-service/pkg/database"
+)
+
+// errBatchAborted rolls back an atomic batch once an operation has failed
+var errBatchAborted = errors.New("batch aborted")
+
+// productBatch tracks the operations of a batch, grouped by type so each
+// group can be written with one repository call, and their results
+type productBatch struct {
+	atomic  bool
+	results []BatchResult
+
+	creates   []*Product
+	createIdx []int
+	updates   []*Product
+	updateIdx []int
+	deletes   []ProductVersion
+	deleteIdx []int
+
+	// Products as read before the batch, for the audit trail
+	updateBefore []*Product
+	deleteBefore []*Product
+}
+
+// BatchProducts applies a batch of create, update and delete operations.
+// Every operation is checked before anything is written. An atomic batch
+// stops there if any check failed and otherwise writes all-or-nothing,
+// inside a single transaction or through the repository's WriteBatch when
+// it has no transactions; a best-effort batch writes every operation that
+// passed. Atomic batches are rejected when the backend can do neither.
+func (s *productService) BatchProducts(ctx context.Context, req *BatchProductsRequest) (*BatchProductsResponse, error) {
+	mode := req.Mode
+	if mode == "" {
+		mode = BatchModeAtomic
+	}
+	if mode != BatchModeAtomic && mode != BatchModeBestEffort {
+		return nil, common.NewValidationError(fmt.Sprintf("batch mode must be %s or %s", BatchModeAtomic, BatchModeBestEffort))
+	}
+	_, lockable := s.repository.(AtomicBatchWriter)
+	if mode == BatchModeAtomic && !lockable && !database.SupportsRollback(s.txManager) {
+		return nil, common.NewValidationError(fmt.Sprintf("%s batches are not supported by this storage backend", BatchModeAtomic))
+	}
+
+	if len(req.Operations) == 0 {
+		return nil, common.NewValidationError("batch must contain at least one operation")
+	}
+	if len(req.Operations) > constants.MaxBatchSize {
+		return nil, common.NewValidationError(fmt.Sprintf("batch cannot contain more than %d operations", constants.MaxBatchSize))
+	}
+
+	batch := &productBatch{
+		atomic:  mode == BatchModeAtomic,
+		results: make([]BatchResult, len(req.Operations)),
+	}
+
+	if err := s.prepareBatch(ctx, batch, req.Operations); err != nil {
+		return nil, err
+	}
+
+	if batch.atomic && batch.failed() {
+		batch.abort()
+	} else if batch.atomic {
+		if err := s.writeAtomicBatch(ctx, batch); err != nil {
+			return nil, repositoryError("failed to apply batch", err)
+		}
+	} else if err := s.writeBatch(ctx, batch); err != nil {
+		return nil, repositoryError("failed to apply batch", err)
+	}
+
+	response := &BatchProductsResponse{
+		Mode:    mode,
+		Results: batch.results,
+	}
+	for _, result := range batch.results {
+		if result.Error == nil {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	return response, nil
+}
+
+// prepareBatch checks every operation against business rules and the stored
+// products, queuing those that pass and recording failures for the rest
+func (s *productService) prepareBatch(ctx context.Context, batch *productBatch, operations []BatchOperation) error {
+	seen := make(map[string]bool)
+	categories := make(map[string]bool)
+	var ids []string
+
+	for i, op := range operations {
+		batch.results[i] = BatchResult{Index: i, Op: op.Op, ID: op.ID}
+
+		switch op.Op {
+		case BatchOpCreate:
+			if op.Product == nil {
+				batch.fail(i, common.NewValidationError("product is required for create"))
+				continue
+			}
+			if op.ID != "" {
+				batch.fail(i, common.NewValidationError("id cannot be set for create"))
+				continue
+			}
+			product, err := newProduct(op.Product)
+			if err == nil {
+				err = s.checkCategory(ctx, product.CategoryID, categories)
+			}
+			if err != nil {
+				batch.fail(i, err)
+				continue
+			}
+			batch.creates = append(batch.creates, product)
+			batch.createIdx = append(batch.createIdx, i)
+			continue
+		case BatchOpUpdate:
+			if op.Changes == nil {
+				batch.fail(i, common.NewValidationError("changes are required for update"))
+				continue
+			}
+		case BatchOpDelete:
+		default:
+			batch.fail(i, common.NewValidationError(fmt.Sprintf("unknown batch operation: %s", op.Op)))
+			continue
+		}
+
+		if op.ID == "" {
+			batch.fail(i, common.NewValidationError("product ID is required"))
+			continue
+		}
+		if seen[op.ID] {
+			batch.fail(i, common.NewValidationError(fmt.Sprintf("product %s appears more than once in the batch", op.ID)))
+			continue
+		}
+		seen[op.ID] = true
+		ids = append(ids, op.ID)
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	existing, err := s.repository.GetByIDs(ctx, ids)
+	if err != nil {
+		return repositoryError("failed to get products", err)
+	}
+
+	byID := make(map[string]*Product, len(existing))
+	for _, product := range existing {
+		byID[product.ID] = product
+	}
+
+	for i, op := range operations {
+		if batch.results[i].Error != nil || op.Op == BatchOpCreate {
+			continue
+		}
+
+		product, ok := byID[op.ID]
+		if !ok {
+			batch.fail(i, fmt.Errorf("%w: %s", ErrProductNotFound, op.ID))
+			continue
+		}
+		if op.Version != 0 && product.Version != op.Version {
+			batch.fail(i, ErrVersionConflict)
+			continue
+		}
+
+		if op.Op == BatchOpDelete {
+			batch.deletes = append(batch.deletes, ProductVersion{ID: op.ID, Version: op.Version})
+			batch.deleteIdx = append(batch.deleteIdx, i)
+			batch.deleteBefore = append(batch.deleteBefore, product)
+			continue
+		}
+
+		before := cloneProduct(product)
+		err := applyUpdate(product, op.Changes)
+		if err == nil && product.CategoryID != before.CategoryID {
+			err = s.checkCategory(ctx, product.CategoryID, categories)
+		}
+		if err != nil {
+			batch.fail(i, err)
+			continue
+		}
+		batch.updates = append(batch.updates, product)
+		batch.updateIdx = append(batch.updateIdx, i)
+		batch.updateBefore = append(batch.updateBefore, before)
+	}
+
+	return nil
+}
+
+// writeAtomicBatch writes the queued operations of an atomic batch
+// all-or-nothing and marks every operation as not applied when one of them
+// fails
+func (s *productService) writeAtomicBatch(ctx context.Context, batch *productBatch) error {
+	if writer, ok := s.repository.(AtomicBatchWriter); ok {
+		return s.writeLockedBatch(ctx, writer, batch)
+	}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		return s.writeBatch(ctx, batch)
+	})
+	if errors.Is(err, errBatchAborted) {
+		batch.abort()
+		return nil
+	}
+	return err
+}
+
+// writeLockedBatch writes the queued operations through a repository that
+// checks them all before writing any, then records an audit event for each
+func (s *productService) writeLockedBatch(ctx context.Context, writer AtomicBatchWriter, batch *productBatch) error {
+	createErrs, updateErrs, deleteErrs := writer.WriteBatch(ctx, batch.creates, batch.updates, batch.deletes)
+	batch.failEach(batch.createIdx, createErrs)
+	batch.failEach(batch.updateIdx, updateErrs)
+	batch.failEach(batch.deleteIdx, deleteErrs)
+	if batch.failed() {
+		batch.abort()
+		return nil
+	}
+
+	for j, product := range batch.creates {
+		if err := s.record(ctx, audit.ActionCreate, product.ID, nil, product); err != nil {
+			return err
+		}
+		batch.succeed(batch.createIdx[j], http.StatusCreated, product)
+	}
+	for j, product := range batch.updates {
+		if err := s.record(ctx, audit.ActionUpdate, product.ID, batch.updateBefore[j], product); err != nil {
+			return err
+		}
+		batch.succeed(batch.updateIdx[j], http.StatusOK, product)
+	}
+	for j, ref := range batch.deletes {
+		if err := s.record(ctx, audit.ActionDelete, ref.ID, batch.deleteBefore[j], nil); err != nil {
+			return err
+		}
+		batch.succeed(batch.deleteIdx[j], http.StatusOK, nil)
+	}
+
+	return nil
+}
+
+// writeBatch writes the queued operations group by group and records an
+// audit event for each one written. A group the repository fails to write
+// as a whole fails each of its operations. In an atomic batch the first
+// failed operation returns errBatchAborted so the surrounding transaction
+// rolls back.
+func (s *productService) writeBatch(ctx context.Context, batch *productBatch) error {
+	if len(batch.creates) > 0 {
+		created := make([]bool, len(batch.creates))
+		if err := s.repository.CreateMany(ctx, batch.creates); err != nil {
+			if batch.atomic {
+				// The products went out in one statement, so the failure
+				// cannot be pinned on one of them
+				batch.failEach(batch.createIdx, repeatError(err, len(batch.creates)))
+				return errBatchAborted
+			}
+			// Fall back to single inserts to find out which products failed
+			for j, product := range batch.creates {
+				if err := s.repository.Create(ctx, product); err != nil {
+					batch.fail(batch.createIdx[j], err)
+					continue
+				}
+				created[j] = true
+			}
+		} else {
+			for j := range created {
+				created[j] = true
+			}
+		}
+
+		for j, product := range batch.creates {
+			if !created[j] {
+				continue
+			}
+			if err := s.record(ctx, audit.ActionCreate, product.ID, nil, product); err != nil {
+				return err
+			}
+			batch.succeed(batch.createIdx[j], http.StatusCreated, product)
+		}
+	}
+
+	if len(batch.updates) > 0 {
+		errs, err := s.repository.UpdateMany(ctx, batch.updates)
+		if err != nil {
+			errs = repeatError(err, len(batch.updates))
+		}
+		for j, product := range batch.updates {
+			if errs[j] != nil {
+				batch.fail(batch.updateIdx[j], errs[j])
+				continue
+			}
+			if err := s.record(ctx, audit.ActionUpdate, product.ID, batch.updateBefore[j], product); err != nil {
+				return err
+			}
+			batch.succeed(batch.updateIdx[j], http.StatusOK, product)
+		}
+		if batch.atomic && batch.failed() {
+			return errBatchAborted
+		}
+	}
+
+	if len(batch.deletes) > 0 {
+		errs, err := s.repository.DeleteMany(ctx, batch.deletes)
+		if err != nil {
+			errs = repeatError(err, len(batch.deletes))
+		}
+		for j, ref := range batch.deletes {
+			if errs[j] != nil {
+				batch.fail(batch.deleteIdx[j], errs[j])
+				continue
+			}
+			if err := s.record(ctx, audit.ActionDelete, ref.ID, batch.deleteBefore[j], nil); err != nil {
+				return err
+			}
+			batch.succeed(batch.deleteIdx[j], http.StatusOK, nil)
+		}
+		if batch.atomic && batch.failed() {
+			return errBatchAborted
+		}
+	}
+
+	return nil
+}
+
+// succeed records a written operation
+func (b *productBatch) succeed(index, status int, product *Product) {
+	b.results[index].Status = status
+	b.results[index].Product = product
+	if product != nil {
+		b.results[index].ID = product.ID
+	}
+}
+
+// fail records a failed operation as an AppError
+func (b *productBatch) fail(index int, err error) {
+	appErr := common.GetAppError(err)
+	if appErr == nil {
+		appErr = common.GetAppError(repositoryError("failed to apply operation", err))
+	}
+
+	b.results[index].Status = appErr.HTTPStatus
+	b.results[index].Product = nil
+	b.results[index].Error = appErr
+}
+
+// repeatError returns n copies of err, for a group that failed as a whole
+func repeatError(err error, n int) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// failEach records the errors of a group of operations, skipping nil ones
+func (b *productBatch) failEach(indexes []int, errs []error) {
+	for j, err := range errs {
+		if err != nil {
+			b.fail(indexes[j], err)
+		}
+	}
+}
+
+// failed reports whether any operation has failed
+func (b *productBatch) failed() bool {
+	for _, result := range b.results {
+		if result.Error != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// abort marks every operation that has not failed itself as not applied
+func (b *productBatch) abort() {
+	for i, result := range b.results {
+		if result.Error != nil {
+			continue
+		}
+		b.fail(i, common.NewFailedDependencyError("not applied because another operation in the batch failed"))
+		if result.Op == BatchOpCreate {
+			b.results[i].ID = ""
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package product
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-service/pkg/common"
+	"gin-service/pkg/constants"
+	"gin-service/pkg/money"
+	"gin-service/pkg/validation"
+)
+
+// Import and export formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// csvColumns are the columns written by CSV exports. Imports read the
+// writable columns by name and ignore the rest, so exports can be imported.
+// Prices without a currency column are read in the default currency.
+var csvColumns = []string{"id", "name", "description", "price", "currency", "category_id", "stock", "version", "created_at", "updated_at"}
+
+// ProductEncoder writes products to an export stream
+type ProductEncoder interface {
+	Encode(product *Product) error
+	Flush() error
+}
+
+// ProductDecoder reads create requests from an import stream. Next returns
+// the line a row starts on; io.EOF ends the stream, an *common.AppError
+// rejects only that row and any other error aborts the import.
+type ProductDecoder interface {
+	Next() (line int, req *CreateProductRequest, err error)
+}
+
+// FormatContentType returns the media type of an import/export format
+func FormatContentType(format string) string {
+	if format == FormatNDJSON {
+		return constants.ContentTypeNDJSON
+	}
+	return constants.ContentTypeCSV
+}
+
+// NewProductEncoder creates an encoder writing format to w
+func NewProductEncoder(format string, w io.Writer) (ProductEncoder, error) {
+	switch format {
+	case FormatCSV:
+		return &csvEncoder{writer: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		buffered := bufio.NewWriter(w)
+		return &ndjsonEncoder{buffer: buffered, encoder: json.NewEncoder(buffered)}, nil
+	default:
+		return nil, unsupportedFormat(format)
+	}
+}
+
+// NewProductDecoder creates a decoder reading format from r
+func NewProductDecoder(format string, r io.Reader) (ProductDecoder, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.ReuseRecord = true
+		reader.TrimLeadingSpace = true
+		return &csvDecoder{reader: reader}, nil
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		return &ndjsonDecoder{scanner: scanner}, nil
+	default:
+		return nil, unsupportedFormat(format)
+	}
+}
+
+// unsupportedFormat reports a format other than csv or ndjson
+func unsupportedFormat(format string) error {
+	return common.NewValidationError(fmt.Sprintf("format must be %s or %s, got %q", FormatCSV, FormatNDJSON, format))
+}
+
+// csvEncoder writes a header row followed by one row per product
+type csvEncoder struct {
+	writer      *csv.Writer
+	wroteHeader bool
+}
+
+// Encode writes product as a CSV row
+func (e *csvEncoder) Encode(product *Product) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	return e.writer.Write([]string{
+		product.ID,
+		product.Name,
+		product.Description,
+		product.Price.Decimal(),
+		product.Price.Currency,
+		product.CategoryID,
+		strconv.Itoa(product.Stock),
+		strconv.FormatInt(product.Version, 10),
+		product.CreatedAt.UTC().Format(time.RFC3339Nano),
+		product.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+// Flush writes buffered rows, including the header of an empty export
+func (e *csvEncoder) Flush() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// writeHeader writes the header row once
+func (e *csvEncoder) writeHeader() error {
+	if e.wroteHeader {
+		return nil
+	}
+	e.wroteHeader = true
+	return e.writer.Write(csvColumns)
+}
+
+// ndjsonEncoder writes one JSON object per line
+type ndjsonEncoder struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+// Encode writes product as a JSON line
+func (e *ndjsonEncoder) Encode(product *Product) error {
+	return e.encoder.Encode(product)
+}
+
+// Flush writes buffered lines
+func (e *ndjsonEncoder) Flush() error {
+	return e.buffer.Flush()
+}
+
+// csvDecoder reads rows by the column names in the header row
+type csvDecoder struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+// Next returns the next CSV row as a create request
+func (d *csvDecoder) Next() (int, *CreateProductRequest, error) {
+	if d.columns == nil {
+		if err := d.readHeader(); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	record, err := d.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+			return parseErr.StartLine, nil, common.NewValidationError(fmt.Sprintf("expected %d fields", len(d.columns)))
+		}
+		return 0, nil, err
+	}
+	line, _ := d.reader.FieldPos(0)
+
+	req := &CreateProductRequest{
+		Name:        d.field(record, "name"),
+		Description: d.field(record, "description"),
+		CategoryID:  d.field(record, "category_id"),
+	}
+
+	var fields []common.FieldError
+	if value := d.field(record, "price"); value != "" {
+		currency := d.field(record, "currency")
+		if currency == "" {
+			currency = money.DefaultCurrency()
+		}
+		if req.Price, err = money.Parse(value, currency); err != nil {
+			fields = append(fields, common.FieldError{Field: "price", Rule: "type", Param: "money", Message: err.Error()})
+		}
+	}
+	if value := d.field(record, "stock"); value != "" {
+		if req.Stock, err = strconv.Atoi(value); err != nil {
+			fields = append(fields, typeError("stock", "int"))
+		}
+	}
+	if len(fields) > 0 {
+		return line, nil, common.NewValidationErrorWithFields(constants.ErrMsgValidationFailed, fields)
+	}
+
+	return line, req, nil
+}
+
+// readHeader maps column names to positions and checks required columns exist
+func (d *csvDecoder) readHeader() error {
+	header, err := d.reader.Read()
+	if err == io.EOF {
+		return common.NewValidationError("CSV header row is required")
+	}
+	if err != nil {
+		return common.NewValidationErrorWithDetails("Malformed CSV header", err.Error())
+	}
+
+	d.columns = make(map[string]int, len(header))
+	for i, name := range header {
+		d.columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, required := range []string{"name", "price", "category_id", "stock"} {
+		if _, ok := d.columns[required]; !ok {
+			return common.NewValidationError(fmt.Sprintf("CSV header is missing the %s column", required))
+		}
+	}
+
+	return nil
+}
+
+// field returns the trimmed value of a named column
+func (d *csvDecoder) field(record []string, name string) string {
+	i, ok := d.columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// ndjsonDecoder reads one JSON object per line, skipping blank lines
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// Next returns the next JSON line as a create request
+func (d *ndjsonDecoder) Next() (int, *CreateProductRequest, error) {
+	for d.scanner.Scan() {
+		d.line++
+		data := bytes.TrimSpace(d.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var req CreateProductRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return d.line, nil, validation.Translate(err)
+		}
+		return d.line, &req, nil
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		return 0, nil, err
+	}
+	return 0, nil, io.EOF
+}
+
+// typeError describes a column holding a value of the wrong type
+func typeError(field, typeName string) common.FieldError {
+	return common.FieldError{
+		Field:   field,
+		Rule:    "type",
+		Param:   typeName,
+		Message: fmt.Sprintf("must be of type %s", typeName),
+	}
+}
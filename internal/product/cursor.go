@@ -0,0 +1,136 @@
+package product
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gin-service/pkg/money"
+)
+
+// ProductCursor marks a position in a sorted product listing. It encodes the
+// sort key of the last product on a page plus its ID as a tiebreaker.
+type ProductCursor struct {
+	SortField string `json:"f"`
+	SortOrder string `json:"o"`
+	Value     string `json:"v"`
+	ID        string `json:"id"`
+}
+
+// NewProductCursor creates a cursor positioned after product
+func NewProductCursor(product *Product, sortField, sortOrder string) *ProductCursor {
+	return &ProductCursor{
+		SortField: sortField,
+		SortOrder: sortOrder,
+		Value:     sortValue(product, sortField),
+		ID:        product.ID,
+	}
+}
+
+// Encode returns the opaque string form of the cursor
+func (c *ProductCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeProductCursor parses an opaque cursor produced by Encode
+func DecodeProductCursor(encoded string) (*ProductCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor encoding")
+	}
+
+	var cursor ProductCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("invalid cursor payload")
+	}
+
+	if _, ok := SortableFields[cursor.SortField]; !ok || cursor.ID == "" {
+		return nil, fmt.Errorf("invalid cursor position")
+	}
+
+	if _, err := cursor.Pivot(); err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+// Pivot returns a product carrying the cursor's sort key and ID, which can be
+// compared against candidate products or bound as query arguments
+func (c *ProductCursor) Pivot() (*Product, error) {
+	pivot := &Product{ID: c.ID}
+
+	var err error
+	switch c.SortField {
+	case "id":
+	case "name":
+		pivot.Name = c.Value
+	case "description":
+		pivot.Description = c.Value
+	case "category_id":
+		pivot.CategoryID = c.Value
+	case "price":
+		pivot.Price, err = money.ParseString(c.Value)
+	case "stock":
+		pivot.Stock, err = strconv.Atoi(c.Value)
+	case "created_at":
+		pivot.CreatedAt, err = time.Parse(time.RFC3339Nano, c.Value)
+	case "updated_at":
+		pivot.UpdatedAt, err = time.Parse(time.RFC3339Nano, c.Value)
+	default:
+		return nil, fmt.Errorf("invalid cursor sort field: %s", c.SortField)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor value for %s", c.SortField)
+	}
+
+	return pivot, nil
+}
+
+// sortValue returns the string form of a product's sort key
+func sortValue(product *Product, field string) string {
+	switch field {
+	case "name":
+		return product.Name
+	case "description":
+		return product.Description
+	case "category_id":
+		return product.CategoryID
+	case "price":
+		return product.Price.String()
+	case "stock":
+		return strconv.Itoa(product.Stock)
+	case "created_at":
+		return product.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "updated_at":
+		return product.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		return product.ID
+	}
+}
+
+// sortArgument returns a product's sort key typed for use as a query argument
+func sortArgument(product *Product, field string) interface{} {
+	switch field {
+	case "name":
+		return product.Name
+	case "description":
+		return product.Description
+	case "category_id":
+		return product.CategoryID
+	case "price":
+		return product.Price.Decimal()
+	case "stock":
+		return product.Stock
+	case "created_at":
+		return product.CreatedAt
+	case "updated_at":
+		return product.UpdatedAt
+	default:
+		return product.ID
+	}
+}
@@ -0,0 +1,33 @@
+package product
+
+import "errors"
+
+// ErrProductNotFound is returned by repositories when no product has the requested ID
+var ErrProductNotFound = errors.New("product not found")
+
+// ErrVersionConflict is returned when a conditional write targets a product
+// version that is no longer current
+var ErrVersionConflict = errors.New("product has been modified by another request")
+
+// ErrInsufficientStock is returned when a stock decrement would make stock negative
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrReservationNotFound is returned when no reservation has the requested ID
+var ErrReservationNotFound = errors.New("reservation not found")
+
+// ErrReservationNotActive is returned when a reservation has already been
+// committed, released or expired
+var ErrReservationNotActive = errors.New("reservation is no longer active")
+
+// ErrReservationExpired is returned when committing a reservation past its TTL
+var ErrReservationExpired = errors.New("reservation has expired")
+
+// ErrVariantNotFound is returned when no variant has the requested ID
+var ErrVariantNotFound = errors.New("variant not found")
+
+// ErrVariantVersionConflict is returned when a conditional write targets a
+// variant version that is no longer current
+var ErrVariantVersionConflict = errors.New("variant has been modified by another request")
+
+// ErrSKUTaken is returned when another variant already uses a SKU
+var ErrSKUTaken = errors.New("SKU is already taken")
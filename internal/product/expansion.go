@@ -0,0 +1,37 @@
+package product
+
+import (
+	"context"
+
+	"gin-service/pkg/common"
+)
+
+// VariantsExpansion embeds a product's variants, ordered by SKU, under
+// "variants" for responses shaped with expand=variants. The variants of a
+// whole page are looked up in one repository call.
+func VariantsExpansion(repository VariantRepository) common.Expansion {
+	return common.Expansion{
+		Name: "variants",
+		Key:  "id",
+		Resolve: func(ctx context.Context, productIDs []string) (map[string]interface{}, error) {
+			found, err := repository.GetByProducts(ctx, productIDs)
+			if err != nil {
+				return nil, err
+			}
+
+			byProduct := make(map[string][]*Variant, len(productIDs))
+			for _, productID := range productIDs {
+				byProduct[productID] = make([]*Variant, 0)
+			}
+			for _, variant := range found {
+				byProduct[variant.ProductID] = append(byProduct[variant.ProductID], variant)
+			}
+
+			variants := make(map[string]interface{}, len(byProduct))
+			for productID, list := range byProduct {
+				variants[productID] = list
+			}
+			return variants, nil
+		},
+	}
+}
@@ -0,0 +1,57 @@
+package product
+
+import (
+	"context"
+	"time"
+
+	"gin-service/pkg/logger"
+)
+
+// ReservationExpirer periodically returns the stock of reservations that
+// have outlived their TTL
+type ReservationExpirer struct {
+	service  StockService
+	interval time.Duration
+	logger   logger.Logger
+}
+
+// NewReservationExpirer creates an expirer that runs every interval
+func NewReservationExpirer(service StockService, interval time.Duration, log logger.Logger) *ReservationExpirer {
+	return &ReservationExpirer{
+		service:  service,
+		interval: interval,
+		logger:   log,
+	}
+}
+
+// Run expires reservations until ctx is cancelled, starting with an
+// immediate pass
+func (e *ReservationExpirer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		e.ExpireOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireOnce expires every reservation past its TTL
+func (e *ReservationExpirer) ExpireOnce(ctx context.Context) {
+	expired, err := e.service.ExpireReservations(ctx, time.Now())
+	if err != nil {
+		e.logger.Error(ctx, "Failed to expire stock reservations", err, logger.Fields{})
+		return
+	}
+
+	if expired > 0 {
+		e.logger.Info(ctx, "Expired stock reservations", logger.Fields{
+			"expired": expired,
+		})
+	}
+}
@@ -1,12 +1,26 @@
 package product
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"gin-service/pkg/common"
+	"gin-service/pkg/constants"
+	"gin-service/pkg/validation"
 
 	"github.com/gin-gonic/gin"
 )
 
-// ProductHandler handles HTTP requests for product endpoints
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+// ProductHandler handles HTTP requests for product endpoints. Errors are
+// attached with c.Error and rendered by middleware.ErrorHandler.
 type ProductHandler struct {
 	service ProductService
 }
@@ -22,21 +36,18 @@ func NewProductHandler(service ProductService) *ProductHandler {
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	var req CreateProductRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body: " + err.Error(),
-		})
+		c.Error(validation.Translate(err))
 		return
 	}
 
 	ctx := c.Request.Context()
 	response, err := h.service.CreateProduct(ctx, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
+	c.Header(headerETag, formatETag(response.Product.Version))
 	c.JSON(http.StatusCreated, response)
 }
 
@@ -44,21 +55,18 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 func (h *ProductHandler) GetProduct(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Product ID is required",
-		})
+		c.Error(common.NewValidationError("Product ID is required"))
 		return
 	}
 
 	ctx := c.Request.Context()
 	response, err := h.service.GetProduct(ctx, id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
+	c.Header(headerETag, formatETag(response.Product.Version))
 	c.JSON(http.StatusOK, response)
 }
 
@@ -66,18 +74,50 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 func (h *ProductHandler) GetAllProducts(c *gin.Context) {
 	var req GetProductsRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid query parameters: " + err.Error(),
-		})
+		c.Error(validation.Translate(err))
 		return
 	}
 
 	ctx := c.Request.Context()
 	response, err := h.service.GetAllProducts(ctx, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SearchProducts handles GET /api/v1/products/search requests
+func (h *ProductHandler) SearchProducts(c *gin.Context) {
+	var req SearchProductsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(validation.Translate(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	response, err := h.service.SearchProducts(ctx, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SuggestProducts handles GET /api/v1/products/suggest requests
+func (h *ProductHandler) SuggestProducts(c *gin.Context) {
+	var req SuggestProductsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(validation.Translate(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	response, err := h.service.SuggestProducts(ctx, &req)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -88,29 +128,62 @@ func (h *ProductHandler) GetAllProducts(c *gin.Context) {
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Product ID is required",
-		})
+		c.Error(common.NewValidationError("Product ID is required"))
+		return
+	}
+
+	expectedVersion, err := h.expectedVersion(c, id)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	var req UpdateProductRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body: " + err.Error(),
-		})
+		c.Error(validation.Translate(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	response, err := h.service.UpdateProduct(ctx, id, &req, expectedVersion)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header(headerETag, formatETag(response.Product.Version))
+	c.JSON(http.StatusOK, response)
+}
+
+// PatchProduct handles PATCH /api/v1/products/:id requests with a JSON Merge
+// Patch or JSON Patch body, told apart by the Content-Type header
+func (h *ProductHandler) PatchProduct(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.Error(common.NewValidationError("Product ID is required"))
+		return
+	}
+
+	expectedVersion, err := h.expectedVersion(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	document, err := c.GetRawData()
+	if err != nil {
+		c.Error(common.NewBadRequestError("Failed to read patch body"))
 		return
 	}
 
 	ctx := c.Request.Context()
-	response, err := h.service.UpdateProduct(ctx, id, &req)
+	response, err := h.service.PatchProduct(ctx, id, c.ContentType(), document, expectedVersion)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
+	c.Header(headerETag, formatETag(response.Product.Version))
 	c.JSON(http.StatusOK, response)
 }
 
@@ -118,22 +191,504 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Product ID is required",
-		})
+		c.Error(common.NewValidationError("Product ID is required"))
+		return
+	}
+
+	expectedVersion, err := h.expectedVersion(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.DeleteProduct(ctx, id, expectedVersion); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Product moved to trash",
+	})
+}
+
+// GetTrashedProducts handles GET /api/v1/products/trash requests
+func (h *ProductHandler) GetTrashedProducts(c *gin.Context) {
+	var req GetProductsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(validation.Translate(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	response, err := h.service.GetTrashedProducts(ctx, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreProduct handles POST /api/v1/products/:id/restore requests
+func (h *ProductHandler) RestoreProduct(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.Error(common.NewValidationError("Product ID is required"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	response, err := h.service.RestoreProduct(ctx, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header(headerETag, formatETag(response.Product.Version))
+	c.JSON(http.StatusOK, response)
+}
+
+// GetProductHistory handles GET /api/v1/products/:id/history requests
+func (h *ProductHandler) GetProductHistory(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.Error(common.NewValidationError("Product ID is required"))
+		return
+	}
+
+	var req GetProductHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(validation.Translate(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	response, err := h.service.GetProductHistory(ctx, id, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// PurgeProduct handles DELETE /api/v1/admin/products/:id requests,
+// permanently removing a product from the trash
+func (h *ProductHandler) PurgeProduct(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.Error(common.NewValidationError("Product ID is required"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.PurgeProduct(ctx, id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Product purged successfully",
+	})
+}
+
+// ProductAction handles POST /api/v1/products:<action> requests. Gin cannot
+// route a literal colon, so the action arrives as a parameter.
+func (h *ProductHandler) ProductAction(c *gin.Context) {
+	switch c.Param("action") {
+	case ":batch":
+		h.BatchProducts(c)
+	default:
+		c.Error(common.NewNotFoundError(constants.ErrMsgNotFound))
+	}
+}
+
+// BatchProducts handles POST /api/v1/products:batch requests
+func (h *ProductHandler) BatchProducts(c *gin.Context) {
+	var req BatchProductsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(validation.Translate(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	response, err := h.service.BatchProducts(ctx, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(batchStatus(response), response)
+}
+
+// batchStatus picks the response status for a batch: 200 when every
+// operation succeeded, 207 when a best-effort batch partly failed, and for
+// an aborted atomic batch the status of the operation that caused it
+func batchStatus(response *BatchProductsResponse) int {
+	if response.Failed == 0 {
+		return http.StatusOK
+	}
+	if response.Mode == BatchModeBestEffort {
+		return http.StatusMultiStatus
+	}
+
+	for _, result := range response.Results {
+		if result.Error != nil && result.Status != http.StatusFailedDependency {
+			return result.Status
+		}
+	}
+	return http.StatusConflict
+}
+
+// ExportProducts handles GET /api/v1/products/export requests, streaming
+// every product as CSV or NDJSON
+func (h *ProductHandler) ExportProducts(c *gin.Context) {
+	format := c.DefaultQuery("format", FormatCSV)
+	writer := &exportWriter{context: c, format: format}
+	encoder, err := NewProductEncoder(format, writer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Once streaming has started a failure can only truncate the body; the
+	// error is still recorded for the request log. Before that, the error is
+	// rendered as a regular JSON response.
+	ctx := c.Request.Context()
+	if err := h.service.ExportProducts(ctx, encoder); err != nil {
+		if !writer.started {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+		}
+		c.Error(err)
+	}
+}
+
+// exportWriter sets the download headers of an export right before its
+// first bytes are written, so that an export failing before then is not
+// served as a file
+type exportWriter struct {
+	context *gin.Context
+	format  string
+	started bool
+}
+
+// Write sets the headers on the first call and writes p to the response
+func (w *exportWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.context.Header("Content-Type", FormatContentType(w.format))
+		w.context.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="products.%s"`, w.format))
+	}
+	return w.context.Writer.Write(p)
+}
+
+// ImportProducts handles POST /api/v1/products/import requests. The format
+// comes from the format query parameter or else the Content-Type header.
+func (h *ProductHandler) ImportProducts(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		format = FormatCSV
+		if c.ContentType() == constants.ContentTypeNDJSON {
+			format = FormatNDJSON
+		}
+	}
+
+	decoder, err := NewProductDecoder(format, c.Request.Body)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	summary, err := h.service.ImportProducts(ctx, format, decoder)
+	if err != nil {
+		if !common.IsAppError(err) {
+			err = common.NewValidationErrorWithDetails("Failed to read import body", err.Error())
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// formatETag renders a product version as a strong entity tag
+func formatETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// expectedVersion resolves the If-Match header of a product write to the
+// version it must apply to, as described at ifMatchVersion
+func (h *ProductHandler) expectedVersion(c *gin.Context, id string) (int64, error) {
+	return ifMatchVersion(c, ErrVersionConflict, func(ctx context.Context) (int64, error) {
+		current, err := h.service.GetProduct(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return current.Product.Version, nil
+	})
+}
+
+// ifMatchVersion resolves the If-Match header of a write to the version it
+// must apply to. The header is required; "*" matches any version and
+// returns zero. When the header lists several tags, current looks up the
+// resource's version and the matching tag is returned, so that the write
+// itself still fails if the resource changes in between. A header no tag of
+// which can match fails with conflict.
+func ifMatchVersion(c *gin.Context, conflict error, current func(ctx context.Context) (int64, error)) (int64, error) {
+	header := c.GetHeader(headerIfMatch)
+	if strings.TrimSpace(header) == "" {
+		return 0, common.NewPreconditionRequiredError("If-Match header is required")
+	}
+
+	versions, err := parseIfMatch(header)
+	if err != nil {
+		return 0, common.NewBadRequestError(err.Error())
+	}
+	if versions == nil {
+		return 0, nil
+	}
+	if len(versions) == 0 {
+		return 0, common.NewPreconditionFailedError("Weak entity tags never match If-Match")
+	}
+	if len(versions) == 1 {
+		return versions[0], nil
+	}
+
+	version, err := current(c.Request.Context())
+	if err != nil {
+		return 0, err
+	}
+	for _, candidate := range versions {
+		if candidate == version {
+			return candidate, nil
+		}
+	}
+	return 0, common.NewPreconditionFailedError(conflict.Error())
+}
+
+// parseIfMatch extracts the product versions listed in an If-Match header.
+// "*" returns nil, meaning any version. If-Match uses strong comparison, so
+// weak tags are skipped; a header of only weak tags returns an empty slice.
+func parseIfMatch(header string) ([]int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return nil, nil
+	}
+
+	versions := []int64{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		opaque := strings.TrimPrefix(tag, "W/")
+		unquoted, err := strconv.Unquote(opaque)
+		if err != nil || !strings.HasPrefix(opaque, `"`) {
+			return nil, fmt.Errorf("invalid If-Match header: %s", header)
+		}
+		if opaque != tag {
+			continue
+		}
+
+		version, err := strconv.ParseInt(unquoted, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid If-Match header: %s", header)
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
+
+// StockHandler handles HTTP requests for stock reservation endpoints
+type StockHandler struct {
+	service StockService
+}
+
+// NewStockHandler creates a new stock handler instance
+func NewStockHandler(service StockService) *StockHandler {
+	return &StockHandler{
+		service: service,
+	}
+}
+
+// ReserveStock handles POST /api/v1/products/:id/stock/reserve requests
+func (h *StockHandler) ReserveStock(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.Error(common.NewValidationError("Product ID is required"))
+		return
+	}
+
+	var req ReserveStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(validation.Translate(err))
 		return
 	}
 
 	ctx := c.Request.Context()
-	err := h.service.DeleteProduct(ctx, id)
+	response, err := h.service.ReserveStock(ctx, id, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// ReleaseStock handles POST /api/v1/products/:id/stock/release requests
+func (h *StockHandler) ReleaseStock(c *gin.Context) {
+	h.resolveReservation(c, h.service.ReleaseStock)
+}
+
+// CommitStock handles POST /api/v1/products/:id/stock/commit requests
+func (h *StockHandler) CommitStock(c *gin.Context) {
+	h.resolveReservation(c, h.service.CommitStock)
+}
+
+// resolveReservation binds a reservation action request and applies resolve
+func (h *StockHandler) resolveReservation(c *gin.Context, resolve func(ctx context.Context, productID, reservationID string) (*ReservationResponse, error)) {
+	id := c.Param("id")
+	if id == "" {
+		c.Error(common.NewValidationError("Product ID is required"))
+		return
+	}
+
+	var req ReservationActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(validation.Translate(err))
+		return
+	}
+
+	response, err := resolve(c.Request.Context(), id, req.ReservationID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// VariantHandler handles HTTP requests for product variant endpoints
+type VariantHandler struct {
+	service VariantService
+}
+
+// NewVariantHandler creates a new variant handler instance
+func NewVariantHandler(service VariantService) *VariantHandler {
+	return &VariantHandler{
+		service: service,
+	}
+}
+
+// CreateVariant handles POST /api/v1/products/:id/variants requests
+func (h *VariantHandler) CreateVariant(c *gin.Context) {
+	var req CreateVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(validation.Translate(err))
+		return
+	}
+
+	response, err := h.service.CreateVariant(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header(headerETag, formatETag(response.Variant.Version))
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetVariants handles GET /api/v1/products/:id/variants requests
+func (h *VariantHandler) GetVariants(c *gin.Context) {
+	response, err := h.service.GetVariants(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetVariant handles GET /api/v1/products/:id/variants/:variant_id requests
+func (h *VariantHandler) GetVariant(c *gin.Context) {
+	response, err := h.service.GetVariant(c.Request.Context(), c.Param("id"), c.Param("variant_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header(headerETag, formatETag(response.Variant.Version))
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateVariant handles PUT /api/v1/products/:id/variants/:variant_id requests
+func (h *VariantHandler) UpdateVariant(c *gin.Context) {
+	expectedVersion, err := h.expectedVersion(c)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		c.Error(err)
+		return
+	}
+
+	var req UpdateVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(validation.Translate(err))
+		return
+	}
+
+	response, err := h.service.UpdateVariant(c.Request.Context(), c.Param("id"), c.Param("variant_id"), &req, expectedVersion)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header(headerETag, formatETag(response.Variant.Version))
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteVariant handles DELETE /api/v1/products/:id/variants/:variant_id requests
+func (h *VariantHandler) DeleteVariant(c *gin.Context) {
+	expectedVersion, err := h.expectedVersion(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.service.DeleteVariant(c.Request.Context(), c.Param("id"), c.Param("variant_id"), expectedVersion); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Product deleted successfully",
+		"message": "Variant deleted successfully",
+	})
+}
+
+// GetVariantHistory handles GET /api/v1/products/:id/variants/:variant_id/history requests
+func (h *VariantHandler) GetVariantHistory(c *gin.Context) {
+	var req GetProductHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(validation.Translate(err))
+		return
+	}
+
+	response, err := h.service.GetVariantHistory(c.Request.Context(), c.Param("id"), c.Param("variant_id"), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// expectedVersion resolves the If-Match header of a variant write to the
+// version it must apply to, as described at ifMatchVersion
+func (h *VariantHandler) expectedVersion(c *gin.Context) (int64, error) {
+	return ifMatchVersion(c, ErrVariantVersionConflict, func(ctx context.Context) (int64, error) {
+		current, err := h.service.GetVariant(ctx, c.Param("id"), c.Param("variant_id"))
+		if err != nil {
+			return 0, err
+		}
+		return current.Variant.Version, nil
 	})
 }
@@ -0,0 +1,271 @@
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gin-service/pkg/audit"
+	"gin-service/pkg/common"
+	"gin-service/pkg/database"
+	"gin-service/pkg/middleware"
+	"gin-service/pkg/money"
+	"gin-service/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRouter serves the single-product routes over an
+// in-memory repository seeded with one product at version 1
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repo := NewProductRepository()
+	seedProducts(t, repo, &Product{ID: "p1", Name: "Mug", Price: usd("5"), CategoryID: "x", Stock: 1})
+	handler := NewProductHandler(NewProductService(repo, NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore()))
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.POST("/products", handler.CreateProduct)
+	router.GET("/products/:id", handler.GetProduct)
+	router.PUT("/products/:id", handler.UpdateProduct)
+	router.PATCH("/products/:id", handler.PatchProduct)
+	router.DELETE("/products/:id", handler.DeleteProduct)
+	return router
+}
+
+func performRequest(router *gin.Engine, method, target, ifMatch, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		request.Header.Set(headerIfMatch, ifMatch)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func errorCode(t *testing.T, recorder *httptest.ResponseRecorder) common.ErrorCode {
+	t.Helper()
+	var response common.Response
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.NotNil(t, response.Error)
+	return response.Error.Code
+}
+
+func TestProductHandler_GetProduct_SetsETag(t *testing.T) {
+	router := newTestRouter(t)
+
+	recorder := performRequest(router, http.MethodGet, "/products/p1", "", "")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `"1"`, recorder.Header().Get(headerETag))
+}
+
+func TestProductHandler_UpdateProduct_HonoursIfMatch(t *testing.T) {
+	router := newTestRouter(t)
+	body := `{"stock": 3}`
+
+	recorder := performRequest(router, http.MethodPut, "/products/p1", `"1"`, body)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `"2"`, recorder.Header().Get(headerETag))
+
+	recorder = performRequest(router, http.MethodPut, "/products/p1", `"1"`, body)
+	assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+	assert.Equal(t, common.ErrorCodePrecondition, errorCode(t, recorder))
+
+	recorder = performRequest(router, http.MethodPut, "/products/p1", `"1", "2"`, body)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `"3"`, recorder.Header().Get(headerETag))
+
+	recorder = performRequest(router, http.MethodPut, "/products/p1", "*", body)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `"4"`, recorder.Header().Get(headerETag))
+}
+
+func TestProductHandler_RejectsMissingAndWeakIfMatch(t *testing.T) {
+	router := newTestRouter(t)
+
+	tests := []struct {
+		name    string
+		method  string
+		ifMatch string
+		status  int
+	}{
+		{"put without If-Match", http.MethodPut, "", http.StatusPreconditionRequired},
+		{"patch without If-Match", http.MethodPatch, "", http.StatusPreconditionRequired},
+		{"delete without If-Match", http.MethodDelete, "", http.StatusPreconditionRequired},
+		{"weak tag", http.MethodPut, `W/"1"`, http.StatusPreconditionFailed},
+		{"weak tag on delete", http.MethodDelete, `W/"1"`, http.StatusPreconditionFailed},
+		{"stale tag list", http.MethodDelete, `"7", W/"1"`, http.StatusPreconditionFailed},
+		{"unquoted tag", http.MethodPut, "1", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := performRequest(router, tt.method, "/products/p1", tt.ifMatch, `{"stock": 3}`)
+			assert.Equal(t, tt.status, recorder.Code)
+		})
+	}
+
+	// None of the rejected writes may have changed the product
+	recorder := performRequest(router, http.MethodGet, "/products/p1", "", "")
+	assert.Equal(t, `"1"`, recorder.Header().Get(headerETag))
+}
+
+func TestParseIfMatch(t *testing.T) {
+	versions, err := parseIfMatch(`"3", W/"4" ,"5"`)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 5}, versions)
+
+	versions, err = parseIfMatch("*")
+	require.NoError(t, err)
+	assert.Nil(t, versions)
+
+	for _, header := range []string{`"abc"`, `"0"`, `'1'`, `"1",`} {
+		_, err := parseIfMatch(header)
+		assert.Error(t, err, header)
+	}
+}
+
+func TestProductHandler_CreateProduct_AcceptsZeroStock(t *testing.T) {
+	router := newTestRouter(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"zero stock", `{"name": "Mug", "price": {"amount": "5.00", "currency": "USD"}, "category_id": "x", "stock": 0}`, http.StatusCreated},
+		{"stock left out", `{"name": "Mug", "price": {"amount": "5.00", "currency": "USD"}, "category_id": "x"}`, http.StatusCreated},
+		{"negative stock", `{"name": "Mug", "price": {"amount": "5.00", "currency": "USD"}, "category_id": "x", "stock": -1}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := performRequest(router, http.MethodPost, "/products", "", tt.body)
+			assert.Equal(t, tt.status, recorder.Code, recorder.Body.String())
+		})
+	}
+}
+
+func TestVariantHandler_HonoursIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	require.NoError(t, validation.Setup())
+	products := NewProductRepository()
+	seedProducts(t, products, &Product{ID: "p1", Name: "Shirt", Price: usd("20"), CategoryID: "x"})
+	handler := NewVariantHandler(NewVariantService(products, NewVariantRepository(), database.NewNoopTransactionManager(), audit.NewMemoryStore()))
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.POST("/products/:id/variants", handler.CreateVariant)
+	router.PUT("/products/:id/variants/:variant_id", handler.UpdateVariant)
+	router.DELETE("/products/:id/variants/:variant_id", handler.DeleteVariant)
+
+	recorder := performRequest(router, http.MethodPost, "/products/p1/variants", "",
+		`{"sku": "SHIRT-M", "price": {"amount": "22.00", "currency": "USD"}, "stock": 1}`)
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+	assert.Equal(t, `"1"`, recorder.Header().Get(headerETag))
+	var created VariantResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	target := "/products/p1/variants/" + created.Variant.ID
+
+	recorder = performRequest(router, http.MethodPut, target, "", `{"stock": 2}`)
+	assert.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+
+	recorder = performRequest(router, http.MethodPut, target, `"1"`, `{"clear_price": true}`)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, `"2"`, recorder.Header().Get(headerETag))
+	var updated VariantResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &updated))
+	assert.Nil(t, updated.Variant.Price)
+
+	recorder = performRequest(router, http.MethodDelete, target, `"1"`, "")
+	assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+
+	recorder = performRequest(router, http.MethodDelete, target, `"1", "2"`, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestProductHandler_PatchProduct_KeepsCurrencyWithNumberPrices(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := NewProductRepository()
+	seedProducts(t, repo, &Product{ID: "p1", Name: "Mug", Price: money.MustParse("5.00", "EUR"), CategoryID: "x", Stock: 1})
+	handler := NewProductHandler(NewProductService(repo, NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore()))
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(), middleware.NumberPrices())
+	router.PATCH("/products/:id", handler.PatchProduct)
+
+	patch := func(ifMatch, contentType, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPatch, "/products/p1", strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		request.Header.Set(headerIfMatch, ifMatch)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := patch(`"1"`, "application/merge-patch+json", `{"stock": 3}`)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Contains(t, recorder.Body.String(), `"price":5`)
+
+	recorder = patch(`"2"`, "application/json-patch+json", `[{"op": "replace", "path": "/price/amount", "value": "6.50"}]`)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	product, err := repo.GetByID(context.Background(), "p1")
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("6.50", "EUR"), product.Price)
+	assert.Equal(t, 3, product.Stock)
+}
+
+// failingListRepository fails every product listing
+type failingListRepository struct {
+	ProductRepository
+}
+
+func (failingListRepository) GetAll(ctx context.Context, query ProductQuery) ([]*Product, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestProductHandler_ExportProducts_RendersEarlyFailuresAsJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := failingListRepository{ProductRepository: NewProductRepository()}
+	handler := NewProductHandler(NewProductService(repo, NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore()))
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.GET("/products/export", handler.ExportProducts)
+
+	recorder := performRequest(router, http.MethodGet, "/products/export?format=csv", "", "")
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "application/json")
+	assert.Empty(t, recorder.Header().Get("Content-Disposition"))
+	assert.Equal(t, common.ErrorCodeInternal, errorCode(t, recorder))
+}
+
+func TestProductHandler_ExportProducts_SetsDownloadHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := NewProductRepository()
+	seedProducts(t, repo, &Product{ID: "p1", Name: "Mug", Price: usd("5"), CategoryID: "x", Stock: 1})
+	handler := NewProductHandler(NewProductService(repo, NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore()))
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.GET("/products/export", handler.ExportProducts)
+
+	recorder := performRequest(router, http.MethodGet, "/products/export?format=csv", "", "")
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, FormatContentType(FormatCSV), recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="products.csv"`, recorder.Header().Get("Content-Disposition"))
+	assert.Contains(t, recorder.Body.String(), "p1,Mug")
+}
@@ -1,22 +1,134 @@
 package product
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // ProductService defines the interface for product business logic
 type ProductService interface {
 	CreateProduct(ctx context.Context, req *CreateProductRequest) (*ProductResponse, error)
 	GetProduct(ctx context.Context, id string) (*ProductResponse, error)
 	GetAllProducts(ctx context.Context, req *GetProductsRequest) (*GetProductsResponse, error)
-	UpdateProduct(ctx context.Context, id string, req *UpdateProductRequest) (*ProductResponse, error)
-	DeleteProduct(ctx context.Context, id string) error
+	UpdateProduct(ctx context.Context, id string, req *UpdateProductRequest, expectedVersion int64) (*ProductResponse, error)
+	PatchProduct(ctx context.Context, id, contentType string, document []byte, expectedVersion int64) (*ProductResponse, error)
+	DeleteProduct(ctx context.Context, id string, expectedVersion int64) error
+	BatchProducts(ctx context.Context, req *BatchProductsRequest) (*BatchProductsResponse, error)
+	ExportProducts(ctx context.Context, encoder ProductEncoder) error
+	ImportProducts(ctx context.Context, format string, decoder ProductDecoder) (*ImportSummary, error)
+	GetTrashedProducts(ctx context.Context, req *GetProductsRequest) (*GetProductsResponse, error)
+	RestoreProduct(ctx context.Context, id string) (*ProductResponse, error)
+	PurgeProduct(ctx context.Context, id string) error
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error)
+	GetProductHistory(ctx context.Context, id string, req *GetProductHistoryRequest) (*ProductHistoryResponse, error)
+	SearchProducts(ctx context.Context, req *SearchProductsRequest) (*SearchProductsResponse, error)
+	SuggestProducts(ctx context.Context, req *SuggestProductsRequest) (*SuggestProductsResponse, error)
 }
 
-// ProductRepository defines the interface for product data access
+// ProductRepository defines the interface for product data access. Trashed
+// products are invisible to every method except Restore, Purge,
+// PurgeDeletedBefore and queries whose filter selects the trash.
 type ProductRepository interface {
 	Create(ctx context.Context, product *Product) error
+	// CreateMany stores every product or, on any failure, none of them
+	CreateMany(ctx context.Context, products []*Product) error
 	GetByID(ctx context.Context, id string) (*Product, error)
-	GetAll(ctx context.Context, limit, offset int) ([]*Product, error)
+	// GetByIDs retrieves the products with the given IDs, skipping unknown IDs
+	GetByIDs(ctx context.Context, ids []string) ([]*Product, error)
+	GetAll(ctx context.Context, query ProductQuery) ([]*Product, error)
+	GetAllAfter(ctx context.Context, query ProductQuery, cursor *ProductCursor) ([]*Product, error)
+	// Update only succeeds when product.Version matches the stored version,
+	// and increments it
 	Update(ctx context.Context, product *Product) error
-	Delete(ctx context.Context, id string) error
-	Count(ctx context.Context) (int64, error)
+	// UpdateMany applies Update to each product and returns one error per
+	// input, nil for products that were written
+	UpdateMany(ctx context.Context, products []*Product) ([]error, error)
+	// Delete moves a product to the trash, checking its version unless
+	// expectedVersion is zero
+	Delete(ctx context.Context, id string, expectedVersion int64) error
+	// DeleteMany applies Delete to each reference and returns one error per
+	// input, nil for products that were trashed
+	DeleteMany(ctx context.Context, refs []ProductVersion) ([]error, error)
+	Restore(ctx context.Context, id string) (*Product, error)
+	Purge(ctx context.Context, id string) error
+	// PurgeDeletedBefore permanently removes products trashed before cutoff
+	// and returns their IDs
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]string, error)
+	// AdjustStock adds delta to the stock in one atomic step, bumping the
+	// version, and fails with ErrInsufficientStock rather than go below zero
+	AdjustStock(ctx context.Context, id string, delta int) (*Product, error)
+	Count(ctx context.Context, filter ProductFilter) (int64, error)
+	// CountByCategory counts live and trashed products filed under a category
+	CountByCategory(ctx context.Context, categoryID string) (int64, error)
+	// Search ranks live products by relevance to the query text and returns
+	// one page of hits plus the total number of matches
+	Search(ctx context.Context, query SearchQuery) ([]*SearchHit, int64, error)
+	// Suggest returns distinct live product names with a word starting with
+	// prefix, names that start with it first
+	Suggest(ctx context.Context, prefix string, limit int) ([]string, error)
+}
+
+// AtomicBatchWriter is implemented by repositories that have no transactions
+// but can still apply a batch all-or-nothing. WriteBatch checks every write
+// under one lock and writes nothing unless all of them pass; the returned
+// errors line up with their inputs, nil for writes that passed.
+type AtomicBatchWriter interface {
+	WriteBatch(ctx context.Context, creates, updates []*Product, deletes []ProductVersion) (createErrs, updateErrs, deleteErrs []error)
+}
+
+// VariantService defines the interface for product variant business logic
+type VariantService interface {
+	CreateVariant(ctx context.Context, productID string, req *CreateVariantRequest) (*VariantResponse, error)
+	GetVariant(ctx context.Context, productID, variantID string) (*VariantResponse, error)
+	GetVariants(ctx context.Context, productID string) (*GetVariantsResponse, error)
+	UpdateVariant(ctx context.Context, productID, variantID string, req *UpdateVariantRequest, expectedVersion int64) (*VariantResponse, error)
+	DeleteVariant(ctx context.Context, productID, variantID string, expectedVersion int64) error
+	GetVariantHistory(ctx context.Context, productID, variantID string, req *GetProductHistoryRequest) (*ProductHistoryResponse, error)
+}
+
+// VariantRepository defines the interface for variant data access. Create
+// and Update fail with ErrSKUTaken when another variant has the same SKU.
+// Update only succeeds when variant.Version matches the stored version and
+// increments it; Delete does the same check unless expectedVersion is zero.
+// Both fail with ErrVariantVersionConflict otherwise. AdjustStock adds delta
+// to the stock in one atomic step, bumping the version, and fails with
+// ErrInsufficientStock rather than go below zero. GetByProduct lists a
+// product's variants ordered by SKU; GetByProducts does the same for several
+// products at once. DeleteByProducts removes every variant of the given
+// products.
+type VariantRepository interface {
+	Create(ctx context.Context, variant *Variant) error
+	GetByID(ctx context.Context, id string) (*Variant, error)
+	GetByProduct(ctx context.Context, productID string) ([]*Variant, error)
+	GetByProducts(ctx context.Context, productIDs []string) ([]*Variant, error)
+	Update(ctx context.Context, variant *Variant) error
+	Delete(ctx context.Context, id string, expectedVersion int64) error
+	AdjustStock(ctx context.Context, id string, delta int) (*Variant, error)
+	DeleteByProducts(ctx context.Context, productIDs []string) error
+}
+
+// CategoryLookup reports whether a category exists, so that products only
+// reference known categories
+type CategoryLookup interface {
+	Exists(ctx context.Context, id string) (bool, error)
+}
+
+// StockService defines the interface for stock reservations
+type StockService interface {
+	ReserveStock(ctx context.Context, productID string, req *ReserveStockRequest) (*ReservationResponse, error)
+	ReleaseStock(ctx context.Context, productID, reservationID string) (*ReservationResponse, error)
+	CommitStock(ctx context.Context, productID, reservationID string) (*ReservationResponse, error)
+	ExpireReservations(ctx context.Context, now time.Time) (int, error)
+}
+
+// ReservationRepository defines the interface for reservation data access.
+// Resolve moves an active reservation to a final status and fails with
+// ErrReservationNotActive otherwise, so each reservation resolves once.
+// DeleteByProducts removes every reservation of the given products.
+type ReservationRepository interface {
+	Create(ctx context.Context, reservation *Reservation) error
+	GetByID(ctx context.Context, id string) (*Reservation, error)
+	Resolve(ctx context.Context, id, status string) (*Reservation, error)
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]*Reservation, error)
+	DeleteByProducts(ctx context.Context, productIDs []string) error
 }
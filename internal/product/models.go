@@ -1,37 +1,48 @@
 package product
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
+
+	"gin-service/pkg/audit"
+	"gin-service/pkg/common"
+	"gin-service/pkg/money"
 )
 
-// Product represents the product entity
+// Product represents the product entity. A product with DeletedAt set is in
+// the trash and hidden from everything but trash listings.
 type Product struct {
-	ID          string    `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name"`
-	Description string    `json:"description" db:"description"`
-	Price       float64   `json:"price" db:"price"`
-	Category    string    `json:"category" db:"category"`
-	Stock       int       `json:"stock" db:"stock"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+	CategoryID  string      `json:"category_id"`
+	Stock       int         `json:"stock"`
+	Version     int64       `json:"version"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	DeletedAt   *time.Time  `json:"deleted_at,omitempty"`
 }
 
-// CreateProductRequest represents the request for creating a product
+// CreateProductRequest represents the request for creating a product. Price
+// also accepts a bare number in the default currency.
 type CreateProductRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"required,gt=0"`
-	Category    string  `json:"category" binding:"required"`
-	Stock       int     `json:"stock" binding:"required,gte=0"`
+	Name        string      `json:"name" binding:"required"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+	CategoryID  string      `json:"category_id" binding:"required"`
+	Stock       int         `json:"stock" binding:"gte=0"`
 }
 
 // UpdateProductRequest represents the request for updating a product
 type UpdateProductRequest struct {
-	Name        *string  `json:"name"`
-	Description *string  `json:"description"`
-	Price       *float64 `json:"price" binding:"omitempty,gt=0"`
-	Category    *string  `json:"category"`
-	Stock       *int     `json:"stock" binding:"omitempty,gte=0"`
+	Name        *string      `json:"name"`
+	Description *string      `json:"description"`
+	Price       *money.Money `json:"price"`
+	CategoryID  *string      `json:"category_id"`
+	Stock       *int         `json:"stock" binding:"omitempty,gte=0"`
 }
 
 // ProductResponse represents the response for product operations
@@ -40,16 +51,344 @@ type ProductResponse struct {
 	Message string   `json:"message,omitempty"`
 }
 
-// GetProductsRequest represents the request for getting products with pagination
+// GetProductsRequest represents the request for getting products with pagination,
+// filtering and sorting
 type GetProductsRequest struct {
+	Limit      int      `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset     int      `form:"offset" binding:"omitempty,min=0"`
+	CategoryID string   `form:"category_id"`
+	MinPrice   *float64 `form:"min_price" binding:"omitempty,gte=0"`
+	MaxPrice   *float64 `form:"max_price" binding:"omitempty,gte=0"`
+	InStock    bool     `form:"in_stock"`
+	Search     string   `form:"search"`
+	Sort       string   `form:"sort"`
+	Order      string   `form:"order" binding:"omitempty,oneof=asc desc"`
+	Cursor     string   `form:"cursor"`
+}
+
+// ProductFilter holds the criteria a product must match to be listed.
+// Trashed selects products in the trash instead of live ones.
+type ProductFilter struct {
+	CategoryID  string
+	MinPrice    *float64
+	MaxPrice    *float64
+	InStockOnly bool
+	Search      string
+	Trashed     bool
+}
+
+// ProductQuery describes a filtered, sorted and paginated product listing
+type ProductQuery struct {
+	Filter    ProductFilter
+	SortField string
+	SortOrder string
+	Limit     int
+	Offset    int
+}
+
+// SortableFields maps the sort names accepted by the API to database columns.
+// Text fields sort by byte order rather than by locale, so uppercase letters
+// come before lowercase ones; both repositories order them the same way.
+var SortableFields = map[string]string{
+	"id":          "id",
+	"name":        "name",
+	"description": "description",
+	"price":       "price",
+	"category_id": "category_id",
+	"stock":       "stock",
+	"created_at":  "created_at",
+	"updated_at":  "updated_at",
+}
+
+// DefaultSortField is used when a listing does not request a sort field
+const DefaultSortField = "created_at"
+
+// GetProductsResponse represents the response for getting multiple products.
+// NextCursor is set when more products follow and can be passed back as
+// cursor to fetch the next page without offsets.
+type GetProductsResponse struct {
+	Products   []*Product `json:"products"`
+	Total      int64      `json:"total"`
+	Limit      int        `json:"limit"`
+	Offset     int        `json:"offset"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// SearchProductsRequest represents the request for a full-text product search
+type SearchProductsRequest struct {
+	Q          string `form:"q" binding:"required,max=255"`
+	CategoryID string `form:"category_id"`
+	Limit      int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset     int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+// SearchQuery describes a page of a full-text search over live products.
+// Every indexable word of Text must match; stop words are ignored.
+type SearchQuery struct {
+	Text       string
+	CategoryID string
+	Limit      int
+	Offset     int
+}
+
+// SearchHighlights holds a product's name and a snippet of its description
+// with the matched words wrapped in <mark> tags
+type SearchHighlights struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// SearchHit is a product matching a search together with its relevance.
+// Scores only order the hits of one search and are not comparable across
+// searches or repositories.
+type SearchHit struct {
+	Product    *Product         `json:"product"`
+	Score      float64          `json:"score"`
+	Highlights SearchHighlights `json:"highlights"`
+}
+
+// SearchProductsResponse represents a page of search hits, best match first
+type SearchProductsResponse struct {
+	Results []*SearchHit `json:"results"`
+	Total   int64        `json:"total"`
+	Limit   int          `json:"limit"`
+	Offset  int          `json:"offset"`
+}
+
+// SuggestProductsRequest represents the request for typeahead suggestions
+type SuggestProductsRequest struct {
+	Q     string `form:"q" binding:"required,max=100"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=20"`
+}
+
+// SuggestProductsResponse represents the product names completing a prefix
+type SuggestProductsResponse struct {
+	Suggestions []string `json:"suggestions"`
+}
+
+// DefaultSuggestionLimit is used when a suggestion request does not set a limit
+const DefaultSuggestionLimit = 10
+
+// ProductVersion references a product together with the version a write
+// expects it to have. A zero Version matches any version.
+type ProductVersion struct {
+	ID      string
+	Version int64
+}
+
+// Batch operation types
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// Batch modes. Atomic batches apply every operation or none of them;
+// best-effort batches apply each operation that succeeds on its own.
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+)
+
+// BatchOperation is a single create, update or delete within a batch.
+// Product carries the fields of a create and Changes those of an update;
+// Version optionally makes an update or delete conditional.
+type BatchOperation struct {
+	Op      string                `json:"op" binding:"required,oneof=create update delete"`
+	ID      string                `json:"id"`
+	Version int64                 `json:"version" binding:"omitempty,gte=0"`
+	Product *CreateProductRequest `json:"product"`
+	Changes *UpdateProductRequest `json:"changes"`
+}
+
+// BatchProductsRequest represents the request for applying several product
+// operations at once
+type BatchProductsRequest struct {
+	Mode       string           `json:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,max=1000,dive"`
+}
+
+// BatchResult reports the outcome of one operation, in request order
+type BatchResult struct {
+	Index   int              `json:"index"`
+	Op      string           `json:"op"`
+	ID      string           `json:"id,omitempty"`
+	Status  int              `json:"status"`
+	Product *Product         `json:"product,omitempty"`
+	Error   *common.AppError `json:"error,omitempty"`
+}
+
+// BatchProductsResponse represents the response for a batch of product operations
+type BatchProductsResponse struct {
+	Mode      string        `json:"mode"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// ImportRowError reports a rejected import row by the line it starts on
+type ImportRowError struct {
+	Line  int              `json:"line"`
+	Error *common.AppError `json:"error"`
+}
+
+// ImportSummary represents the outcome of a product import. Errors lists at
+// most MaxImportErrors rejected rows; Failed counts all of them.
+type ImportSummary struct {
+	Format   string           `json:"format"`
+	Total    int              `json:"total"`
+	Imported int              `json:"imported"`
+	Failed   int              `json:"failed"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// MaxImportErrors caps the row errors reported in an ImportSummary
+const MaxImportErrors = 1000
+
+// GetProductHistoryRequest represents the request for a product's change history
+type GetProductHistoryRequest struct {
 	Limit  int `form:"limit" binding:"omitempty,min=1,max=100"`
 	Offset int `form:"offset" binding:"omitempty,min=0"`
 }
 
-// GetProductsResponse represents the response for getting multiple products
-type GetProductsResponse struct {
-	Products []*Product `json:"products"`
-	Total    int64      `json:"total"`
-	Limit    int        `json:"limit"`
-	Offset   int        `json:"offset"`
+// ProductHistoryResponse represents a page of a product's audit events
+type ProductHistoryResponse struct {
+	Events []*audit.Event `json:"events"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+// Reservation statuses. Only active reservations hold stock; the others are final.
+const (
+	ReservationActive    = "active"
+	ReservationCommitted = "committed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+)
+
+// Reservation holds stock of a product, or of one of its variants when
+// VariantID is set, for a checkout. Reserving takes the quantity out of
+// stock; releasing or expiry puts it back and committing keeps it out for
+// good.
+type Reservation struct {
+	ID        string    `json:"id" db:"id"`
+	ProductID string    `json:"product_id" db:"product_id"`
+	VariantID string    `json:"variant_id,omitempty" db:"variant_id"`
+	Quantity  int       `json:"quantity" db:"quantity"`
+	Status    string    `json:"status" db:"status"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ReserveStockRequest represents the request for reserving stock. The
+// stock of VariantID is reserved instead of the product's when it is set.
+// TTLSeconds overrides the configured reservation TTL.
+type ReserveStockRequest struct {
+	VariantID  string `json:"variant_id"`
+	Quantity   int    `json:"quantity" binding:"required,min=1"`
+	TTLSeconds int    `json:"ttl_seconds" binding:"omitempty,min=1,max=86400"`
+}
+
+// ReservationActionRequest represents the request for releasing or
+// committing a reservation
+type ReservationActionRequest struct {
+	ReservationID string `json:"reservation_id" binding:"required"`
+}
+
+// ReservationResponse represents the response for reservation operations
+type ReservationResponse struct {
+	Reservation *Reservation `json:"reservation"`
+	Message     string       `json:"message,omitempty"`
+}
+
+// Variant attribute types. Values are sent as strings and must parse as their type.
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+)
+
+// VariantAttribute is a typed name/value pair distinguishing a variant, for
+// example size or color
+type VariantAttribute struct {
+	Name  string `json:"name" binding:"required,max=64"`
+	Type  string `json:"type" binding:"required,oneof=string number boolean"`
+	Value string `json:"value" binding:"required,max=255"`
+}
+
+// VariantAttributes is the attribute list of a variant. It is stored as JSON.
+type VariantAttributes []VariantAttribute
+
+// Value implements driver.Valuer
+func (a VariantAttributes) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(a)
+}
+
+// Scan implements sql.Scanner
+func (a *VariantAttributes) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into variant attributes", src)
+	}
+
+	return json.Unmarshal(data, a)
+}
+
+// Variant is a sellable version of a product with its own SKU and stock.
+// Price overrides the product's price when set. Version increments on every
+// write and guards conditional updates like a product's.
+type Variant struct {
+	ID         string            `json:"id"`
+	ProductID  string            `json:"product_id"`
+	SKU        string            `json:"sku"`
+	Attributes VariantAttributes `json:"attributes"`
+	Price      *money.Money      `json:"price,omitempty"`
+	Stock      int               `json:"stock"`
+	Version    int64             `json:"version"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// CreateVariantRequest represents the request for creating a variant
+type CreateVariantRequest struct {
+	SKU        string            `json:"sku" binding:"required,max=64,sku"`
+	Attributes VariantAttributes `json:"attributes" binding:"omitempty,max=20,dive"`
+	Price      *money.Money      `json:"price"`
+	Stock      int               `json:"stock" binding:"gte=0"`
+}
+
+// UpdateVariantRequest represents the request for updating a variant. When
+// Attributes is present it replaces every attribute of the variant.
+// ClearPrice removes the price override so the product's price applies
+// again; it cannot be combined with Price.
+type UpdateVariantRequest struct {
+	SKU        *string           `json:"sku" binding:"omitempty,max=64,sku"`
+	Attributes VariantAttributes `json:"attributes" binding:"omitempty,max=20,dive"`
+	Price      *money.Money      `json:"price"`
+	ClearPrice bool              `json:"clear_price"`
+	Stock      *int              `json:"stock" binding:"omitempty,gte=0"`
+}
+
+// VariantResponse represents the response for variant operations
+type VariantResponse struct {
+	Variant *Variant `json:"variant"`
+	Message string   `json:"message,omitempty"`
+}
+
+// GetVariantsResponse represents the variants of a product, ordered by SKU
+type GetVariantsResponse struct {
+	Variants []*Variant `json:"variants"`
+	Total    int        `json:"total"`
 }
@@ -0,0 +1,720 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gin-service/pkg/constants"
+	"gin-service/pkg/database/postgresql"
+	"gin-service/pkg/money"
+	"gin-service/pkg/search"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// productsTable is the table products are persisted in
+const productsTable = "products"
+
+// productRow is the stored form of a product. The price is kept as a NUMERIC
+// amount in major units next to its currency code.
+type productRow struct {
+	ID          string     `db:"id"`
+	Name        string     `db:"name"`
+	Description string     `db:"description"`
+	Price       string     `db:"price"`
+	Currency    string     `db:"currency"`
+	CategoryID  string     `db:"category_id"`
+	Stock       int        `db:"stock"`
+	Version     int64      `db:"version"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+	DeletedAt   *time.Time `db:"deleted_at"`
+}
+
+// newProductRow converts a product to its stored form
+func newProductRow(product *Product) *productRow {
+	return &productRow{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price.Decimal(),
+		Currency:    product.Price.Currency,
+		CategoryID:  product.CategoryID,
+		Stock:       product.Stock,
+		Version:     product.Version,
+		CreatedAt:   product.CreatedAt,
+		UpdatedAt:   product.UpdatedAt,
+		DeletedAt:   product.DeletedAt,
+	}
+}
+
+// product converts a stored row back to a product
+func (row *productRow) product() (*Product, error) {
+	price, err := money.Parse(row.Price, row.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price of product %s: %w", row.ID, err)
+	}
+
+	return &Product{
+		ID:          row.ID,
+		Name:        row.Name,
+		Description: row.Description,
+		Price:       price,
+		CategoryID:  row.CategoryID,
+		Stock:       row.Stock,
+		Version:     row.Version,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+		DeletedAt:   row.DeletedAt,
+	}, nil
+}
+
+// postgreSQLProductRepository implements ProductRepository backed by PostgreSQL
+type postgreSQLProductRepository struct {
+	conn    postgresql.Connection
+	base    postgresql.Repository[productRow]
+	columns string
+}
+
+// NewPostgreSQLProductRepository creates a new PostgreSQL-backed product repository
+func NewPostgreSQLProductRepository(conn postgresql.Connection) (ProductRepository, error) {
+	base, err := postgresql.NewPostgreSQLRepository[productRow](conn, productsTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create product repository: %w", err)
+	}
+
+	columns, err := postgresql.Columns[productRow]()
+	if err != nil {
+		return nil, fmt.Errorf("failed to map product columns: %w", err)
+	}
+
+	return &postgreSQLProductRepository{
+		conn:    conn,
+		base:    base,
+		columns: strings.Join(columns, ", "),
+	}, nil
+}
+
+// Create inserts a new product into the products table
+func (r *postgreSQLProductRepository) Create(ctx context.Context, product *Product) error {
+	// Generate ID if not provided
+	if product.ID == "" {
+		product.ID = uuid.New().String()
+	}
+
+	// Set timestamps, truncated to the precision PostgreSQL stores
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	product.CreatedAt = now
+	product.UpdatedAt = now
+	product.Version = 1
+
+	if err := r.base.Create(ctx, newProductRow(product)); err != nil {
+		return fmt.Errorf("failed to insert product: %w", err)
+	}
+
+	return nil
+}
+
+// CreateMany inserts products with multi-row INSERT statements
+func (r *postgreSQLProductRepository) CreateMany(ctx context.Context, products []*Product) error {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	rows := make([]*productRow, len(products))
+	for i, product := range products {
+		if product.ID == "" {
+			product.ID = uuid.New().String()
+		}
+		product.CreatedAt = now
+		product.UpdatedAt = now
+		product.Version = 1
+		rows[i] = newProductRow(product)
+	}
+
+	if err := r.base.CreateMany(ctx, rows); err != nil {
+		return fmt.Errorf("failed to insert products: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a product by ID unless it is in the trash
+func (r *postgreSQLProductRepository) GetByID(ctx context.Context, id string) (*Product, error) {
+	statement := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1 AND deleted_at IS NULL", r.columns, productsTable)
+	return r.queryProduct(ctx, statement, id)
+}
+
+// GetByIDs retrieves the products with the given IDs, skipping unknown IDs
+func (r *postgreSQLProductRepository) GetByIDs(ctx context.Context, ids []string) ([]*Product, error) {
+	statement := fmt.Sprintf("SELECT %s FROM %s WHERE id = ANY($1) AND deleted_at IS NULL", r.columns, productsTable)
+	return r.queryProducts(ctx, statement, pq.Array(ids))
+}
+
+// GetAll retrieves products matching the query's filter, sorted and paginated
+func (r *postgreSQLProductRepository) GetAll(ctx context.Context, query ProductQuery) ([]*Product, error) {
+	where, args := buildProductWhere(query.Filter)
+	column, direction := sortClause(query)
+
+	args = append(args, query.Limit, query.Offset)
+	statement := fmt.Sprintf(
+		"SELECT %s FROM %s%s ORDER BY %s %s, id %s LIMIT $%d OFFSET $%d",
+		r.columns, productsTable, where, column, direction, direction, len(args)-1, len(args),
+	)
+
+	return r.queryProducts(ctx, statement, args...)
+}
+
+// GetAllAfter retrieves the page of products that follows the cursor position
+// using a keyset comparison on (sort column, id)
+func (r *postgreSQLProductRepository) GetAllAfter(ctx context.Context, query ProductQuery, cursor *ProductCursor) ([]*Product, error) {
+	pivot, err := cursor.Pivot()
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := buildProductWhere(query.Filter)
+	column, direction := sortClause(query)
+
+	comparison := ">"
+	if direction == "DESC" {
+		comparison = "<"
+	}
+
+	args = append(args, sortArgument(pivot, query.SortField), pivot.ID)
+	keyset := fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, comparison, len(args)-1, len(args))
+	where += " AND " + keyset
+
+	args = append(args, query.Limit)
+	statement := fmt.Sprintf(
+		"SELECT %s FROM %s%s ORDER BY %s %s, id %s LIMIT $%d",
+		r.columns, productsTable, where, column, direction, direction, len(args),
+	)
+
+	return r.queryProducts(ctx, statement, args...)
+}
+
+// Update updates an existing product if its version is still current
+func (r *postgreSQLProductRepository) Update(ctx context.Context, product *Product) error {
+	updatedAt := time.Now().UTC().Truncate(time.Microsecond)
+
+	statement := `UPDATE ` + productsTable + `
+		SET name = $1, description = $2, price = $3, currency = $4, category_id = $5, stock = $6,
+			updated_at = $7, version = version + 1
+		WHERE id = $8 AND version = $9 AND deleted_at IS NULL`
+
+	result, err := r.executor(ctx).ExecContext(ctx, statement,
+		product.Name,
+		product.Description,
+		product.Price.Decimal(),
+		product.Price.Currency,
+		product.CategoryID,
+		product.Stock,
+		updatedAt,
+		product.ID,
+		product.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update product: %w", err)
+	}
+
+	if err := r.checkConditionalWrite(ctx, result, product.ID); err != nil {
+		return err
+	}
+
+	product.UpdatedAt = updatedAt
+	product.Version++
+	return nil
+}
+
+// UpdateMany updates every product whose version is still current with a
+// single statement over unnested arrays
+func (r *postgreSQLProductRepository) UpdateMany(ctx context.Context, products []*Product) ([]error, error) {
+	updatedAt := time.Now().UTC().Truncate(time.Microsecond)
+
+	ids := make([]string, len(products))
+	names := make([]string, len(products))
+	descriptions := make([]string, len(products))
+	prices := make([]string, len(products))
+	currencies := make([]string, len(products))
+	categories := make([]string, len(products))
+	stocks := make([]int64, len(products))
+	versions := make([]int64, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+		names[i] = product.Name
+		descriptions[i] = product.Description
+		prices[i] = product.Price.Decimal()
+		currencies[i] = product.Price.Currency
+		categories[i] = product.CategoryID
+		stocks[i] = int64(product.Stock)
+		versions[i] = product.Version
+	}
+
+	statement := `UPDATE ` + productsTable + ` AS p
+		SET name = v.name, description = v.description, price = v.price, currency = v.currency,
+			category_id = v.category_id, stock = v.stock, updated_at = $1, version = p.version + 1
+		FROM UNNEST($2::VARCHAR[], $3::VARCHAR[], $4::TEXT[], $5::NUMERIC[], $6::VARCHAR[], $7::VARCHAR[], $8::INTEGER[], $9::BIGINT[])
+			AS v(id, name, description, price, currency, category_id, stock, version)
+		WHERE p.id = v.id AND p.version = v.version AND p.deleted_at IS NULL
+		RETURNING p.id`
+
+	written, err := r.queryIDs(ctx, statement, updatedAt,
+		pq.Array(ids), pq.Array(names), pq.Array(descriptions), pq.Array(prices), pq.Array(currencies),
+		pq.Array(categories), pq.Array(stocks), pq.Array(versions),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update products: %w", err)
+	}
+
+	errs, err := r.conditionalWriteErrors(ctx, ids, written)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, product := range products {
+		if errs[i] == nil {
+			product.UpdatedAt = updatedAt
+			product.Version++
+		}
+	}
+
+	return errs, nil
+}
+
+// Delete moves a product to the trash, checking its version when one is expected
+func (r *postgreSQLProductRepository) Delete(ctx context.Context, id string, expectedVersion int64) error {
+	statement := `UPDATE ` + productsTable + `
+		SET deleted_at = $3, updated_at = $3, version = version + 1
+		WHERE id = $1 AND ($2::BIGINT = 0 OR version = $2::BIGINT) AND deleted_at IS NULL`
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	result, err := r.executor(ctx).ExecContext(ctx, statement, id, expectedVersion, now)
+	if err != nil {
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+
+	return r.checkConditionalWrite(ctx, result, id)
+}
+
+// DeleteMany trashes every referenced product whose version still matches
+// with a single statement over unnested arrays
+func (r *postgreSQLProductRepository) DeleteMany(ctx context.Context, refs []ProductVersion) ([]error, error) {
+	ids := make([]string, len(refs))
+	versions := make([]int64, len(refs))
+	for i, ref := range refs {
+		ids[i] = ref.ID
+		versions[i] = ref.Version
+	}
+
+	statement := `UPDATE ` + productsTable + ` AS p
+		SET deleted_at = $1, updated_at = $1, version = p.version + 1
+		FROM UNNEST($2::VARCHAR[], $3::BIGINT[]) AS v(id, version)
+		WHERE p.id = v.id AND (v.version = 0 OR p.version = v.version) AND p.deleted_at IS NULL
+		RETURNING p.id`
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	written, err := r.queryIDs(ctx, statement, now, pq.Array(ids), pq.Array(versions))
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete products: %w", err)
+	}
+
+	return r.conditionalWriteErrors(ctx, ids, written)
+}
+
+// conditionalWriteErrors reports, for each ID a batch write targeted, nil if
+// it was written and otherwise whether it is missing or has a stale version
+func (r *postgreSQLProductRepository) conditionalWriteErrors(ctx context.Context, ids []string, written map[string]bool) ([]error, error) {
+	errs := make([]error, len(ids))
+
+	var missed []string
+	for _, id := range ids {
+		if !written[id] {
+			missed = append(missed, id)
+		}
+	}
+	if len(missed) == 0 {
+		return errs, nil
+	}
+
+	existing, err := r.queryIDs(ctx, "SELECT id FROM "+productsTable+" WHERE id = ANY($1) AND deleted_at IS NULL", pq.Array(missed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check product existence: %w", err)
+	}
+
+	for i, id := range ids {
+		switch {
+		case written[id]:
+		case existing[id]:
+			errs[i] = ErrVersionConflict
+		default:
+			errs[i] = fmt.Errorf("%w: %s", ErrProductNotFound, id)
+		}
+	}
+
+	return errs, nil
+}
+
+// checkConditionalWrite tells a missing product apart from a stale version
+// when a conditional write affected no rows
+func (r *postgreSQLProductRepository) checkConditionalWrite(ctx context.Context, result sql.Result, id string) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	existing, err := r.queryIDs(ctx, "SELECT id FROM "+productsTable+" WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("failed to check product existence: %w", err)
+	}
+	if !existing[id] {
+		return fmt.Errorf("%w: %s", ErrProductNotFound, id)
+	}
+
+	return ErrVersionConflict
+}
+
+// Restore moves a product out of the trash
+func (r *postgreSQLProductRepository) Restore(ctx context.Context, id string) (*Product, error) {
+	statement := fmt.Sprintf(
+		"UPDATE %s SET deleted_at = NULL, updated_at = $2, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL RETURNING %s",
+		productsTable, r.columns,
+	)
+
+	product, err := r.queryProduct(ctx, statement, id, time.Now().UTC().Truncate(time.Microsecond))
+	if errors.Is(err, ErrProductNotFound) {
+		return nil, fmt.Errorf("%w in trash: %s", ErrProductNotFound, id)
+	}
+	return product, err
+}
+
+// AdjustStock adds delta to a product's stock with a single conditional
+// UPDATE that only matches while the result stays non-negative
+func (r *postgreSQLProductRepository) AdjustStock(ctx context.Context, id string, delta int) (*Product, error) {
+	statement := fmt.Sprintf(
+		"UPDATE %s SET stock = stock + $2::INTEGER, updated_at = $3, version = version + 1 WHERE id = $1 AND stock >= -$2::INTEGER AND deleted_at IS NULL RETURNING %s",
+		productsTable, r.columns,
+	)
+
+	product, err := r.queryProduct(ctx, statement, id, delta, time.Now().UTC().Truncate(time.Microsecond))
+	if !errors.Is(err, ErrProductNotFound) {
+		return product, err
+	}
+
+	existing, err := r.queryIDs(ctx, "SELECT id FROM "+productsTable+" WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check product existence: %w", err)
+	}
+	if existing[id] {
+		return nil, fmt.Errorf("%w: %s", ErrInsufficientStock, id)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrProductNotFound, id)
+}
+
+// Purge permanently removes a product from the trash
+func (r *postgreSQLProductRepository) Purge(ctx context.Context, id string) error {
+	statement := `DELETE FROM ` + productsTable + ` WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	result, err := r.executor(ctx).ExecContext(ctx, statement, id)
+	if err != nil {
+		return fmt.Errorf("failed to purge product: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w in trash: %s", ErrProductNotFound, id)
+	}
+
+	return nil
+}
+
+// PurgeDeletedBefore permanently removes products trashed before cutoff and
+// returns their IDs in order
+func (r *postgreSQLProductRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	statement := `DELETE FROM ` + productsTable + ` WHERE deleted_at < $1 RETURNING id`
+
+	ids, err := r.queryIDs(ctx, statement, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge trash: %w", err)
+	}
+
+	purged := make([]string, 0, len(ids))
+	for id := range ids {
+		purged = append(purged, id)
+	}
+
+	sort.Strings(purged)
+	return purged, nil
+}
+
+// Count returns the number of products matching the filter
+func (r *postgreSQLProductRepository) Count(ctx context.Context, filter ProductFilter) (int64, error) {
+	where, args := buildProductWhere(filter)
+
+	var count int64
+	statement := "SELECT COUNT(*) FROM " + productsTable + where
+	if err := r.executor(ctx).QueryRowContext(ctx, statement, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count products: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountByCategory returns the number of live and trashed products in a category
+func (r *postgreSQLProductRepository) CountByCategory(ctx context.Context, categoryID string) (int64, error) {
+	var count int64
+	statement := "SELECT COUNT(*) FROM " + productsTable + " WHERE category_id = $1"
+	if err := r.executor(ctx).QueryRowContext(ctx, statement, categoryID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count category products: %w", err)
+	}
+
+	return count, nil
+}
+
+// productSearchRow is a product row with the rank and highlights of a search
+type productSearchRow struct {
+	productRow
+	Score                float64 `db:"score"`
+	NameHighlight        string  `db:"name_highlight"`
+	DescriptionHighlight string  `db:"description_highlight"`
+}
+
+// searchHeadlineOptions configures ts_headline to mark matches the way
+// search.Highlight does
+var searchHeadlineOptions = fmt.Sprintf("StartSel=%s, StopSel=%s", search.HighlightStart, search.HighlightStop)
+
+// Search ranks live products by ts_rank over the GIN-indexed search_vector
+// column, highlighting matches with ts_headline
+func (r *postgreSQLProductRepository) Search(ctx context.Context, query SearchQuery) ([]*SearchHit, int64, error) {
+	where := " WHERE search_vector @@ plainto_tsquery('english', $1) AND deleted_at IS NULL"
+	args := []interface{}{query.Text}
+	if query.CategoryID != "" {
+		args = append(args, query.CategoryID)
+		where += fmt.Sprintf(" AND category_id = $%d", len(args))
+	}
+
+	var total int64
+	statement := "SELECT COUNT(*) FROM " + productsTable + where
+	if err := r.executor(ctx).QueryRowContext(ctx, statement, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	args = append(args, query.Limit, query.Offset)
+	statement = fmt.Sprintf(
+		`SELECT %s,
+			ts_rank(search_vector, plainto_tsquery('english', $1)) AS score,
+			ts_headline('english', name, plainto_tsquery('english', $1), '%s, HighlightAll=true') AS name_highlight,
+			ts_headline('english', description, plainto_tsquery('english', $1), '%s, MaxWords=%d, MinWords=%d') AS description_highlight
+		FROM %s%s ORDER BY score DESC, id LIMIT $%d OFFSET $%d`,
+		r.columns, searchHeadlineOptions, searchHeadlineOptions, snippetWords, snippetWords/2,
+		productsTable, where, len(args)-1, len(args),
+	)
+
+	rows, err := r.executor(ctx).QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search products: %w", err)
+	}
+	defer rows.Close()
+
+	hits := make([]*SearchHit, 0)
+	for rows.Next() {
+		var row productSearchRow
+		if err := postgresql.ScanStruct(rows, &row); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		product, err := row.product()
+		if err != nil {
+			return nil, 0, err
+		}
+		hits = append(hits, &SearchHit{
+			Product: product,
+			Score:   row.Score,
+			Highlights: SearchHighlights{
+				Name:        row.NameHighlight,
+				Description: row.DescriptionHighlight,
+			},
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate search results: %w", err)
+	}
+
+	return hits, total, nil
+}
+
+// Suggest returns distinct live product names completing prefix, matching
+// the start of the name or of any later word
+func (r *postgreSQLProductRepository) Suggest(ctx context.Context, prefix string, limit int) ([]string, error) {
+	statement := `SELECT name FROM ` + productsTable + `
+		WHERE (LOWER(name) LIKE $1 OR LOWER(name) LIKE $2) AND deleted_at IS NULL
+		GROUP BY name
+		ORDER BY LOWER(name) NOT LIKE $1, name
+		LIMIT $3`
+
+	escaped := escapeLike(strings.ToLower(prefix))
+	rows, err := r.executor(ctx).QueryContext(ctx, statement, escaped+"%", "% "+escaped+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest products: %w", err)
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan suggestion: %w", err)
+		}
+		names = append(names, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate suggestions: %w", err)
+	}
+
+	return names, nil
+}
+
+// queryProducts runs a SELECT of product columns and scans every row
+func (r *postgreSQLProductRepository) queryProducts(ctx context.Context, statement string, args ...interface{}) ([]*Product, error) {
+	rows, err := r.executor(ctx).QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+	defer rows.Close()
+
+	products := make([]*Product, 0)
+	for rows.Next() {
+		var row productRow
+		if err := postgresql.ScanStruct(rows, &row); err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		product, err := row.product()
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate products: %w", err)
+	}
+
+	return products, nil
+}
+
+// queryProduct runs a statement returning product columns for at most one row
+func (r *postgreSQLProductRepository) queryProduct(ctx context.Context, statement string, args ...interface{}) (*Product, error) {
+	var row productRow
+	err := postgresql.ScanStruct(r.executor(ctx).QueryRowContext(ctx, statement, args...), &row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrProductNotFound, args[0])
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	return row.product()
+}
+
+// queryIDs runs a statement returning a single id column and collects the IDs
+func (r *postgreSQLProductRepository) queryIDs(ctx context.Context, statement string, args ...interface{}) (map[string]bool, error) {
+	rows, err := r.executor(ctx).QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+
+	return ids, rows.Err()
+}
+
+// executor returns the transaction carried by ctx or the connection pool
+func (r *postgreSQLProductRepository) executor(ctx context.Context) postgresql.Executor {
+	return postgresql.ExecutorFromContext(ctx, r.conn.GetDB())
+}
+
+// byteOrderColumns are the text columns that sort with COLLATE "C", so that
+// their order does not depend on the database locale and matches the
+// in-memory repository. IDs are UUIDs, which every collation orders alike.
+var byteOrderColumns = map[string]bool{
+	"name":        true,
+	"description": true,
+}
+
+// sortClause resolves the query's sort field and order to SQL
+func sortClause(query ProductQuery) (string, string) {
+	column, ok := SortableFields[query.SortField]
+	if !ok {
+		column = SortableFields[DefaultSortField]
+	}
+	if byteOrderColumns[column] {
+		column += ` COLLATE "C"`
+	}
+
+	direction := "ASC"
+	if query.SortOrder == constants.SortOrderDesc {
+		direction = "DESC"
+	}
+
+	return column, direction
+}
+
+// buildProductWhere translates a filter into a WHERE clause and its arguments
+func buildProductWhere(filter ProductFilter) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	if filter.Trashed {
+		conditions[0] = "deleted_at IS NOT NULL"
+	}
+	var args []interface{}
+
+	addCondition := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.CategoryID != "" {
+		addCondition("category_id = $%d", filter.CategoryID)
+	}
+	if filter.MinPrice != nil {
+		addCondition("price >= $%d", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		addCondition("price <= $%d", *filter.MaxPrice)
+	}
+	if filter.InStockOnly {
+		conditions = append(conditions, "stock > 0")
+	}
+	if filter.Search != "" {
+		addCondition("(name ILIKE $%[1]d OR description ILIKE $%[1]d)", "%"+escapeLike(filter.Search)+"%")
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// likeEscaper escapes LIKE wildcards so search terms match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes LIKE wildcards in a user supplied search term
+func escapeLike(term string) string {
+	return likeEscaper.Replace(term)
+}
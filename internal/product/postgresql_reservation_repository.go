@@ -0,0 +1,145 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gin-service/pkg/database/postgresql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// reservationsTable is the table stock reservations are persisted in
+const reservationsTable = "stock_reservations"
+
+// postgreSQLReservationRepository implements ReservationRepository backed by PostgreSQL
+type postgreSQLReservationRepository struct {
+	conn    postgresql.Connection
+	base    postgresql.Repository[Reservation]
+	columns string
+}
+
+// NewPostgreSQLReservationRepository creates a new PostgreSQL-backed reservation repository
+func NewPostgreSQLReservationRepository(conn postgresql.Connection) (ReservationRepository, error) {
+	base, err := postgresql.NewPostgreSQLRepository[Reservation](conn, reservationsTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reservation repository: %w", err)
+	}
+
+	columns, err := postgresql.Columns[Reservation]()
+	if err != nil {
+		return nil, fmt.Errorf("failed to map reservation columns: %w", err)
+	}
+
+	return &postgreSQLReservationRepository{
+		conn:    conn,
+		base:    base,
+		columns: strings.Join(columns, ", "),
+	}, nil
+}
+
+// Create inserts a new reservation
+func (r *postgreSQLReservationRepository) Create(ctx context.Context, reservation *Reservation) error {
+	if reservation.ID == "" {
+		reservation.ID = uuid.New().String()
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	reservation.CreatedAt = now
+	reservation.UpdatedAt = now
+	reservation.ExpiresAt = reservation.ExpiresAt.UTC().Truncate(time.Microsecond)
+
+	if err := r.base.Create(ctx, reservation); err != nil {
+		return fmt.Errorf("failed to insert reservation: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a reservation by ID
+func (r *postgreSQLReservationRepository) GetByID(ctx context.Context, id string) (*Reservation, error) {
+	reservation, err := r.base.GetByID(ctx, id)
+	if errors.Is(err, postgresql.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrReservationNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	return reservation, nil
+}
+
+// Resolve moves an active reservation to status with a conditional UPDATE
+func (r *postgreSQLReservationRepository) Resolve(ctx context.Context, id, status string) (*Reservation, error) {
+	statement := fmt.Sprintf(
+		"UPDATE %s SET status = $2, updated_at = $3 WHERE id = $1 AND status = $4 RETURNING %s",
+		reservationsTable, r.columns,
+	)
+
+	var reservation Reservation
+	row := r.executor(ctx).QueryRowContext(ctx, statement, id, status, time.Now().UTC().Truncate(time.Microsecond), ReservationActive)
+	err := postgresql.ScanStruct(row, &reservation)
+	if err == nil {
+		return &reservation, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to resolve reservation: %w", err)
+	}
+
+	existing, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: %s is %s", ErrReservationNotActive, id, existing.Status)
+}
+
+// ListExpired returns up to limit active reservations that expired at or
+// before now, oldest first
+func (r *postgreSQLReservationRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*Reservation, error) {
+	statement := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE status = $1 AND expires_at <= $2 ORDER BY expires_at LIMIT $3",
+		r.columns, reservationsTable,
+	)
+
+	rows, err := r.executor(ctx).QueryContext(ctx, statement, ReservationActive, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired reservations: %w", err)
+	}
+	defer rows.Close()
+
+	reservations := make([]*Reservation, 0)
+	for rows.Next() {
+		var reservation Reservation
+		if err := postgresql.ScanStruct(rows, &reservation); err != nil {
+			return nil, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		reservations = append(reservations, &reservation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate reservations: %w", err)
+	}
+
+	return reservations, nil
+}
+
+// DeleteByProducts removes every reservation of the given products
+func (r *postgreSQLReservationRepository) DeleteByProducts(ctx context.Context, productIDs []string) error {
+	statement := `DELETE FROM ` + reservationsTable + ` WHERE product_id = ANY($1)`
+
+	if _, err := r.executor(ctx).ExecContext(ctx, statement, pq.Array(productIDs)); err != nil {
+		return fmt.Errorf("failed to delete reservations: %w", err)
+	}
+
+	return nil
+}
+
+// executor returns the transaction carried by ctx or the connection pool
+func (r *postgreSQLReservationRepository) executor(ctx context.Context) postgresql.Executor {
+	return postgresql.ExecutorFromContext(ctx, r.conn.GetDB())
+}
@@ -0,0 +1,294 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gin-service/pkg/database/postgresql"
+	"gin-service/pkg/money"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// variantsTable is the table product variants are persisted in
+const variantsTable = "product_variants"
+
+// uniqueViolation is the PostgreSQL error code of a unique constraint violation
+const uniqueViolation = "23505"
+
+// variantRow is the stored form of a variant. A price override is kept as a
+// nullable NUMERIC amount next to its currency code.
+type variantRow struct {
+	ID         string            `db:"id"`
+	ProductID  string            `db:"product_id"`
+	SKU        string            `db:"sku"`
+	Attributes VariantAttributes `db:"attributes"`
+	Price      sql.NullString    `db:"price"`
+	Currency   sql.NullString    `db:"currency"`
+	Stock      int               `db:"stock"`
+	Version    int64             `db:"version"`
+	CreatedAt  time.Time         `db:"created_at"`
+	UpdatedAt  time.Time         `db:"updated_at"`
+}
+
+// newVariantRow converts a variant to its stored form
+func newVariantRow(variant *Variant) *variantRow {
+	row := &variantRow{
+		ID:         variant.ID,
+		ProductID:  variant.ProductID,
+		SKU:        variant.SKU,
+		Attributes: variant.Attributes,
+		Stock:      variant.Stock,
+		Version:    variant.Version,
+		CreatedAt:  variant.CreatedAt,
+		UpdatedAt:  variant.UpdatedAt,
+	}
+	if variant.Price != nil {
+		row.Price = sql.NullString{String: variant.Price.Decimal(), Valid: true}
+		row.Currency = sql.NullString{String: variant.Price.Currency, Valid: true}
+	}
+	return row
+}
+
+// variant converts a stored row back to a variant
+func (row *variantRow) variant() (*Variant, error) {
+	variant := &Variant{
+		ID:         row.ID,
+		ProductID:  row.ProductID,
+		SKU:        row.SKU,
+		Attributes: row.Attributes,
+		Stock:      row.Stock,
+		Version:    row.Version,
+		CreatedAt:  row.CreatedAt,
+		UpdatedAt:  row.UpdatedAt,
+	}
+	if row.Price.Valid {
+		price, err := money.Parse(row.Price.String, row.Currency.String)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price of variant %s: %w", row.ID, err)
+		}
+		variant.Price = &price
+	}
+	return variant, nil
+}
+
+// postgreSQLVariantRepository implements VariantRepository backed by PostgreSQL
+type postgreSQLVariantRepository struct {
+	conn    postgresql.Connection
+	base    postgresql.Repository[variantRow]
+	columns string
+}
+
+// NewPostgreSQLVariantRepository creates a new PostgreSQL-backed variant repository
+func NewPostgreSQLVariantRepository(conn postgresql.Connection) (VariantRepository, error) {
+	base, err := postgresql.NewPostgreSQLRepository[variantRow](conn, variantsTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create variant repository: %w", err)
+	}
+
+	columns, err := postgresql.Columns[variantRow]()
+	if err != nil {
+		return nil, fmt.Errorf("failed to map variant columns: %w", err)
+	}
+
+	return &postgreSQLVariantRepository{
+		conn:    conn,
+		base:    base,
+		columns: strings.Join(columns, ", "),
+	}, nil
+}
+
+// Create inserts a new variant; the unique index on sku rejects duplicates
+func (r *postgreSQLVariantRepository) Create(ctx context.Context, variant *Variant) error {
+	if variant.ID == "" {
+		variant.ID = uuid.New().String()
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	variant.CreatedAt = now
+	variant.UpdatedAt = now
+	variant.Version = 1
+
+	if err := r.base.Create(ctx, newVariantRow(variant)); err != nil {
+		return variantError("failed to insert variant", variant, err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a variant by ID
+func (r *postgreSQLVariantRepository) GetByID(ctx context.Context, id string) (*Variant, error) {
+	row, err := r.base.GetByID(ctx, id)
+	if errors.Is(err, postgresql.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrVariantNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variant: %w", err)
+	}
+
+	return row.variant()
+}
+
+// GetByProduct lists a product's variants ordered by SKU
+func (r *postgreSQLVariantRepository) GetByProduct(ctx context.Context, productID string) ([]*Variant, error) {
+	rows, err := r.base.FindBy(ctx, map[string]interface{}{"product_id": productID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list variants: %w", err)
+	}
+
+	variants := make([]*Variant, len(rows))
+	for i, row := range rows {
+		if variants[i], err = row.variant(); err != nil {
+			return nil, err
+		}
+	}
+
+	sortVariants(variants)
+	return variants, nil
+}
+
+// GetByProducts lists the variants of several products ordered by SKU
+func (r *postgreSQLVariantRepository) GetByProducts(ctx context.Context, productIDs []string) ([]*Variant, error) {
+	statement := fmt.Sprintf("SELECT %s FROM %s WHERE product_id = ANY($1) ORDER BY sku", r.columns, variantsTable)
+
+	rows, err := r.executor(ctx).QueryContext(ctx, statement, pq.Array(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list variants: %w", err)
+	}
+	defer rows.Close()
+
+	variants := make([]*Variant, 0)
+	for rows.Next() {
+		var row variantRow
+		if err := postgresql.ScanStruct(rows, &row); err != nil {
+			return nil, fmt.Errorf("failed to scan variant: %w", err)
+		}
+		variant, err := row.variant()
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, variant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate variants: %w", err)
+	}
+
+	return variants, nil
+}
+
+// Update replaces a stored variant if its version is still current
+func (r *postgreSQLVariantRepository) Update(ctx context.Context, variant *Variant) error {
+	updatedAt := time.Now().UTC().Truncate(time.Microsecond)
+	row := newVariantRow(variant)
+
+	statement := `UPDATE ` + variantsTable + `
+		SET sku = $1, attributes = $2, price = $3, currency = $4, stock = $5,
+			updated_at = $6, version = version + 1
+		WHERE id = $7 AND version = $8`
+
+	result, err := r.executor(ctx).ExecContext(ctx, statement,
+		row.SKU,
+		row.Attributes,
+		row.Price,
+		row.Currency,
+		row.Stock,
+		updatedAt,
+		row.ID,
+		row.Version,
+	)
+	if err != nil {
+		return variantError("failed to update variant", variant, err)
+	}
+
+	if err := r.checkConditionalWrite(ctx, result, variant.ID); err != nil {
+		return err
+	}
+
+	variant.UpdatedAt = updatedAt
+	variant.Version++
+	return nil
+}
+
+// Delete removes a variant, checking its version when one is expected
+func (r *postgreSQLVariantRepository) Delete(ctx context.Context, id string, expectedVersion int64) error {
+	statement := `DELETE FROM ` + variantsTable + ` WHERE id = $1 AND ($2::BIGINT = 0 OR version = $2::BIGINT)`
+
+	result, err := r.executor(ctx).ExecContext(ctx, statement, id, expectedVersion)
+	if err != nil {
+		return fmt.Errorf("failed to delete variant: %w", err)
+	}
+
+	return r.checkConditionalWrite(ctx, result, id)
+}
+
+// AdjustStock adds delta to a variant's stock with a conditional UPDATE that
+// refuses to go below zero
+func (r *postgreSQLVariantRepository) AdjustStock(ctx context.Context, id string, delta int) (*Variant, error) {
+	statement := fmt.Sprintf(
+		"UPDATE %s SET stock = stock + $2::INTEGER, updated_at = $3, version = version + 1 WHERE id = $1 AND stock >= -$2::INTEGER RETURNING %s",
+		variantsTable, r.columns,
+	)
+
+	var row variantRow
+	err := postgresql.ScanStruct(r.executor(ctx).QueryRowContext(ctx, statement, id, delta, time.Now().UTC().Truncate(time.Microsecond)), &row)
+	if err == nil {
+		return row.variant()
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to adjust variant stock: %w", err)
+	}
+
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInsufficientStock, id)
+}
+
+// DeleteByProducts removes every variant of the given products
+func (r *postgreSQLVariantRepository) DeleteByProducts(ctx context.Context, productIDs []string) error {
+	statement := `DELETE FROM ` + variantsTable + ` WHERE product_id = ANY($1)`
+
+	if _, err := r.executor(ctx).ExecContext(ctx, statement, pq.Array(productIDs)); err != nil {
+		return fmt.Errorf("failed to delete variants: %w", err)
+	}
+
+	return nil
+}
+
+// checkConditionalWrite tells a missing variant from a stale version when a
+// conditional write affected no rows
+func (r *postgreSQLVariantRepository) checkConditionalWrite(ctx context.Context, result sql.Result, id string) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return ErrVariantVersionConflict
+}
+
+// executor returns the transaction carried by ctx or the connection pool
+func (r *postgreSQLVariantRepository) executor(ctx context.Context) postgresql.Executor {
+	return postgresql.ExecutorFromContext(ctx, r.conn.GetDB())
+}
+
+// variantError maps a unique violation on the SKU to ErrSKUTaken
+func variantError(message string, variant *Variant, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: %s", ErrSKUTaken, variant.SKU)
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
@@ -0,0 +1,64 @@
+package product
+
+import (
+	"context"
+	"time"
+
+	"gin-service/pkg/logger"
+)
+
+// TrashPurger periodically and permanently removes products that have been
+// in the trash for longer than the retention period
+type TrashPurger struct {
+	service   ProductService
+	retention time.Duration
+	interval  time.Duration
+	logger    logger.Logger
+}
+
+// NewTrashPurger creates a purger that runs every interval
+func NewTrashPurger(service ProductService, retention, interval time.Duration, log logger.Logger) *TrashPurger {
+	return &TrashPurger{
+		service:   service,
+		retention: retention,
+		interval:  interval,
+		logger:    log,
+	}
+}
+
+// Run purges expired products until ctx is cancelled, starting with an
+// immediate pass
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.PurgeOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce removes every product trashed before the retention cutoff
+func (p *TrashPurger) PurgeOnce(ctx context.Context) {
+	cutoff := time.Now().Add(-p.retention)
+
+	purged, err := p.service.PurgeTrash(ctx, cutoff)
+	if err != nil {
+		p.logger.Error(ctx, "Failed to purge product trash", err, logger.Fields{
+			"cutoff": cutoff,
+		})
+		return
+	}
+
+	if purged > 0 {
+		p.logger.Info(ctx, "Purged expired products from trash", logger.Fields{
+			"purged": purged,
+			"cutoff": cutoff,
+		})
+	}
+}
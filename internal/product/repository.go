@@ -1,17 +1,26 @@
 package product
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"gin-service/pkg/constants"
+	"gin-service/pkg/search"
+
 	"github.com/google/uuid"
 )
 
-// productRepository implements ProductRepository interface
+// productRepository implements ProductRepository interface. Products are
+// kept in a full-text index as well, trashed ones included so that restoring
+// does not need to reindex them.
 type productRepository struct {
 	products map[string]*Product
+	index    *search.Index
 	mutex    sync.RWMutex
 }
 
@@ -19,6 +28,7 @@ type productRepository struct {
 func NewProductRepository() ProductRepository {
 	return &productRepository{
 		products: make(map[string]*Product),
+		index:    search.NewIndex(),
 	}
 }
 
@@ -27,19 +37,44 @@ func (r *productRepository) Create(ctx context.Context, product *Product) error
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	r.insert(product, time.Now())
+	return nil
+}
+
+// CreateMany adds all products to the repository under a single lock
+func (r *productRepository) CreateMany(ctx context.Context, products []*Product) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	for _, product := range products {
+		if _, exists := r.products[product.ID]; exists && product.ID != "" {
+			return fmt.Errorf("product already exists: %s", product.ID)
+		}
+	}
+	for _, product := range products {
+		r.insert(product, now)
+	}
+
+	return nil
+}
+
+// insert assigns identity, timestamps and the initial version to product
+// and stores a copy. The caller must hold the write lock.
+func (r *productRepository) insert(product *Product, now time.Time) {
 	// Generate ID if not provided
 	if product.ID == "" {
 		product.ID = uuid.New().String()
 	}
 
-	// Set timestamps
-	now := time.Now()
+	// Set timestamps and initial version
 	product.CreatedAt = now
 	product.UpdatedAt = now
+	product.Version = 1
 
-	// Store the product
-	r.products[product.ID] = product
-	return nil
+	// Store a copy so callers cannot mutate repository state
+	r.products[product.ID] = cloneProduct(product)
+	r.index.Add(product.ID, searchFields(product)...)
 }
 
 // GetByID retrieves a product by ID
@@ -48,67 +83,477 @@ func (r *productRepository) GetByID(ctx context.Context, id string) (*Product, e
 	defer r.mutex.RUnlock()
 
 	product, exists := r.products[id]
-	if !exists {
-		return nil, fmt.Errorf("product not found: %s", id)
+	if !exists || product.DeletedAt != nil {
+		return nil, fmt.Errorf("%w: %s", ErrProductNotFound, id)
 	}
 
-	return product, nil
+	return cloneProduct(product), nil
 }
 
-// GetAll retrieves all products with pagination
-func (r *productRepository) GetAll(ctx context.Context, limit, offset int) ([]*Product, error) {
+// GetByIDs retrieves the products with the given IDs, skipping unknown IDs
+func (r *productRepository) GetByIDs(ctx context.Context, ids []string) ([]*Product, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	products := make([]*Product, 0, len(r.products))
-	for _, product := range r.products {
-		products = append(products, product)
+	products := make([]*Product, 0, len(ids))
+	for _, id := range ids {
+		if product, exists := r.products[id]; exists && product.DeletedAt == nil {
+			products = append(products, cloneProduct(product))
+		}
 	}
 
+	return products, nil
+}
+
+// GetAll retrieves products matching the query's filter, sorted and paginated
+func (r *productRepository) GetAll(ctx context.Context, query ProductQuery) ([]*Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	products := r.sortedMatches(query, nil)
+
 	// Apply pagination
-	if offset >= len(products) {
+	if query.Offset >= len(products) {
 		return []*Product{}, nil
 	}
 
-	end := offset + limit
+	end := query.Offset + query.Limit
 	if end > len(products) {
 		end = len(products)
 	}
 
-	return products[offset:end], nil
+	return cloneProducts(products[query.Offset:end]), nil
+}
+
+// GetAllAfter retrieves the page of products that follows the cursor position
+func (r *productRepository) GetAllAfter(ctx context.Context, query ProductQuery, cursor *ProductCursor) ([]*Product, error) {
+	pivot, err := cursor.Pivot()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	products := r.sortedMatches(query, pivot)
+	if len(products) > query.Limit {
+		products = products[:query.Limit]
+	}
+
+	return cloneProducts(products), nil
 }
 
-// Update updates an existing product
+// sortedMatches returns the filtered products in query order, keeping only
+// those positioned after pivot when one is given
+func (r *productRepository) sortedMatches(query ProductQuery, pivot *Product) []*Product {
+	desc := query.SortOrder == constants.SortOrderDesc
+
+	products := make([]*Product, 0, len(r.products))
+	for _, product := range r.products {
+		if !matchesFilter(product, query.Filter) {
+			continue
+		}
+		if pivot != nil {
+			order := compareWithTiebreak(product, pivot, query.SortField)
+			if (desc && order >= 0) || (!desc && order <= 0) {
+				continue
+			}
+		}
+		products = append(products, product)
+	}
+
+	sortProducts(products, query.SortField, query.SortOrder)
+	return products
+}
+
+// Update updates an existing product if its version is still current
 func (r *productRepository) Update(ctx context.Context, product *Product) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.products[product.ID]; !exists {
-		return fmt.Errorf("product not found: %s", product.ID)
+	return r.update(product, time.Now())
+}
+
+// UpdateMany updates every product whose version is still current
+func (r *productRepository) UpdateMany(ctx context.Context, products []*Product) ([]error, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	errs := make([]error, len(products))
+	for i, product := range products {
+		errs[i] = r.update(product, now)
 	}
 
-	product.UpdatedAt = time.Now()
-	r.products[product.ID] = product
+	return errs, nil
+}
+
+// update stores product if its version matches the stored one. The caller
+// must hold the write lock.
+func (r *productRepository) update(product *Product, now time.Time) error {
+	if err := r.checkVersion(product.ID, product.Version, false); err != nil {
+		return err
+	}
+
+	product.UpdatedAt = now
+	product.Version++
+	r.products[product.ID] = cloneProduct(product)
+	r.index.Add(product.ID, searchFields(product)...)
 	return nil
 }
 
-// Delete removes a product by ID
-func (r *productRepository) Delete(ctx context.Context, id string) error {
+// Delete moves a product to the trash, checking its version when one is expected
+func (r *productRepository) Delete(ctx context.Context, id string, expectedVersion int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.delete(ProductVersion{ID: id, Version: expectedVersion}, time.Now())
+}
+
+// DeleteMany trashes every referenced product whose version still matches
+func (r *productRepository) DeleteMany(ctx context.Context, refs []ProductVersion) ([]error, error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.products[id]; !exists {
-		return fmt.Errorf("product not found: %s", id)
+	now := time.Now()
+	errs := make([]error, len(refs))
+	for i, ref := range refs {
+		errs[i] = r.delete(ref, now)
+	}
+
+	return errs, nil
+}
+
+// delete moves the referenced product to the trash. The caller must hold
+// the write lock.
+func (r *productRepository) delete(ref ProductVersion, now time.Time) error {
+	if err := r.checkVersion(ref.ID, ref.Version, true); err != nil {
+		return err
+	}
+
+	trashed := cloneProduct(r.products[ref.ID])
+	trashed.DeletedAt = &now
+	trashed.UpdatedAt = now
+	trashed.Version++
+	r.products[ref.ID] = trashed
+	return nil
+}
+
+// WriteBatch checks all creates, updates and deletes under the write lock
+// and applies them only when every one of them would succeed
+func (r *productRepository) WriteBatch(ctx context.Context, creates, updates []*Product, deletes []ProductVersion) ([]error, []error, []error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	failed := false
+	createErrs := make([]error, len(creates))
+	for i, product := range creates {
+		if _, exists := r.products[product.ID]; exists && product.ID != "" {
+			createErrs[i] = fmt.Errorf("product already exists: %s", product.ID)
+			failed = true
+		}
+	}
+	updateErrs := make([]error, len(updates))
+	for i, product := range updates {
+		if updateErrs[i] = r.checkVersion(product.ID, product.Version, false); updateErrs[i] != nil {
+			failed = true
+		}
+	}
+	deleteErrs := make([]error, len(deletes))
+	for i, ref := range deletes {
+		if deleteErrs[i] = r.checkVersion(ref.ID, ref.Version, true); deleteErrs[i] != nil {
+			failed = true
+		}
+	}
+	if failed {
+		return createErrs, updateErrs, deleteErrs
+	}
+
+	now := time.Now()
+	for _, product := range creates {
+		r.insert(product, now)
+	}
+	for _, product := range updates {
+		r.update(product, now)
+	}
+	for _, ref := range deletes {
+		r.delete(ref, now)
+	}
+
+	return createErrs, updateErrs, deleteErrs
+}
+
+// checkVersion fails unless id names a live product at version, which may
+// be zero to accept any version when anyVersion is set. The caller must
+// hold the lock.
+func (r *productRepository) checkVersion(id string, version int64, anyVersion bool) error {
+	stored, exists := r.products[id]
+	if !exists || stored.DeletedAt != nil {
+		return fmt.Errorf("%w: %s", ErrProductNotFound, id)
+	}
+
+	if (version != 0 || !anyVersion) && stored.Version != version {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// Restore moves a product out of the trash
+func (r *productRepository) Restore(ctx context.Context, id string) (*Product, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, exists := r.products[id]
+	if !exists || stored.DeletedAt == nil {
+		return nil, fmt.Errorf("%w in trash: %s", ErrProductNotFound, id)
+	}
+
+	restored := cloneProduct(stored)
+	restored.DeletedAt = nil
+	restored.UpdatedAt = time.Now()
+	restored.Version++
+	r.products[id] = restored
+
+	return cloneProduct(restored), nil
+}
+
+// AdjustStock adds delta to a product's stock as a compare-and-set under the
+// write lock, refusing to go below zero
+func (r *productRepository) AdjustStock(ctx context.Context, id string, delta int) (*Product, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, exists := r.products[id]
+	if !exists || stored.DeletedAt != nil {
+		return nil, fmt.Errorf("%w: %s", ErrProductNotFound, id)
+	}
+	if stored.Stock+delta < 0 {
+		return nil, fmt.Errorf("%w: %s has %d", ErrInsufficientStock, id, stored.Stock)
+	}
+
+	adjusted := cloneProduct(stored)
+	adjusted.Stock += delta
+	adjusted.UpdatedAt = time.Now()
+	adjusted.Version++
+	r.products[id] = adjusted
+
+	return cloneProduct(adjusted), nil
+}
+
+// Purge permanently removes a product from the trash
+func (r *productRepository) Purge(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, exists := r.products[id]
+	if !exists || stored.DeletedAt == nil {
+		return fmt.Errorf("%w in trash: %s", ErrProductNotFound, id)
 	}
 
 	delete(r.products, id)
+	r.index.Remove(id)
 	return nil
 }
 
-// Count returns the total number of products
-func (r *productRepository) Count(ctx context.Context) (int64, error) {
+// PurgeDeletedBefore permanently removes products trashed before cutoff and
+// returns their IDs in order
+func (r *productRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	purged := make([]string, 0)
+	for id, product := range r.products {
+		if product.DeletedAt != nil && product.DeletedAt.Before(cutoff) {
+			delete(r.products, id)
+			r.index.Remove(id)
+			purged = append(purged, id)
+		}
+	}
+
+	sort.Strings(purged)
+	return purged, nil
+}
+
+// Count returns the number of products matching the filter
+func (r *productRepository) Count(ctx context.Context, filter ProductFilter) (int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var count int64
+	for _, product := range r.products {
+		if matchesFilter(product, filter) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// CountByCategory returns the number of live and trashed products in a category
+func (r *productRepository) CountByCategory(ctx context.Context, categoryID string) (int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var count int64
+	for _, product := range r.products {
+		if product.CategoryID == categoryID {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Search ranks live products by relevance using the full-text index
+func (r *productRepository) Search(ctx context.Context, query SearchQuery) ([]*SearchHit, int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	hits := r.index.Search(query.Text, func(id string) bool {
+		product := r.products[id]
+		return product != nil && product.DeletedAt == nil &&
+			(query.CategoryID == "" || product.CategoryID == query.CategoryID)
+	})
+	total := int64(len(hits))
+
+	// Apply pagination
+	if query.Offset >= len(hits) {
+		return []*SearchHit{}, total, nil
+	}
+
+	end := query.Offset + query.Limit
+	if end > len(hits) {
+		end = len(hits)
+	}
+
+	results := make([]*SearchHit, 0, end-query.Offset)
+	for _, hit := range hits[query.Offset:end] {
+		results = append(results, newSearchHit(cloneProduct(r.products[hit.ID]), hit.Score, query.Text))
+	}
+
+	return results, total, nil
+}
+
+// Suggest returns distinct live product names completing prefix
+func (r *productRepository) Suggest(ctx context.Context, prefix string, limit int) ([]string, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	return int64(len(r.products)), nil
+	prefix = strings.ToLower(prefix)
+	ranks := make(map[string]int)
+	for _, product := range r.products {
+		if product.DeletedAt != nil {
+			continue
+		}
+		if rank := suggestionRank(product.Name, prefix); rank >= 0 {
+			ranks[product.Name] = rank
+		}
+	}
+
+	names := make([]string, 0, len(ranks))
+	for name := range ranks {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if ranks[names[i]] != ranks[names[j]] {
+			return ranks[names[i]] < ranks[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	if len(names) > limit {
+		names = names[:limit]
+	}
+	return names, nil
+}
+
+// cloneProduct returns a copy of product
+func cloneProduct(product *Product) *Product {
+	clone := *product
+	if product.DeletedAt != nil {
+		deletedAt := *product.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	return &clone
+}
+
+// cloneProducts returns copies of every product in the slice
+func cloneProducts(products []*Product) []*Product {
+	clones := make([]*Product, len(products))
+	for i, product := range products {
+		clones[i] = cloneProduct(product)
+	}
+	return clones
+}
+
+// matchesFilter reports whether a product satisfies every filter criterion
+func matchesFilter(product *Product, filter ProductFilter) bool {
+	if (product.DeletedAt != nil) != filter.Trashed {
+		return false
+	}
+	if filter.CategoryID != "" && product.CategoryID != filter.CategoryID {
+		return false
+	}
+	if filter.MinPrice != nil && product.Price.Float64() < *filter.MinPrice {
+		return false
+	}
+	if filter.MaxPrice != nil && product.Price.Float64() > *filter.MaxPrice {
+		return false
+	}
+	if filter.InStockOnly && product.Stock <= 0 {
+		return false
+	}
+	if filter.Search != "" {
+		search := strings.ToLower(filter.Search)
+		if !strings.Contains(strings.ToLower(product.Name), search) &&
+			!strings.Contains(strings.ToLower(product.Description), search) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortProducts orders products by field, breaking ties by ID so that
+// pagination is stable between calls
+func sortProducts(products []*Product, field, order string) {
+	desc := order == constants.SortOrderDesc
+	sort.SliceStable(products, func(i, j int) bool {
+		result := compareWithTiebreak(products[i], products[j], field)
+		if desc {
+			return result > 0
+		}
+		return result < 0
+	})
+}
+
+// compareWithTiebreak compares two products on a sortable field, then by ID
+func compareWithTiebreak(a, b *Product, field string) int {
+	if result := compareProducts(a, b, field); result != 0 {
+		return result
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+// compareProducts compares two products on a sortable field. Text is
+// compared byte by byte, the order PostgreSQL uses with COLLATE "C", so
+// "Zebra" sorts before "apple" on both backends.
+func compareProducts(a, b *Product, field string) int {
+	switch field {
+	case "name":
+		return strings.Compare(a.Name, b.Name)
+	case "description":
+		return strings.Compare(a.Description, b.Description)
+	case "price":
+		return a.Price.CompareAmount(b.Price)
+	case "category_id":
+		return strings.Compare(a.CategoryID, b.CategoryID)
+	case "stock":
+		return cmp.Compare(a.Stock, b.Stock)
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	default:
+		return strings.Compare(a.ID, b.ID)
+	}
 }
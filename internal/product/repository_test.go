@@ -0,0 +1,150 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gin-service/pkg/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedProducts(t *testing.T, repo ProductRepository, products ...*Product) {
+	t.Helper()
+	for _, product := range products {
+		require.NoError(t, repo.Create(context.Background(), product))
+	}
+}
+
+func usd(amount string) money.Money {
+	return money.MustParse(amount, "USD")
+}
+
+// anyCategory is a CategoryLookup that knows every category
+type anyCategory struct{}
+
+func (anyCategory) Exists(ctx context.Context, id string) (bool, error) {
+	return id != "", nil
+}
+
+// categorySet is a CategoryLookup that knows the categories it holds
+type categorySet map[string]bool
+
+func (s categorySet) Exists(ctx context.Context, id string) (bool, error) {
+	return s[id], nil
+}
+
+func productIDs(products []*Product) []string {
+	ids := make([]string, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	return ids
+}
+
+func TestProductRepository_GetAll_FiltersAndSorts(t *testing.T) {
+	repo := NewProductRepository()
+	ctx := context.Background()
+	seedProducts(t, repo,
+		&Product{ID: "a", Name: "Red Mug", Description: "Ceramic", Price: usd("12"), CategoryID: "kitchen", Stock: 3},
+		&Product{ID: "b", Name: "Blue Mug", Description: "Ceramic", Price: usd("8"), CategoryID: "kitchen", Stock: 0},
+		&Product{ID: "c", Name: "Desk Lamp", Description: "Warm red light", Price: usd("40"), CategoryID: "office", Stock: 7},
+	)
+
+	minPrice := 10.0
+	products, err := repo.GetAll(ctx, ProductQuery{
+		Filter:    ProductFilter{CategoryID: "kitchen", MinPrice: &minPrice},
+		SortField: "price",
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, productIDs(products))
+
+	products, err = repo.GetAll(ctx, ProductQuery{
+		Filter:    ProductFilter{Search: "red"},
+		SortField: "name",
+		SortOrder: "desc",
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, productIDs(products))
+
+	count, err := repo.Count(ctx, ProductFilter{InStockOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestProductRepository_GetAll_SortsTextByByteOrder(t *testing.T) {
+	repo := NewProductRepository()
+	seedProducts(t, repo,
+		&Product{ID: "a", Name: "apple", Price: usd("1"), CategoryID: "x"},
+		&Product{ID: "b", Name: "Zebra", Price: usd("1"), CategoryID: "x"},
+		&Product{ID: "c", Name: "Banana", Price: usd("1"), CategoryID: "x"},
+	)
+
+	products, err := repo.GetAll(context.Background(), ProductQuery{SortField: "name", Limit: 10})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "b", "a"}, productIDs(products))
+
+	// PostgreSQL sorts the same text columns with the byte-order collation
+	column, _ := sortClause(ProductQuery{SortField: "name"})
+	assert.Equal(t, `name COLLATE "C"`, column)
+	column, _ = sortClause(ProductQuery{SortField: "price"})
+	assert.Equal(t, "price", column)
+}
+
+func TestProductRepository_GetAll_IsDeterministic(t *testing.T) {
+	repo := NewProductRepository()
+	ctx := context.Background()
+	seedProducts(t, repo,
+		&Product{ID: "c", Name: "Same", Price: usd("5"), CategoryID: "x"},
+		&Product{ID: "a", Name: "Same", Price: usd("5"), CategoryID: "x"},
+		&Product{ID: "b", Name: "Same", Price: usd("5"), CategoryID: "x"},
+	)
+
+	for i := 0; i < 5; i++ {
+		products, err := repo.GetAll(ctx, ProductQuery{SortField: "price", Limit: 2, Offset: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "c"}, productIDs(products))
+	}
+}
+
+func TestProductRepository_Update_DetectsConcurrentWriters(t *testing.T) {
+	repo := NewProductRepository()
+	ctx := context.Background()
+	seedProducts(t, repo, &Product{ID: "p1", Name: "Mug", Price: usd("5"), CategoryID: "x", Stock: 1})
+
+	first, err := repo.GetByID(ctx, "p1")
+	require.NoError(t, err)
+	second, err := repo.GetByID(ctx, "p1")
+	require.NoError(t, err)
+
+	first.Stock = 0
+	require.NoError(t, repo.Update(ctx, first))
+
+	second.Stock = 5
+	assert.True(t, errors.Is(repo.Update(ctx, second), ErrVersionConflict))
+}
+
+func TestVariantsExpansion_ResolvesAPageAtOnce(t *testing.T) {
+	ctx := context.Background()
+	repository := NewVariantRepository()
+	for _, variant := range []*Variant{
+		{ProductID: "shirt", SKU: "SHIRT-M"},
+		{ProductID: "mug", SKU: "MUG-XL"},
+		{ProductID: "shirt", SKU: "SHIRT-L"},
+	} {
+		require.NoError(t, repository.Create(ctx, variant))
+	}
+
+	resolved, err := VariantsExpansion(repository).Resolve(ctx, []string{"shirt", "plate"})
+	require.NoError(t, err)
+	require.Len(t, resolved, 2)
+	shirts := resolved["shirt"].([]*Variant)
+	require.Len(t, shirts, 2)
+	assert.Equal(t, "SHIRT-L", shirts[0].SKU)
+	assert.Empty(t, resolved["plate"])
+}
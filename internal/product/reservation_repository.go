@@ -0,0 +1,118 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// reservationRepository implements ReservationRepository in memory
+type reservationRepository struct {
+	reservations map[string]*Reservation
+	mutex        sync.RWMutex
+}
+
+// NewReservationRepository creates a new in-memory reservation repository
+func NewReservationRepository() ReservationRepository {
+	return &reservationRepository{
+		reservations: make(map[string]*Reservation),
+	}
+}
+
+// Create stores a new reservation
+func (r *reservationRepository) Create(ctx context.Context, reservation *Reservation) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if reservation.ID == "" {
+		reservation.ID = uuid.New().String()
+	}
+
+	now := time.Now()
+	reservation.CreatedAt = now
+	reservation.UpdatedAt = now
+
+	stored := *reservation
+	r.reservations[reservation.ID] = &stored
+	return nil
+}
+
+// GetByID retrieves a reservation by ID
+func (r *reservationRepository) GetByID(ctx context.Context, id string) (*Reservation, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stored, exists := r.reservations[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrReservationNotFound, id)
+	}
+
+	reservation := *stored
+	return &reservation, nil
+}
+
+// Resolve moves an active reservation to status
+func (r *reservationRepository) Resolve(ctx context.Context, id, status string) (*Reservation, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, exists := r.reservations[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrReservationNotFound, id)
+	}
+	if stored.Status != ReservationActive {
+		return nil, fmt.Errorf("%w: %s is %s", ErrReservationNotActive, id, stored.Status)
+	}
+
+	resolved := *stored
+	resolved.Status = status
+	resolved.UpdatedAt = time.Now()
+	r.reservations[id] = &resolved
+
+	reservation := resolved
+	return &reservation, nil
+}
+
+// ListExpired returns up to limit active reservations that expired at or
+// before now, oldest first
+func (r *reservationRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*Reservation, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	expired := make([]*Reservation, 0)
+	for _, stored := range r.reservations {
+		if stored.Status == ReservationActive && !stored.ExpiresAt.After(now) {
+			reservation := *stored
+			expired = append(expired, &reservation)
+		}
+	}
+
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].ExpiresAt.Before(expired[j].ExpiresAt)
+	})
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+
+	return expired, nil
+}
+
+// DeleteByProducts removes every reservation of the given products
+func (r *reservationRepository) DeleteByProducts(ctx context.Context, productIDs []string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, productID := range productIDs {
+		for id, reservation := range r.reservations {
+			if reservation.ProductID == productID {
+				delete(r.reservations, id)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package product
+
+import (
+	"strings"
+
+	"gin-service/pkg/search"
+)
+
+// Field weights for ranking, matching the default ts_rank weights of the A
+// and B labels the PostgreSQL search vector gives names and descriptions
+const (
+	nameSearchWeight        = 1.0
+	descriptionSearchWeight = 0.4
+)
+
+// snippetWords is the length of description snippets, matching the
+// ts_headline MaxWords option
+const snippetWords = 35
+
+// searchFields returns the weighted text a product is indexed under
+func searchFields(product *Product) []search.Field {
+	return []search.Field{
+		{Text: product.Name, Weight: nameSearchWeight},
+		{Text: product.Description, Weight: descriptionSearchWeight},
+	}
+}
+
+// newSearchHit builds the hit of a product matching text with its highlights
+func newSearchHit(product *Product, score float64, text string) *SearchHit {
+	return &SearchHit{
+		Product: product,
+		Score:   score,
+		Highlights: SearchHighlights{
+			Name:        search.Highlight(product.Name, text, 0),
+			Description: search.Highlight(product.Description, text, snippetWords),
+		},
+	}
+}
+
+// suggestionRank reports how well name completes a lower-case prefix: 0 when
+// name starts with it, 1 when a later word does and -1 when neither does
+func suggestionRank(name, prefix string) int {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasPrefix(lower, prefix):
+		return 0
+	case strings.Contains(lower, " "+prefix):
+		return 1
+	default:
+		return -1
+	}
+}
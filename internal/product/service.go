@@ -1,45 +1,71 @@
 package product
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"gin-service/pkg/audit"
+	"gin-service/pkg/common"
+	"gin-service/pkg/constants"
+	"gin-service/pkg/database"
+	"gin-service/pkg/logger"
+	"gin-service/pkg/patch"
+	"gin-service/pkg/search"
+	"gin-service/pkg/validation"
 )
 
+// productEntity is the entity type products are audited under
+const productEntity = "product"
+
 // productService implements ProductService interface
 type productService struct {
-	repository ProductRepository
+	repository   ProductRepository
+	variants     VariantRepository
+	reservations ReservationRepository
+	categories   CategoryLookup
+	txManager    database.TransactionManager
+	auditStore   audit.Store
 }
 
-// NewProductService creates a new product service instance
-func NewProductService(repository ProductRepository) ProductService {
+// NewProductService creates a new product service instance. txManager scopes
+// each write and its audit event, as well as atomic batches, to a single
+// transaction. categories is checked whenever a product is filed under a
+// category. Purging a product also removes its variants and reservations.
+func NewProductService(repository ProductRepository, variants VariantRepository, reservations ReservationRepository, categories CategoryLookup, txManager database.TransactionManager, auditStore audit.Store) ProductService {
 	return &productService{
-		repository: repository,
+		repository:   repository,
+		variants:     variants,
+		reservations: reservations,
+		categories:   categories,
+		txManager:    txManager,
+		auditStore:   auditStore,
 	}
 }
 
 // CreateProduct handles product creation business logic
 func (s *productService) CreateProduct(ctx context.Context, req *CreateProductRequest) (*ProductResponse, error) {
-	// Validate business rules
-	if req.Price <= 0 {
-		return nil, fmt.Errorf("price must be greater than zero")
+	product, err := newProduct(req)
+	if err != nil {
+		return nil, err
 	}
-
-	if req.Stock < 0 {
-		return nil, fmt.Errorf("stock cannot be negative")
-	}
-
-	// Create product entity
-	product := &Product{
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		Category:    req.Category,
-		Stock:       req.Stock,
+	if err := s.checkCategory(ctx, product.CategoryID, nil); err != nil {
+		return nil, err
 	}
 
 	// Save to repository
-	if err := s.repository.Create(ctx, product); err != nil {
-		return nil, fmt.Errorf("failed to create product: %w", err)
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repository.Create(ctx, product); err != nil {
+			return err
+		}
+		return s.record(ctx, audit.ActionCreate, product.ID, nil, product)
+	})
+	if err != nil {
+		return nil, repositoryError("failed to create product", err)
 	}
 
 	return &ProductResponse{
@@ -51,12 +77,12 @@ func (s *productService) CreateProduct(ctx context.Context, req *CreateProductRe
 // GetProduct handles product retrieval business logic
 func (s *productService) GetProduct(ctx context.Context, id string) (*ProductResponse, error) {
 	if id == "" {
-		return nil, fmt.Errorf("product ID is required")
+		return nil, common.NewValidationError("product ID is required")
 	}
 
 	product, err := s.repository.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product: %w", err)
+		return nil, repositoryError("failed to get product", err)
 	}
 
 	return &ProductResponse{
@@ -66,6 +92,17 @@ func (s *productService) GetProduct(ctx context.Context, id string) (*ProductRes
 
 // GetAllProducts handles product listing business logic
 func (s *productService) GetAllProducts(ctx context.Context, req *GetProductsRequest) (*GetProductsResponse, error) {
+	return s.listProducts(ctx, req, false)
+}
+
+// GetTrashedProducts lists products in the trash with the same filtering,
+// sorting and pagination as GetAllProducts
+func (s *productService) GetTrashedProducts(ctx context.Context, req *GetProductsRequest) (*GetProductsResponse, error) {
+	return s.listProducts(ctx, req, true)
+}
+
+// listProducts lists live or trashed products
+func (s *productService) listProducts(ctx context.Context, req *GetProductsRequest, trashed bool) (*GetProductsResponse, error) {
 	// Set default pagination values
 	limit := req.Limit
 	if limit <= 0 {
@@ -80,87 +117,611 @@ func (s *productService) GetAllProducts(ctx context.Context, req *GetProductsReq
 		offset = 0
 	}
 
+	// A cursor carries its own sort, which the request may repeat but not change
+	var cursor *ProductCursor
+	if req.Cursor != "" {
+		if req.Offset > 0 {
+			return nil, common.NewValidationError("cursor and offset cannot be combined")
+		}
+
+		decoded, err := DecodeProductCursor(req.Cursor)
+		if err != nil {
+			return nil, common.NewValidationErrorWithDetails("invalid cursor", err.Error())
+		}
+		if (req.Sort != "" && req.Sort != decoded.SortField) ||
+			(req.Order != "" && !strings.EqualFold(req.Order, decoded.SortOrder)) {
+			return nil, common.NewValidationError("sort and order must match the cursor")
+		}
+
+		cursor = decoded
+		req.Sort, req.Order = decoded.SortField, decoded.SortOrder
+	}
+
+	// Validate sorting
+	sortField := req.Sort
+	if sortField == "" {
+		sortField = DefaultSortField
+	}
+	if _, ok := SortableFields[sortField]; !ok {
+		return nil, common.NewValidationError(fmt.Sprintf("cannot sort by unknown field: %s", sortField))
+	}
+
+	sortOrder := strings.ToLower(req.Order)
+	if sortOrder == "" {
+		sortOrder = constants.SortOrderAsc
+	}
+	if sortOrder != constants.SortOrderAsc && sortOrder != constants.SortOrderDesc {
+		return nil, common.NewValidationError(fmt.Sprintf("sort order must be %s or %s", constants.SortOrderAsc, constants.SortOrderDesc))
+	}
+
+	// Validate filters
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		return nil, common.NewValidationError("min_price cannot be greater than max_price")
+	}
+
+	filter := ProductFilter{
+		CategoryID:  strings.TrimSpace(req.CategoryID),
+		MinPrice:    req.MinPrice,
+		MaxPrice:    req.MaxPrice,
+		InStockOnly: req.InStock,
+		Search:      strings.TrimSpace(req.Search),
+		Trashed:     trashed,
+	}
+
+	// Fetch one extra product to find out whether another page follows
+	query := ProductQuery{
+		Filter:    filter,
+		SortField: sortField,
+		SortOrder: sortOrder,
+		Limit:     limit + 1,
+		Offset:    offset,
+	}
+
 	// Get products from repository
-	products, err := s.repository.GetAll(ctx, limit, offset)
+	var products []*Product
+	var err error
+	if cursor != nil {
+		products, err = s.repository.GetAllAfter(ctx, query, cursor)
+	} else {
+		products, err = s.repository.GetAll(ctx, query)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get products: %w", err)
+		return nil, repositoryError("failed to get products", err)
+	}
+
+	var nextCursor string
+	if len(products) > limit {
+		products = products[:limit]
+		nextCursor = NewProductCursor(products[limit-1], sortField, sortOrder).Encode()
 	}
 
-	// Get total count
-	total, err := s.repository.Count(ctx)
+	// Get total count of matching products
+	total, err := s.repository.Count(ctx, filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product count: %w", err)
+		return nil, repositoryError("failed to get product count", err)
 	}
 
 	return &GetProductsResponse{
-		Products: products,
-		Total:    total,
-		Limit:    limit,
-		Offset:   offset,
+		Products:   products,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+		NextCursor: nextCursor,
 	}, nil
 }
 
-// UpdateProduct handles product update business logic
-func (s *productService) UpdateProduct(ctx context.Context, id string, req *UpdateProductRequest) (*ProductResponse, error) {
+// UpdateProduct handles product update business logic. A non-zero
+// expectedVersion makes the update conditional on the client's last read;
+// otherwise the version read here guards against concurrent writers.
+func (s *productService) UpdateProduct(ctx context.Context, id string, req *UpdateProductRequest, expectedVersion int64) (*ProductResponse, error) {
 	if id == "" {
-		return nil, fmt.Errorf("product ID is required")
+		return nil, common.NewValidationError("product ID is required")
 	}
 
 	// Get existing product
 	existingProduct, err := s.repository.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product: %w", err)
+		return nil, repositoryError("failed to get product", err)
 	}
 
-	// Apply updates
-	if req.Name != nil {
-		existingProduct.Name = *req.Name
+	if expectedVersion != 0 && existingProduct.Version != expectedVersion {
+		return nil, repositoryError("failed to update product", ErrVersionConflict)
 	}
-	if req.Description != nil {
-		existingProduct.Description = *req.Description
+
+	before := cloneProduct(existingProduct)
+	if err := applyUpdate(existingProduct, req); err != nil {
+		return nil, err
 	}
-	if req.Price != nil {
-		if *req.Price <= 0 {
-			return nil, fmt.Errorf("price must be greater than zero")
-		}
-		existingProduct.Price = *req.Price
+
+	return s.saveUpdate(ctx, before, existingProduct)
+}
+
+// PatchProduct applies a JSON Merge Patch or JSON Patch document, chosen by
+// contentType, to the writable fields of a product. The patched product must
+// pass the same validation as a newly created one. expectedVersion works as
+// in UpdateProduct.
+func (s *productService) PatchProduct(ctx context.Context, id, contentType string, document []byte, expectedVersion int64) (*ProductResponse, error) {
+	if id == "" {
+		return nil, common.NewValidationError("product ID is required")
 	}
-	if req.Category != nil {
-		existingProduct.Category = *req.Category
+
+	var apply func(document, patch []byte) ([]byte, error)
+	switch contentType {
+	case constants.ContentTypeMergePatch:
+		apply = patch.Merge
+	case constants.ContentTypeJSONPatch:
+		apply = patch.Apply
+	default:
+		return nil, common.NewUnsupportedMediaTypeError(fmt.Sprintf("patch content type must be %s or %s", constants.ContentTypeMergePatch, constants.ContentTypeJSONPatch))
 	}
-	if req.Stock != nil {
-		if *req.Stock < 0 {
-			return nil, fmt.Errorf("stock cannot be negative")
+
+	// Get existing product
+	existingProduct, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, repositoryError("failed to get product", err)
+	}
+
+	if expectedVersion != 0 && existingProduct.Version != expectedVersion {
+		return nil, repositoryError("failed to update product", ErrVersionConflict)
+	}
+
+	req, err := patchProduct(existingProduct, document, apply)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := newProduct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	before := cloneProduct(existingProduct)
+	existingProduct.Name = patched.Name
+	existingProduct.Description = patched.Description
+	existingProduct.Price = patched.Price
+	existingProduct.CategoryID = patched.CategoryID
+	existingProduct.Stock = patched.Stock
+
+	return s.saveUpdate(ctx, before, existingProduct)
+}
+
+// saveUpdate stores a changed product together with its audit event,
+// checking the category when the change moved the product to another one
+func (s *productService) saveUpdate(ctx context.Context, before, product *Product) (*ProductResponse, error) {
+	if product.CategoryID != before.CategoryID {
+		if err := s.checkCategory(ctx, product.CategoryID, nil); err != nil {
+			return nil, err
 		}
-		existingProduct.Stock = *req.Stock
 	}
 
 	// Save to repository
-	if err := s.repository.Update(ctx, existingProduct); err != nil {
-		return nil, fmt.Errorf("failed to update product: %w", err)
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repository.Update(ctx, product); err != nil {
+			return err
+		}
+		return s.record(ctx, audit.ActionUpdate, product.ID, before, product)
+	})
+	if err != nil {
+		return nil, repositoryError("failed to update product", err)
 	}
 
 	return &ProductResponse{
-		Product: existingProduct,
+		Product: product,
 		Message: "Product updated successfully",
 	}, nil
 }
 
-// DeleteProduct handles product deletion business logic
-func (s *productService) DeleteProduct(ctx context.Context, id string) error {
+// DeleteProduct moves a product to the trash. A non-zero expectedVersion
+// makes the delete conditional on the client's last read.
+func (s *productService) DeleteProduct(ctx context.Context, id string, expectedVersion int64) error {
 	if id == "" {
-		return fmt.Errorf("product ID is required")
+		return common.NewValidationError("product ID is required")
 	}
 
 	// Check if product exists
-	if _, err := s.repository.GetByID(ctx, id); err != nil {
-		return fmt.Errorf("failed to get product: %w", err)
+	existingProduct, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return repositoryError("failed to get product", err)
 	}
 
 	// Delete from repository
-	if err := s.repository.Delete(ctx, id); err != nil {
-		return fmt.Errorf("failed to delete product: %w", err)
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repository.Delete(ctx, id, expectedVersion); err != nil {
+			return err
+		}
+		return s.record(ctx, audit.ActionDelete, id, existingProduct, nil)
+	})
+	if err != nil {
+		return repositoryError("failed to delete product", err)
 	}
 
 	return nil
 }
+
+// RestoreProduct moves a product out of the trash
+func (s *productService) RestoreProduct(ctx context.Context, id string) (*ProductResponse, error) {
+	if id == "" {
+		return nil, common.NewValidationError("product ID is required")
+	}
+
+	var product *Product
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if product, err = s.repository.Restore(ctx, id); err != nil {
+			return err
+		}
+		return s.record(ctx, audit.ActionRestore, id, nil, nil)
+	})
+	if err != nil {
+		return nil, repositoryError("failed to restore product", err)
+	}
+
+	return &ProductResponse{
+		Product: product,
+		Message: "Product restored successfully",
+	}, nil
+}
+
+// PurgeProduct permanently removes a product from the trash
+func (s *productService) PurgeProduct(ctx context.Context, id string) error {
+	if id == "" {
+		return common.NewValidationError("product ID is required")
+	}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repository.Purge(ctx, id); err != nil {
+			return err
+		}
+		if err := s.purgeDependents(ctx, []string{id}); err != nil {
+			return err
+		}
+		return s.record(ctx, audit.ActionPurge, id, nil, nil)
+	})
+	if err != nil {
+		return repositoryError("failed to purge product", err)
+	}
+
+	return nil
+}
+
+// PurgeTrash permanently removes products trashed before deletedBefore,
+// recording the purge of each, and returns how many were removed
+func (s *productService) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged []string
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if purged, err = s.repository.PurgeDeletedBefore(ctx, deletedBefore); err != nil {
+			return err
+		}
+		if err := s.purgeDependents(ctx, purged); err != nil {
+			return err
+		}
+		for _, id := range purged {
+			if err := s.record(ctx, audit.ActionPurge, id, nil, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, repositoryError("failed to purge trash", err)
+	}
+
+	return int64(len(purged)), nil
+}
+
+// purgeDependents removes the variants and reservations of purged products.
+// PostgreSQL already cascades the product delete to them; other backends
+// rely on this.
+func (s *productService) purgeDependents(ctx context.Context, productIDs []string) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+	if err := s.variants.DeleteByProducts(ctx, productIDs); err != nil {
+		return err
+	}
+	return s.reservations.DeleteByProducts(ctx, productIDs)
+}
+
+// GetProductHistory lists the audit events of a product, newest first. The
+// history outlives the product, so trashed and purged products have one too.
+func (s *productService) GetProductHistory(ctx context.Context, id string, req *GetProductHistoryRequest) (*ProductHistoryResponse, error) {
+	if id == "" {
+		return nil, common.NewValidationError("product ID is required")
+	}
+
+	response, err := listHistory(ctx, s.auditStore, productEntity, id, req)
+	if err != nil {
+		return nil, common.NewInternalErrorWithErr("failed to get product history", err)
+	}
+
+	if len(response.Events) == 0 && response.Offset == 0 {
+		return nil, common.NewNotFoundError("Product not found")
+	}
+
+	return response, nil
+}
+
+// listHistory returns one page of the audit events of an entity, newest
+// first, clamping the requested page to the allowed sizes
+func listHistory(ctx context.Context, store audit.Store, entity, id string, req *GetProductHistoryRequest) (*ProductHistoryResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = constants.DefaultPageSize
+	}
+	if limit > constants.MaxPageSize {
+		limit = constants.MaxPageSize
+	}
+
+	offset := req.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	events, err := store.List(ctx, audit.Query{
+		EntityType: entity,
+		EntityID:   id,
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProductHistoryResponse{
+		Events: events,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// SearchProducts ranks live products by relevance to the query text
+func (s *productService) SearchProducts(ctx context.Context, req *SearchProductsRequest) (*SearchProductsResponse, error) {
+	text := strings.TrimSpace(req.Q)
+	if len(search.Terms(text)) == 0 {
+		return nil, common.NewValidationError("search query has no searchable words")
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = constants.DefaultPageSize
+	}
+	if limit > constants.MaxPageSize {
+		limit = constants.MaxPageSize
+	}
+
+	offset := req.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	hits, total, err := s.repository.Search(ctx, SearchQuery{
+		Text:       text,
+		CategoryID: strings.TrimSpace(req.CategoryID),
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		return nil, repositoryError("failed to search products", err)
+	}
+
+	return &SearchProductsResponse{
+		Results: hits,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	}, nil
+}
+
+// SuggestProducts returns product names completing the typed prefix
+func (s *productService) SuggestProducts(ctx context.Context, req *SuggestProductsRequest) (*SuggestProductsResponse, error) {
+	prefix := strings.TrimSpace(req.Q)
+	if prefix == "" {
+		return nil, common.NewValidationError("suggestion prefix is required")
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultSuggestionLimit
+	}
+
+	names, err := s.repository.Suggest(ctx, prefix, limit)
+	if err != nil {
+		return nil, repositoryError("failed to suggest products", err)
+	}
+
+	return &SuggestProductsResponse{
+		Suggestions: names,
+	}, nil
+}
+
+// record stores an audit event for a product change
+func (s *productService) record(ctx context.Context, action, id string, before, after *Product) error {
+	return recordProductChange(ctx, s.auditStore, action, id, before, after)
+}
+
+// recordProductChange stores an audit event for a product change. Changes
+// are the fields that differ between before and after, apart from the
+// timestamp and version bookkeeping; either may be nil.
+func recordProductChange(ctx context.Context, store audit.Store, action, id string, before, after *Product) error {
+	return recordChange(ctx, store, productEntity, action, id, before, after)
+}
+
+// recordChange stores an audit event for a change to an entity with the
+// actors and request ID from ctx, leaving updated_at and version out of
+// the changes
+func recordChange(ctx context.Context, store audit.Store, entity, action, id string, before, after interface{}) error {
+	changes, err := audit.Diff(before, after, "updated_at", "version")
+	if err != nil {
+		return err
+	}
+
+	return store.Record(ctx, &audit.Event{
+		EntityType:   entity,
+		EntityID:     id,
+		Action:       action,
+		Actor:        audit.ActorFromContext(ctx),
+		ClaimedActor: audit.ClaimedActorFromContext(ctx),
+		RequestID:    logger.RequestIDFromContext(ctx),
+		Changes:      changes,
+		OccurredAt:   time.Now().UTC().Truncate(time.Microsecond),
+	})
+}
+
+// checkCategory verifies that categoryID references an existing category.
+// Lookups are cached in known when it is not nil.
+func (s *productService) checkCategory(ctx context.Context, categoryID string, known map[string]bool) error {
+	exists, cached := known[categoryID]
+	if !cached {
+		var err error
+		if exists, err = s.categories.Exists(ctx, categoryID); err != nil {
+			return common.NewInternalErrorWithErr("failed to check category", err)
+		}
+		if known != nil {
+			known[categoryID] = exists
+		}
+	}
+
+	if !exists {
+		return common.NewValidationErrorWithFields(constants.ErrMsgValidationFailed, []common.FieldError{{
+			Field:   "category_id",
+			Rule:    "exists",
+			Message: fmt.Sprintf("category not found: %s", categoryID),
+		}})
+	}
+	return nil
+}
+
+// newProduct validates a create request against business rules and builds
+// the product entity
+func newProduct(req *CreateProductRequest) (*Product, error) {
+	if !req.Price.IsPositive() {
+		return nil, common.NewValidationError("price must be greater than zero")
+	}
+
+	if req.Stock < 0 {
+		return nil, common.NewValidationError("stock cannot be negative")
+	}
+
+	return &Product{
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		CategoryID:  req.CategoryID,
+		Stock:       req.Stock,
+	}, nil
+}
+
+// patchTarget is the document patches are applied to: the writable fields
+// of a product, which are those of a create request. Its encoding is fixed
+// rather than borrowed from the API types so that the price always keeps its
+// currency, however prices are rendered in responses.
+type patchTarget struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Price       patchPrice `json:"price"`
+	CategoryID  string     `json:"category_id"`
+	Stock       int        `json:"stock"`
+}
+
+// patchPrice is the encoding of a price in a patchTarget
+type patchPrice struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// patchProduct applies a patch document to the writable fields of product
+// and validates the outcome with the create request's binding rules
+func patchProduct(product *Product, document []byte, apply func(document, patch []byte) ([]byte, error)) (*CreateProductRequest, error) {
+	current, err := json.Marshal(&patchTarget{
+		Name:        product.Name,
+		Description: product.Description,
+		Price: patchPrice{
+			Amount:   product.Price.Decimal(),
+			Currency: product.Price.Currency,
+		},
+		CategoryID: product.CategoryID,
+		Stock:      product.Stock,
+	})
+	if err != nil {
+		return nil, common.NewInternalErrorWithErr("failed to encode product", err)
+	}
+
+	patched, err := apply(current, document)
+	switch {
+	case errors.Is(err, patch.ErrTestFailed):
+		return nil, common.NewConflictErrorWithErr("Patch test operation failed", err)
+	case err != nil:
+		return nil, common.NewValidationErrorWithDetails("Invalid patch document", err.Error())
+	}
+
+	// Only writable fields may be patched, so identity and bookkeeping
+	// fields such as id and version are rejected as unknown
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+
+	var req CreateProductRequest
+	if err := decoder.Decode(&req); err != nil {
+		return nil, validation.Translate(err)
+	}
+	if appErr := validation.Validate(&req); appErr != nil {
+		return nil, appErr
+	}
+
+	return &req, nil
+}
+
+// applyUpdate validates an update request against business rules and copies
+// the fields it sets onto product
+func applyUpdate(product *Product, req *UpdateProductRequest) error {
+	if req.Price != nil && !req.Price.IsPositive() {
+		return common.NewValidationError("price must be greater than zero")
+	}
+	if req.Stock != nil && *req.Stock < 0 {
+		return common.NewValidationError("stock cannot be negative")
+	}
+
+	if req.Name != nil {
+		product.Name = *req.Name
+	}
+	if req.Description != nil {
+		product.Description = *req.Description
+	}
+	if req.Price != nil {
+		product.Price = *req.Price
+	}
+	if req.CategoryID != nil {
+		product.CategoryID = *req.CategoryID
+	}
+	if req.Stock != nil {
+		product.Stock = *req.Stock
+	}
+
+	return nil
+}
+
+// repositoryError translates a repository failure into an AppError, keeping
+// the original error in the chain for logging
+func repositoryError(message string, err error) error {
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		return common.NewNotFoundErrorWithErr("Product not found", err)
+	case errors.Is(err, ErrVersionConflict):
+		return common.NewPreconditionFailedErrorWithErr(ErrVersionConflict.Error(), err)
+	case errors.Is(err, ErrInsufficientStock):
+		return common.NewConflictErrorWithErr("Insufficient stock", err)
+	case errors.Is(err, ErrReservationNotFound):
+		return common.NewNotFoundErrorWithErr("Reservation not found", err)
+	case errors.Is(err, ErrReservationNotActive):
+		return common.NewConflictErrorWithErr(ErrReservationNotActive.Error(), err)
+	case errors.Is(err, ErrReservationExpired):
+		return common.NewConflictErrorWithErr(ErrReservationExpired.Error(), err)
+	case errors.Is(err, ErrVariantNotFound):
+		return common.NewNotFoundErrorWithErr("Variant not found", err)
+	case errors.Is(err, ErrVariantVersionConflict):
+		return common.NewPreconditionFailedErrorWithErr(ErrVariantVersionConflict.Error(), err)
+	case errors.Is(err, ErrSKUTaken):
+		return common.NewConflictErrorWithErr(ErrSKUTaken.Error(), err)
+	default:
+		return common.NewInternalErrorWithErr(message, err)
+	}
+}
@@ -0,0 +1,783 @@
+package product
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gin-service/pkg/audit"
+	"gin-service/pkg/common"
+	"gin-service/pkg/database"
+	"gin-service/pkg/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductService_GetAllProducts_CursorPagination(t *testing.T) {
+	repo := NewProductRepository()
+	service := NewProductService(repo, NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		seedProducts(t, repo, &Product{ID: fmt.Sprintf("p%d", i), Name: "Item", Price: money.New(int64(i)*100, "USD"), CategoryID: "x"})
+	}
+
+	first, err := service.GetAllProducts(ctx, &GetProductsRequest{Limit: 2, Sort: "price", Order: "desc"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p5", "p4"}, productIDs(first.Products))
+	require.NotEmpty(t, first.NextCursor)
+
+	// Deleting an already-seen product must not shift the next page
+	require.NoError(t, repo.Delete(ctx, "p5", 0))
+
+	second, err := service.GetAllProducts(ctx, &GetProductsRequest{Limit: 2, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p3", "p2"}, productIDs(second.Products))
+
+	third, err := service.GetAllProducts(ctx, &GetProductsRequest{Limit: 2, Cursor: second.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p1"}, productIDs(third.Products))
+	assert.Empty(t, third.NextCursor)
+}
+
+func TestProductService_GetAllProducts_RejectsMismatchedCursor(t *testing.T) {
+	service := NewProductService(NewProductRepository(), NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+	cursor := NewProductCursor(&Product{ID: "p1", Price: usd("1")}, "price", "asc").Encode()
+
+	_, err := service.GetAllProducts(context.Background(), &GetProductsRequest{Cursor: cursor, Sort: "name"})
+	assert.Error(t, err)
+
+	_, err = service.GetAllProducts(context.Background(), &GetProductsRequest{Cursor: "not-a-cursor"})
+	assert.Error(t, err)
+}
+
+func TestProductService_UpdateProduct_RejectsStaleVersion(t *testing.T) {
+	repo := NewProductRepository()
+	service := NewProductService(repo, NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+	ctx := context.Background()
+	seedProducts(t, repo, &Product{ID: "p1", Name: "Mug", Price: usd("5"), CategoryID: "x"})
+
+	name := "Cup"
+	updated, err := service.UpdateProduct(ctx, "p1", &UpdateProductRequest{Name: &name}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Product.Version)
+
+	other := "Glass"
+	_, err = service.UpdateProduct(ctx, "p1", &UpdateProductRequest{Name: &other}, 1)
+	assert.True(t, errors.Is(err, ErrVersionConflict))
+	assert.Equal(t, http.StatusPreconditionFailed, common.GetHTTPStatus(err))
+
+	err = service.DeleteProduct(ctx, "p1", 1)
+	assert.True(t, errors.Is(err, ErrVersionConflict))
+	assert.Equal(t, http.StatusPreconditionFailed, common.GetHTTPStatus(err))
+
+	stored, err := repo.GetByID(ctx, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, "Cup", stored.Name)
+}
+
+func TestProductService_ReturnsTypedErrors(t *testing.T) {
+	service := NewProductService(NewProductRepository(), NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+	ctx := context.Background()
+
+	_, err := service.GetProduct(ctx, "missing")
+	assert.Equal(t, http.StatusNotFound, common.GetHTTPStatus(err))
+	assert.True(t, errors.Is(err, ErrProductNotFound))
+
+	price := usd("-1")
+	seeded, err := service.CreateProduct(ctx, &CreateProductRequest{Name: "Mug", Price: usd("5"), CategoryID: "x"})
+	require.NoError(t, err)
+	_, err = service.UpdateProduct(ctx, seeded.Product.ID, &UpdateProductRequest{Price: &price}, 0)
+	assert.Equal(t, http.StatusBadRequest, common.GetHTTPStatus(err))
+}
+
+func TestProductService_RejectsUnknownCategory(t *testing.T) {
+	ctx := context.Background()
+	service := NewProductService(NewProductRepository(), NewVariantRepository(), NewReservationRepository(), categorySet{"kitchen": true}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+
+	created, err := service.CreateProduct(ctx, &CreateProductRequest{Name: "Mug", Price: usd("5"), CategoryID: "kitchen"})
+	require.NoError(t, err)
+
+	_, err = service.CreateProduct(ctx, &CreateProductRequest{Name: "Lamp", Price: usd("20"), CategoryID: "garden"})
+	require.Equal(t, http.StatusBadRequest, common.GetHTTPStatus(err))
+	assert.Equal(t, "category_id", common.GetAppError(err).Fields[0].Field)
+
+	garden := "garden"
+	_, err = service.UpdateProduct(ctx, created.Product.ID, &UpdateProductRequest{CategoryID: &garden}, 0)
+	assert.Equal(t, http.StatusBadRequest, common.GetHTTPStatus(err))
+
+	response, err := service.BatchProducts(ctx, &BatchProductsRequest{
+		Mode: BatchModeBestEffort,
+		Operations: []BatchOperation{
+			{Op: BatchOpCreate, Product: &CreateProductRequest{Name: "Pan", Price: usd("9"), CategoryID: "kitchen"}},
+			{Op: BatchOpCreate, Product: &CreateProductRequest{Name: "Rake", Price: usd("9"), CategoryID: "garden"}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Succeeded)
+	assert.Equal(t, http.StatusBadRequest, response.Results[1].Status)
+}
+
+func TestProductService_BatchProducts_Atomic(t *testing.T) {
+	ctx := context.Background()
+	repo := NewProductRepository()
+	service := NewProductService(repo, NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+
+	existing := &Product{Name: "Mug", Price: usd("5"), CategoryID: "kitchen", Stock: 1}
+	require.NoError(t, repo.Create(ctx, existing))
+
+	stock := 7
+	req := &BatchProductsRequest{Operations: []BatchOperation{
+		{Op: BatchOpCreate, Product: &CreateProductRequest{Name: "Lamp", Price: usd("20"), CategoryID: "home", Stock: 3}},
+		{Op: BatchOpUpdate, ID: existing.ID, Changes: &UpdateProductRequest{Stock: &stock}},
+		{Op: BatchOpDelete, ID: "missing"},
+	}}
+
+	response, err := service.BatchProducts(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, BatchModeAtomic, response.Mode)
+	assert.Equal(t, 0, response.Succeeded)
+	assert.Equal(t, 3, response.Failed)
+	assert.Equal(t, http.StatusFailedDependency, response.Results[0].Status)
+	assert.Equal(t, http.StatusFailedDependency, response.Results[1].Status)
+	assert.Equal(t, http.StatusNotFound, response.Results[2].Status)
+
+	// Nothing was written
+	count, err := repo.Count(ctx, ProductFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	stored, err := repo.GetByID(ctx, existing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Stock)
+
+	req.Operations = req.Operations[:2]
+	response, err = service.BatchProducts(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, response.Succeeded)
+	assert.Equal(t, http.StatusCreated, response.Results[0].Status)
+	assert.NotEmpty(t, response.Results[0].ID)
+	assert.Equal(t, int64(2), response.Results[1].Product.Version)
+}
+
+// racingRepository reads products as they are and then lets a concurrent
+// writer bump the product named by race before the batch writes
+type racingRepository struct {
+	*productRepository
+	race string
+}
+
+func (r *racingRepository) GetByIDs(ctx context.Context, ids []string) ([]*Product, error) {
+	products, err := r.productRepository.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.productRepository.AdjustStock(ctx, r.race, 1); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func TestProductService_BatchProducts_AtomicWithoutTransactions(t *testing.T) {
+	ctx := context.Background()
+	repo := &racingRepository{productRepository: NewProductRepository().(*productRepository), race: "p2"}
+	seedProducts(t, repo,
+		&Product{ID: "p1", Name: "Mug", Price: usd("5"), CategoryID: "x", Stock: 1},
+		&Product{ID: "p2", Name: "Cup", Price: usd("4"), CategoryID: "x", Stock: 1},
+	)
+	service := NewProductService(repo, NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+
+	stock := 9
+	response, err := service.BatchProducts(ctx, &BatchProductsRequest{Operations: []BatchOperation{
+		{Op: BatchOpCreate, Product: &CreateProductRequest{Name: "Lamp", Price: usd("20"), CategoryID: "x"}},
+		{Op: BatchOpUpdate, ID: "p1", Changes: &UpdateProductRequest{Stock: &stock}},
+		{Op: BatchOpUpdate, ID: "p2", Changes: &UpdateProductRequest{Stock: &stock}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusFailedDependency, response.Results[0].Status)
+	assert.Equal(t, http.StatusFailedDependency, response.Results[1].Status)
+	assert.Equal(t, http.StatusPreconditionFailed, response.Results[2].Status)
+
+	// The conflict was found before anything was written
+	count, err := repo.Count(ctx, ProductFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	stored, err := repo.GetByID(ctx, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Stock)
+}
+
+// plainRepository hides the WriteBatch of the in-memory repository
+type plainRepository struct {
+	ProductRepository
+	createManyErr error
+}
+
+func (r plainRepository) CreateMany(ctx context.Context, products []*Product) error {
+	if r.createManyErr != nil {
+		return r.createManyErr
+	}
+	return r.ProductRepository.CreateMany(ctx, products)
+}
+
+// rollbackTransactionManager stands in for a manager that can roll back
+type rollbackTransactionManager struct{}
+
+func (rollbackTransactionManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestProductService_BatchProducts_AtomicNeedsRollback(t *testing.T) {
+	service := NewProductService(plainRepository{ProductRepository: NewProductRepository()}, NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+
+	_, err := service.BatchProducts(context.Background(), &BatchProductsRequest{Operations: []BatchOperation{
+		{Op: BatchOpCreate, Product: &CreateProductRequest{Name: "Lamp", Price: usd("20"), CategoryID: "x"}},
+	}})
+	assert.Equal(t, http.StatusBadRequest, common.GetHTTPStatus(err))
+}
+
+func TestProductService_BatchProducts_AtomicReportsFailedCreatesPerItem(t *testing.T) {
+	ctx := context.Background()
+	repo := plainRepository{ProductRepository: NewProductRepository(), createManyErr: errors.New("insert failed")}
+	seedProducts(t, repo, &Product{ID: "p1", Name: "Mug", Price: usd("5"), CategoryID: "x", Stock: 1})
+	service := NewProductService(repo, NewVariantRepository(), NewReservationRepository(), anyCategory{}, rollbackTransactionManager{}, audit.NewMemoryStore())
+
+	stock := 9
+	response, err := service.BatchProducts(ctx, &BatchProductsRequest{Operations: []BatchOperation{
+		{Op: BatchOpCreate, Product: &CreateProductRequest{Name: "Lamp", Price: usd("20"), CategoryID: "x"}},
+		{Op: BatchOpUpdate, ID: "p1", Changes: &UpdateProductRequest{Stock: &stock}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, response.Results[0].Status)
+	assert.Equal(t, http.StatusFailedDependency, response.Results[1].Status)
+	assert.Equal(t, 2, response.Failed)
+}
+
+func TestProductService_BatchProducts_BestEffort(t *testing.T) {
+	ctx := context.Background()
+	repo := NewProductRepository()
+	service := NewProductService(repo, NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+
+	existing := &Product{Name: "Mug", Price: usd("5"), CategoryID: "kitchen", Stock: 1}
+	require.NoError(t, repo.Create(ctx, existing))
+
+	response, err := service.BatchProducts(ctx, &BatchProductsRequest{
+		Mode: BatchModeBestEffort,
+		Operations: []BatchOperation{
+			{Op: BatchOpCreate, Product: &CreateProductRequest{Name: "Lamp", Price: usd("20"), CategoryID: "home", Stock: 3}},
+			{Op: BatchOpCreate, Product: &CreateProductRequest{Name: "Free", Price: usd("0"), CategoryID: "home"}},
+			{Op: BatchOpDelete, ID: existing.ID, Version: 5},
+			{Op: BatchOpDelete, ID: existing.ID},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Succeeded)
+	assert.Equal(t, 3, response.Failed)
+	assert.Equal(t, http.StatusCreated, response.Results[0].Status)
+	assert.Equal(t, http.StatusBadRequest, response.Results[1].Status)
+	assert.Equal(t, http.StatusPreconditionFailed, response.Results[2].Status)
+	assert.Equal(t, http.StatusBadRequest, response.Results[3].Status)
+
+	count, err := repo.Count(ctx, ProductFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestProductService_ExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := NewProductService(NewProductRepository(), NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+	for i := 0; i < exportPageSize+3; i++ {
+		_, err := source.CreateProduct(ctx, &CreateProductRequest{
+			Name: fmt.Sprintf("Item, \"%d\"", i), Price: usd("1.5"), CategoryID: "misc", Stock: i,
+		})
+		require.NoError(t, err)
+	}
+
+	for _, format := range []string{FormatCSV, FormatNDJSON} {
+		var buf bytes.Buffer
+		encoder, err := NewProductEncoder(format, &buf)
+		require.NoError(t, err)
+		require.NoError(t, source.ExportProducts(ctx, encoder))
+
+		target := NewProductRepository()
+		decoder, err := NewProductDecoder(format, &buf)
+		require.NoError(t, err)
+		summary, err := NewProductService(target, NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore()).ImportProducts(ctx, format, decoder)
+		require.NoError(t, err)
+
+		assert.Equal(t, exportPageSize+3, summary.Imported, format)
+		assert.Zero(t, summary.Failed, format)
+		count, err := target.Count(ctx, ProductFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(exportPageSize+3), count, format)
+	}
+}
+
+func TestProductService_ImportReportsRowErrors(t *testing.T) {
+	ctx := context.Background()
+	service := NewProductService(NewProductRepository(), NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+
+	body := "name,price,category_id,stock\n" +
+		"Mug,5,kitchen,1\n" +
+		"Lamp,cheap,home,1\n" +
+		",5,home,1\n" +
+		"Chair,5,home\n"
+	decoder, err := NewProductDecoder(FormatCSV, strings.NewReader(body))
+	require.NoError(t, err)
+
+	summary, err := service.ImportProducts(ctx, FormatCSV, decoder)
+	require.NoError(t, err)
+	assert.Equal(t, 4, summary.Total)
+	assert.Equal(t, 1, summary.Imported)
+	assert.Equal(t, 3, summary.Failed)
+	require.Len(t, summary.Errors, 3)
+	assert.Equal(t, 3, summary.Errors[0].Line)
+	assert.Equal(t, "price", summary.Errors[0].Error.Fields[0].Field)
+	assert.Equal(t, 4, summary.Errors[1].Line)
+	assert.Equal(t, "required", summary.Errors[1].Error.Fields[0].Rule)
+	assert.Equal(t, 5, summary.Errors[2].Line)
+
+	_, err = NewProductDecoder("xml", strings.NewReader(""))
+	assert.Error(t, err)
+}
+
+func TestProductService_TrashLifecycle(t *testing.T) {
+	ctx := context.Background()
+	service := NewProductService(NewProductRepository(), NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+
+	created, err := service.CreateProduct(ctx, &CreateProductRequest{Name: "Mug", Price: usd("5"), CategoryID: "kitchen", Stock: 1})
+	require.NoError(t, err)
+	id := created.Product.ID
+
+	require.NoError(t, service.DeleteProduct(ctx, id, 0))
+
+	// Trashed products are hidden from reads and listings
+	_, err = service.GetProduct(ctx, id)
+	assert.Equal(t, http.StatusNotFound, common.GetHTTPStatus(err))
+	live, err := service.GetAllProducts(ctx, &GetProductsRequest{})
+	require.NoError(t, err)
+	assert.Zero(t, live.Total)
+
+	trash, err := service.GetTrashedProducts(ctx, &GetProductsRequest{})
+	require.NoError(t, err)
+	require.Len(t, trash.Products, 1)
+	assert.NotNil(t, trash.Products[0].DeletedAt)
+
+	restored, err := service.RestoreProduct(ctx, id)
+	require.NoError(t, err)
+	assert.Nil(t, restored.Product.DeletedAt)
+	assert.Equal(t, int64(3), restored.Product.Version)
+
+	// Only trashed products can be restored or purged
+	_, err = service.RestoreProduct(ctx, id)
+	assert.Equal(t, http.StatusNotFound, common.GetHTTPStatus(err))
+	assert.Equal(t, http.StatusNotFound, common.GetHTTPStatus(service.PurgeProduct(ctx, id)))
+
+	require.NoError(t, service.DeleteProduct(ctx, id, 0))
+	purged, err := service.PurgeTrash(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	purged, err = service.PurgeTrash(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	history, err := service.GetProductHistory(ctx, id, &GetProductHistoryRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, audit.ActionPurge, history.Events[0].Action)
+
+	_, err = service.RestoreProduct(ctx, id)
+	assert.Equal(t, http.StatusNotFound, common.GetHTTPStatus(err))
+}
+
+func TestProductService_PurgeRemovesVariantsAndReservations(t *testing.T) {
+	ctx := context.Background()
+	products := NewProductRepository()
+	variants := NewVariantRepository()
+	reservations := NewReservationRepository()
+	txManager := database.NewNoopTransactionManager()
+	auditStore := audit.NewMemoryStore()
+	service := NewProductService(products, variants, reservations, anyCategory{}, txManager, auditStore)
+	variantService := NewVariantService(products, variants, txManager, auditStore)
+	stockService := NewStockService(products, variants, reservations, txManager, auditStore, time.Minute)
+	seedProducts(t, products,
+		&Product{ID: "shirt", Name: "Shirt", Price: usd("20"), CategoryID: "x", Stock: 2},
+		&Product{ID: "polo", Name: "Polo", Price: usd("25"), CategoryID: "x"},
+	)
+
+	_, err := variantService.CreateVariant(ctx, "shirt", &CreateVariantRequest{SKU: "SHIRT-M", Stock: 1})
+	require.NoError(t, err)
+	reserved, err := stockService.ReserveStock(ctx, "shirt", &ReserveStockRequest{Quantity: 1})
+	require.NoError(t, err)
+
+	require.NoError(t, service.DeleteProduct(ctx, "shirt", 0))
+	require.NoError(t, service.PurgeProduct(ctx, "shirt"))
+
+	remaining, err := variants.GetByProducts(ctx, []string{"shirt"})
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+	_, err = reservations.GetByID(ctx, reserved.Reservation.ID)
+	assert.True(t, errors.Is(err, ErrReservationNotFound))
+
+	// The purged variant's SKU is free again
+	_, err = variantService.CreateVariant(ctx, "polo", &CreateVariantRequest{SKU: "SHIRT-M"})
+	require.NoError(t, err)
+}
+
+func TestProductService_PatchProduct(t *testing.T) {
+	ctx := context.Background()
+	service := NewProductService(NewProductRepository(), NewVariantRepository(), NewReservationRepository(), categorySet{"kitchen": true}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+
+	created, err := service.CreateProduct(ctx, &CreateProductRequest{Name: "Mug", Description: "Ceramic", Price: usd("5"), CategoryID: "kitchen", Stock: 4})
+	require.NoError(t, err)
+	id := created.Product.ID
+
+	// A merge patch null clears a field
+	patched, err := service.PatchProduct(ctx, id, "application/merge-patch+json", []byte(`{"description":null,"stock":2}`), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "", patched.Product.Description)
+	assert.Equal(t, 2, patched.Product.Stock)
+	assert.Equal(t, "Mug", patched.Product.Name)
+	assert.Equal(t, int64(2), patched.Product.Version)
+
+	patched, err = service.PatchProduct(ctx, id, "application/json-patch+json", []byte(`[
+		{"op":"test","path":"/name","value":"Mug"},
+		{"op":"replace","path":"/price/amount","value":"7.50"}
+	]`), 0)
+	require.NoError(t, err)
+	assert.Equal(t, usd("7.50"), patched.Product.Price)
+
+	tests := []struct {
+		name        string
+		contentType string
+		document    string
+		status      int
+	}{
+		{name: "failed test", contentType: "application/json-patch+json", document: `[{"op":"test","path":"/name","value":"Cup"}]`, status: http.StatusConflict},
+		{name: "malformed patch", contentType: "application/json-patch+json", document: `[{"op":"remove","path":"/missing"}]`, status: http.StatusBadRequest},
+		{name: "cleared required field", contentType: "application/merge-patch+json", document: `{"name":null}`, status: http.StatusBadRequest},
+		{name: "create rules", contentType: "application/merge-patch+json", document: `{"price":0}`, status: http.StatusBadRequest},
+		{name: "read-only field", contentType: "application/merge-patch+json", document: `{"version":9}`, status: http.StatusBadRequest},
+		{name: "unknown category", contentType: "application/merge-patch+json", document: `{"category_id":"garden"}`, status: http.StatusBadRequest},
+		{name: "plain JSON", contentType: "application/json", document: `{"stock":1}`, status: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.PatchProduct(ctx, id, tt.contentType, []byte(tt.document), 0)
+			assert.Equal(t, tt.status, common.GetHTTPStatus(err))
+		})
+	}
+
+	// Rejected patches leave the product untouched
+	current, err := service.GetProduct(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), current.Product.Version)
+
+	_, err = service.PatchProduct(ctx, id, "application/merge-patch+json", []byte(`{"stock":1}`), 1)
+	assert.Equal(t, http.StatusPreconditionFailed, common.GetHTTPStatus(err))
+}
+
+func TestProductService_SearchProducts(t *testing.T) {
+	ctx := context.Background()
+	repo := NewProductRepository()
+	service := NewProductService(repo, NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+	seedProducts(t, repo,
+		&Product{ID: "a", Name: "Red Mug", Description: "Ceramic mug for coffee", Price: usd("12"), CategoryID: "kitchen"},
+		&Product{ID: "b", Name: "Desk Lamp", Description: "Warm red light", Price: usd("40"), CategoryID: "office"},
+		&Product{ID: "c", Name: "Blue Plate", Description: "Ceramic", Price: usd("8"), CategoryID: "kitchen"},
+	)
+
+	response, err := service.SearchProducts(ctx, &SearchProductsRequest{Q: "red"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), response.Total)
+	require.Len(t, response.Results, 2)
+	assert.Equal(t, "a", response.Results[0].Product.ID)
+	assert.Equal(t, "<mark>Red</mark> Mug", response.Results[0].Highlights.Name)
+	assert.Equal(t, "Warm <mark>red</mark> light", response.Results[1].Highlights.Description)
+
+	// Stemming matches plurals, and every word must match
+	response, err = service.SearchProducts(ctx, &SearchProductsRequest{Q: "ceramic mugs"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, searchHitIDs(response.Results))
+
+	response, err = service.SearchProducts(ctx, &SearchProductsRequest{Q: "ceramic", CategoryID: "kitchen", Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), response.Total)
+	assert.Len(t, response.Results, 1)
+
+	// Trashed products are not found
+	require.NoError(t, service.DeleteProduct(ctx, "a", 0))
+	response, err = service.SearchProducts(ctx, &SearchProductsRequest{Q: "mug"})
+	require.NoError(t, err)
+	assert.Empty(t, response.Results)
+
+	_, err = service.SearchProducts(ctx, &SearchProductsRequest{Q: "the"})
+	assert.Equal(t, http.StatusBadRequest, common.GetHTTPStatus(err))
+}
+
+func TestProductService_SuggestProducts(t *testing.T) {
+	ctx := context.Background()
+	repo := NewProductRepository()
+	service := NewProductService(repo, NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+	seedProducts(t, repo,
+		&Product{Name: "Lamp Shade", Price: usd("9"), CategoryID: "office"},
+		&Product{Name: "Desk Lamp", Price: usd("40"), CategoryID: "office"},
+		&Product{Name: "Desk Lamp", Price: usd("45"), CategoryID: "office"},
+		&Product{Name: "Clamp", Price: usd("3"), CategoryID: "office"},
+	)
+
+	response, err := service.SuggestProducts(ctx, &SuggestProductsRequest{Q: "LA"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Lamp Shade", "Desk Lamp"}, response.Suggestions)
+
+	response, err = service.SuggestProducts(ctx, &SuggestProductsRequest{Q: "la", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Lamp Shade"}, response.Suggestions)
+}
+
+func searchHitIDs(hits []*SearchHit) []string {
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.Product.ID
+	}
+	return ids
+}
+
+func TestProductService_RecordsHistory(t *testing.T) {
+	ctx := audit.ContextWithClaimedActor(context.Background(), "support@example.com")
+	service := NewProductService(NewProductRepository(), NewVariantRepository(), NewReservationRepository(), anyCategory{}, database.NewNoopTransactionManager(), audit.NewMemoryStore())
+
+	created, err := service.CreateProduct(ctx, &CreateProductRequest{Name: "Mug", Price: usd("5"), CategoryID: "kitchen", Stock: 1})
+	require.NoError(t, err)
+	id := created.Product.ID
+
+	price := usd("7.5")
+	_, err = service.UpdateProduct(ctx, id, &UpdateProductRequest{Price: &price}, 0)
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteProduct(ctx, id, 0))
+
+	history, err := service.GetProductHistory(ctx, id, &GetProductHistoryRequest{})
+	require.NoError(t, err)
+	require.Len(t, history.Events, 3)
+
+	update := history.Events[1]
+	assert.Equal(t, audit.ActionUpdate, update.Action)
+	assert.Equal(t, audit.AnonymousActor, update.Actor)
+	assert.Equal(t, "support@example.com", update.ClaimedActor)
+	assert.Equal(t, audit.Change{
+		From: map[string]interface{}{"amount": "5.00", "currency": "USD"},
+		To:   map[string]interface{}{"amount": "7.50", "currency": "USD"},
+	}, update.Changes["price"])
+	assert.NotContains(t, update.Changes, "updated_at")
+	assert.NotContains(t, update.Changes, "version")
+	assert.Equal(t, audit.ActionDelete, history.Events[0].Action)
+	assert.Equal(t, audit.ActionCreate, history.Events[2].Action)
+
+	_, err = service.GetProductHistory(ctx, "missing", &GetProductHistoryRequest{})
+	assert.Equal(t, http.StatusNotFound, common.GetHTTPStatus(err))
+}
+
+func TestVariantService_Variants(t *testing.T) {
+	ctx := context.Background()
+	products := NewProductRepository()
+	service := NewVariantService(products, NewVariantRepository(), database.NewNoopTransactionManager(), audit.NewMemoryStore())
+	seedProducts(t, products,
+		&Product{ID: "shirt", Name: "Shirt", Price: usd("20"), CategoryID: "x"},
+		&Product{ID: "mug", Name: "Mug", Price: usd("5"), CategoryID: "x"},
+	)
+
+	price := usd("22.50")
+	created, err := service.CreateVariant(ctx, "shirt", &CreateVariantRequest{
+		SKU: "SHIRT-RED-M",
+		Attributes: VariantAttributes{
+			{Name: "color", Type: AttributeTypeString, Value: "red"},
+			{Name: "chest_cm", Type: AttributeTypeNumber, Value: "96.5"},
+		},
+		Price: &price,
+		Stock: 4,
+	})
+	require.NoError(t, err)
+	_, err = service.CreateVariant(ctx, "shirt", &CreateVariantRequest{SKU: "SHIRT-BLUE-M", Stock: 2})
+	require.NoError(t, err)
+
+	_, err = service.CreateVariant(ctx, "mug", &CreateVariantRequest{SKU: "SHIRT-RED-M"})
+	assert.Equal(t, http.StatusConflict, common.GetHTTPStatus(err))
+	_, err = service.CreateVariant(ctx, "mug", &CreateVariantRequest{
+		SKU:        "MUG-XL",
+		Attributes: VariantAttributes{{Name: "volume", Type: AttributeTypeNumber, Value: "large"}},
+	})
+	assert.Equal(t, http.StatusBadRequest, common.GetHTTPStatus(err))
+	_, err = service.CreateVariant(ctx, "missing", &CreateVariantRequest{SKU: "NONE"})
+	assert.Equal(t, http.StatusNotFound, common.GetHTTPStatus(err))
+
+	listed, err := service.GetVariants(ctx, "shirt")
+	require.NoError(t, err)
+	require.Len(t, listed.Variants, 2)
+	assert.Equal(t, "SHIRT-BLUE-M", listed.Variants[0].SKU)
+	assert.Equal(t, "22.50", listed.Variants[1].Price.Decimal())
+
+	_, err = service.GetVariant(ctx, "mug", created.Variant.ID)
+	assert.Equal(t, http.StatusNotFound, common.GetHTTPStatus(err))
+
+	stock := 0
+	updated, err := service.UpdateVariant(ctx, "shirt", created.Variant.ID, &UpdateVariantRequest{Stock: &stock}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, updated.Variant.Stock)
+	assert.Equal(t, int64(2), updated.Variant.Version)
+	assert.Len(t, updated.Variant.Attributes, 2)
+
+	_, err = service.UpdateVariant(ctx, "shirt", created.Variant.ID, &UpdateVariantRequest{Stock: &stock}, 1)
+	assert.Equal(t, http.StatusPreconditionFailed, common.GetHTTPStatus(err))
+	_, err = service.UpdateVariant(ctx, "shirt", created.Variant.ID, &UpdateVariantRequest{Price: &price, ClearPrice: true}, 0)
+	assert.Equal(t, http.StatusBadRequest, common.GetHTTPStatus(err))
+
+	cleared, err := service.UpdateVariant(ctx, "shirt", created.Variant.ID, &UpdateVariantRequest{ClearPrice: true}, 0)
+	require.NoError(t, err)
+	assert.Nil(t, cleared.Variant.Price)
+
+	err = service.DeleteVariant(ctx, "shirt", created.Variant.ID, 2)
+	assert.Equal(t, http.StatusPreconditionFailed, common.GetHTTPStatus(err))
+	require.NoError(t, service.DeleteVariant(ctx, "shirt", created.Variant.ID, 3))
+	_, err = service.GetVariant(ctx, "shirt", created.Variant.ID)
+	assert.Equal(t, http.StatusNotFound, common.GetHTTPStatus(err))
+}
+
+func TestStockService_Reservations(t *testing.T) {
+	ctx := context.Background()
+	repo := NewProductRepository()
+	service := NewStockService(repo, NewVariantRepository(), NewReservationRepository(), database.NewNoopTransactionManager(), audit.NewMemoryStore(), time.Minute)
+	seedProducts(t, repo, &Product{ID: "p1", Name: "Mug", Price: usd("5"), CategoryID: "x", Stock: 3})
+
+	stock := func() int {
+		product, err := repo.GetByID(ctx, "p1")
+		require.NoError(t, err)
+		return product.Stock
+	}
+
+	first, err := service.ReserveStock(ctx, "p1", &ReserveStockRequest{Quantity: 2})
+	require.NoError(t, err)
+	assert.Equal(t, ReservationActive, first.Reservation.Status)
+	assert.Equal(t, 1, stock())
+
+	_, err = service.ReserveStock(ctx, "p1", &ReserveStockRequest{Quantity: 2})
+	assert.True(t, errors.Is(err, ErrInsufficientStock))
+	assert.Equal(t, http.StatusConflict, common.GetHTTPStatus(err))
+
+	released, err := service.ReleaseStock(ctx, "p1", first.Reservation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ReservationReleased, released.Reservation.Status)
+	assert.Equal(t, 3, stock())
+
+	_, err = service.ReleaseStock(ctx, "p1", first.Reservation.ID)
+	assert.True(t, errors.Is(err, ErrReservationNotActive))
+
+	second, err := service.ReserveStock(ctx, "p1", &ReserveStockRequest{Quantity: 3})
+	require.NoError(t, err)
+	_, err = service.CommitStock(ctx, "other", second.Reservation.ID)
+	assert.Equal(t, http.StatusNotFound, common.GetHTTPStatus(err))
+	committed, err := service.CommitStock(ctx, "p1", second.Reservation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ReservationCommitted, committed.Reservation.Status)
+	assert.Equal(t, 0, stock())
+}
+
+func TestStockService_VariantReservations(t *testing.T) {
+	ctx := context.Background()
+	products := NewProductRepository()
+	variants := NewVariantRepository()
+	auditStore := audit.NewMemoryStore()
+	txManager := database.NewNoopTransactionManager()
+	service := NewStockService(products, variants, NewReservationRepository(), txManager, auditStore, time.Minute)
+	seedProducts(t, products,
+		&Product{ID: "shirt", Name: "Shirt", Price: usd("20"), CategoryID: "x", Stock: 7},
+		&Product{ID: "mug", Name: "Mug", Price: usd("5"), CategoryID: "x"},
+	)
+	variantService := NewVariantService(products, variants, txManager, auditStore)
+	created, err := variantService.CreateVariant(ctx, "shirt", &CreateVariantRequest{SKU: "SHIRT-RED-M", Stock: 2})
+	require.NoError(t, err)
+	variantID := created.Variant.ID
+
+	stock := func() int {
+		variant, err := variants.GetByID(ctx, variantID)
+		require.NoError(t, err)
+		return variant.Stock
+	}
+
+	reserved, err := service.ReserveStock(ctx, "shirt", &ReserveStockRequest{VariantID: variantID, Quantity: 2})
+	require.NoError(t, err)
+	assert.Equal(t, variantID, reserved.Reservation.VariantID)
+	assert.Equal(t, 0, stock())
+
+	_, err = service.ReserveStock(ctx, "shirt", &ReserveStockRequest{VariantID: variantID, Quantity: 1})
+	assert.True(t, errors.Is(err, ErrInsufficientStock))
+	_, err = service.ReserveStock(ctx, "mug", &ReserveStockRequest{VariantID: variantID, Quantity: 1})
+	assert.Equal(t, http.StatusNotFound, common.GetHTTPStatus(err))
+
+	_, err = service.ReleaseStock(ctx, "shirt", reserved.Reservation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stock())
+
+	product, err := products.GetByID(ctx, "shirt")
+	require.NoError(t, err)
+	assert.Equal(t, 7, product.Stock)
+
+	history, err := variantService.GetVariantHistory(ctx, "shirt", variantID, &GetProductHistoryRequest{})
+	require.NoError(t, err)
+	require.Len(t, history.Events, 3)
+	assert.Equal(t, audit.Change{From: float64(0), To: float64(2)}, history.Events[0].Changes["stock"])
+	assert.Equal(t, audit.ActionCreate, history.Events[2].Action)
+}
+
+func TestStockService_ExpiresReservations(t *testing.T) {
+	ctx := context.Background()
+	repo := NewProductRepository()
+	service := NewStockService(repo, NewVariantRepository(), NewReservationRepository(), database.NewNoopTransactionManager(), audit.NewMemoryStore(), time.Minute)
+	seedProducts(t, repo, &Product{ID: "p1", Name: "Mug", Price: usd("5"), CategoryID: "x", Stock: 5})
+
+	short, err := service.ReserveStock(ctx, "p1", &ReserveStockRequest{Quantity: 2, TTLSeconds: 1})
+	require.NoError(t, err)
+	_, err = service.ReserveStock(ctx, "p1", &ReserveStockRequest{Quantity: 1})
+	require.NoError(t, err)
+
+	expired, err := service.ExpireReservations(ctx, time.Now().Add(2*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	product, err := repo.GetByID(ctx, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, product.Stock)
+
+	_, err = service.CommitStock(ctx, "p1", short.Reservation.ID)
+	assert.True(t, errors.Is(err, ErrReservationNotActive))
+}
+
+func TestStockService_DoesNotOversell(t *testing.T) {
+	ctx := context.Background()
+	repo := NewProductRepository()
+	service := NewStockService(repo, NewVariantRepository(), NewReservationRepository(), database.NewNoopTransactionManager(), audit.NewMemoryStore(), time.Minute)
+	seedProducts(t, repo, &Product{ID: "p1", Name: "Mug", Price: usd("5"), CategoryID: "x", Stock: 10})
+
+	var wg sync.WaitGroup
+	var reserved atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.ReserveStock(ctx, "p1", &ReserveStockRequest{Quantity: 1}); err == nil {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	product, err := repo.GetByID(ctx, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(10), reserved.Load())
+	assert.Equal(t, 0, product.Stock)
+}
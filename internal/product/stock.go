@@ -0,0 +1,253 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gin-service/pkg/audit"
+	"gin-service/pkg/common"
+	"gin-service/pkg/database"
+)
+
+// expireBatchSize caps the reservations expired per repository round trip
+const expireBatchSize = 100
+
+// stockService implements StockService
+type stockService struct {
+	products     ProductRepository
+	variants     VariantRepository
+	reservations ReservationRepository
+	txManager    database.TransactionManager
+	auditStore   audit.Store
+	ttl          time.Duration
+}
+
+// NewStockService creates a stock service whose reservations expire after
+// ttl unless a request asks for another TTL. Each stock change and its
+// reservation are written in a single transaction.
+func NewStockService(products ProductRepository, variants VariantRepository, reservations ReservationRepository, txManager database.TransactionManager, auditStore audit.Store, ttl time.Duration) StockService {
+	return &stockService{
+		products:     products,
+		variants:     variants,
+		reservations: reservations,
+		txManager:    txManager,
+		auditStore:   auditStore,
+		ttl:          ttl,
+	}
+}
+
+// ReserveStock takes quantity out of a product's stock, or out of the stock
+// of one of its variants when the request names one, and holds it in a new
+// reservation
+func (s *stockService) ReserveStock(ctx context.Context, productID string, req *ReserveStockRequest) (*ReservationResponse, error) {
+	if productID == "" {
+		return nil, common.NewValidationError("product ID is required")
+	}
+	if req.Quantity <= 0 {
+		return nil, common.NewValidationError("quantity must be greater than zero")
+	}
+
+	ttl := s.ttl
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+
+	reservation := &Reservation{
+		ProductID: productID,
+		VariantID: req.VariantID,
+		Quantity:  req.Quantity,
+		Status:    ReservationActive,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	if req.VariantID != "" {
+		if err := s.checkVariant(ctx, productID, req.VariantID); err != nil {
+			return nil, err
+		}
+	}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.adjustReserved(ctx, reservation, -req.Quantity); err != nil {
+			return err
+		}
+		return s.reservations.Create(ctx, reservation)
+	})
+	if err != nil {
+		return nil, repositoryError("failed to reserve stock", err)
+	}
+
+	return &ReservationResponse{
+		Reservation: reservation,
+		Message:     "Stock reserved successfully",
+	}, nil
+}
+
+// ReleaseStock puts the quantity of an active reservation back into stock
+func (s *stockService) ReleaseStock(ctx context.Context, productID, reservationID string) (*ReservationResponse, error) {
+	if _, err := s.reservation(ctx, productID, reservationID); err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.resolve(ctx, reservationID, ReservationReleased)
+	if err != nil {
+		return nil, repositoryError("failed to release reservation", err)
+	}
+
+	return &ReservationResponse{
+		Reservation: reservation,
+		Message:     "Reservation released successfully",
+	}, nil
+}
+
+// CommitStock keeps the quantity of an active reservation out of stock for
+// good. A reservation past its TTL is expired instead.
+func (s *stockService) CommitStock(ctx context.Context, productID, reservationID string) (*ReservationResponse, error) {
+	reservation, err := s.reservation(ctx, productID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !reservation.ExpiresAt.After(time.Now()) {
+		if _, err := s.resolve(ctx, reservationID, ReservationExpired); err != nil {
+			return nil, repositoryError("failed to commit reservation", err)
+		}
+		return nil, repositoryError("failed to commit reservation", ErrReservationExpired)
+	}
+
+	reservation, err = s.resolve(ctx, reservationID, ReservationCommitted)
+	if err != nil {
+		return nil, repositoryError("failed to commit reservation", err)
+	}
+
+	return &ReservationResponse{
+		Reservation: reservation,
+		Message:     "Reservation committed successfully",
+	}, nil
+}
+
+// ExpireReservations returns the stock of every active reservation that
+// expired at or before now and reports how many were expired
+func (s *stockService) ExpireReservations(ctx context.Context, now time.Time) (int, error) {
+	expired := 0
+	for {
+		reservations, err := s.reservations.ListExpired(ctx, now, expireBatchSize)
+		if err != nil {
+			return expired, repositoryError("failed to list expired reservations", err)
+		}
+
+		for _, reservation := range reservations {
+			_, err := s.resolve(ctx, reservation.ID, ReservationExpired)
+			if errors.Is(err, ErrReservationNotActive) {
+				// Released or committed since it was listed
+				continue
+			}
+			if err != nil {
+				return expired, repositoryError("failed to expire reservation", err)
+			}
+			expired++
+		}
+
+		if len(reservations) < expireBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+// reservation retrieves a reservation, treating one of another product as
+// not found
+func (s *stockService) reservation(ctx context.Context, productID, reservationID string) (*Reservation, error) {
+	if productID == "" {
+		return nil, common.NewValidationError("product ID is required")
+	}
+	if reservationID == "" {
+		return nil, common.NewValidationError("reservation ID is required")
+	}
+
+	reservation, err := s.reservations.GetByID(ctx, reservationID)
+	if err == nil && reservation.ProductID != productID {
+		err = fmt.Errorf("%w: %s", ErrReservationNotFound, reservationID)
+	}
+	if err != nil {
+		return nil, repositoryError("failed to get reservation", err)
+	}
+
+	return reservation, nil
+}
+
+// resolve moves an active reservation to status, returning its quantity to
+// stock unless it is committed. Stock of a product or variant that has since
+// been trashed, purged or deleted is not restored.
+func (s *stockService) resolve(ctx context.Context, reservationID, status string) (*Reservation, error) {
+	var reservation *Reservation
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if reservation, err = s.reservations.Resolve(ctx, reservationID, status); err != nil {
+			return err
+		}
+		if status == ReservationCommitted {
+			return nil
+		}
+
+		err = s.adjustReserved(ctx, reservation, reservation.Quantity)
+		if errors.Is(err, ErrProductNotFound) || errors.Is(err, ErrVariantNotFound) {
+			return nil
+		}
+		return err
+	})
+
+	return reservation, err
+}
+
+// checkVariant verifies that a live product has a variant with the given ID
+func (s *stockService) checkVariant(ctx context.Context, productID, variantID string) error {
+	if _, err := s.products.GetByID(ctx, productID); err != nil {
+		return repositoryError("failed to get product", err)
+	}
+
+	variant, err := s.variants.GetByID(ctx, variantID)
+	if err == nil && variant.ProductID != productID {
+		err = fmt.Errorf("%w: %s", ErrVariantNotFound, variantID)
+	}
+	if err != nil {
+		return repositoryError("failed to get variant", err)
+	}
+	return nil
+}
+
+// adjustReserved changes the stock a reservation holds from by delta: its
+// variant's when it has one, its product's otherwise
+func (s *stockService) adjustReserved(ctx context.Context, reservation *Reservation, delta int) error {
+	if reservation.VariantID != "" {
+		return s.adjustVariantStock(ctx, reservation.VariantID, delta)
+	}
+	return s.adjustStock(ctx, reservation.ProductID, delta)
+}
+
+// adjustVariantStock changes a variant's stock by delta and records the
+// change
+func (s *stockService) adjustVariantStock(ctx context.Context, variantID string, delta int) error {
+	variant, err := s.variants.AdjustStock(ctx, variantID, delta)
+	if err != nil {
+		return err
+	}
+
+	before := cloneVariant(variant)
+	before.Stock -= delta
+	before.Version--
+	return recordVariantChange(ctx, s.auditStore, audit.ActionUpdate, variantID, before, variant)
+}
+
+// adjustStock changes a product's stock by delta and records the change
+func (s *stockService) adjustStock(ctx context.Context, productID string, delta int) error {
+	product, err := s.products.AdjustStock(ctx, productID, delta)
+	if err != nil {
+		return err
+	}
+
+	before := cloneProduct(product)
+	before.Stock -= delta
+	before.Version--
+	return recordProductChange(ctx, s.auditStore, audit.ActionUpdate, productID, before, product)
+}
@@ -0,0 +1,128 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"gin-service/pkg/common"
+	"gin-service/pkg/constants"
+	"gin-service/pkg/validation"
+)
+
+// exportPageSize is the number of products read per page while exporting
+const exportPageSize = 500
+
+// ExportProducts writes every product to encoder in ID order. Products are
+// read a page at a time with keyset pagination, so memory use does not grow
+// with the catalog.
+func (s *productService) ExportProducts(ctx context.Context, encoder ProductEncoder) error {
+	query := ProductQuery{
+		SortField: "id",
+		SortOrder: constants.SortOrderAsc,
+		Limit:     exportPageSize,
+	}
+
+	products, err := s.repository.GetAll(ctx, query)
+	for {
+		if err != nil {
+			return repositoryError("failed to export products", err)
+		}
+
+		for _, product := range products {
+			if err := encoder.Encode(product); err != nil {
+				return err
+			}
+		}
+
+		if len(products) < exportPageSize {
+			break
+		}
+
+		cursor := NewProductCursor(products[len(products)-1], query.SortField, query.SortOrder)
+		products, err = s.repository.GetAllAfter(ctx, query, cursor)
+	}
+
+	return encoder.Flush()
+}
+
+// ImportProducts creates a product for every row decoder yields. Rows are
+// validated like create requests and written in best-effort batches of
+// constants.MaxBatchSize, so one bad row only rejects itself.
+func (s *productService) ImportProducts(ctx context.Context, format string, decoder ProductDecoder) (*ImportSummary, error) {
+	summary := &ImportSummary{
+		Format: format,
+		Errors: make([]ImportRowError, 0),
+	}
+
+	var lines []int
+	var operations []BatchOperation
+
+	flush := func() error {
+		if len(operations) == 0 {
+			return nil
+		}
+
+		response, err := s.BatchProducts(ctx, &BatchProductsRequest{
+			Mode:       BatchModeBestEffort,
+			Operations: operations,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, result := range response.Results {
+			if result.Error != nil {
+				summary.reject(lines[result.Index], result.Error)
+				continue
+			}
+			summary.Imported++
+		}
+
+		lines, operations = lines[:0], operations[:0]
+		return nil
+	}
+
+	for {
+		line, req, err := decoder.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if appErr := common.GetAppError(err); appErr != nil && line > 0 {
+				summary.Total++
+				summary.reject(line, appErr)
+				continue
+			}
+			return nil, err
+		}
+
+		summary.Total++
+		if appErr := validation.Validate(req); appErr != nil {
+			summary.reject(line, appErr)
+			continue
+		}
+
+		lines = append(lines, line)
+		operations = append(operations, BatchOperation{Op: BatchOpCreate, Product: req})
+		if len(operations) == constants.MaxBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// reject records a row that was not imported
+func (s *ImportSummary) reject(line int, err *common.AppError) {
+	s.Failed++
+	if len(s.Errors) < MaxImportErrors {
+		s.Errors = append(s.Errors, ImportRowError{Line: line, Error: err})
+	}
+}
@@ -0,0 +1,279 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gin-service/pkg/audit"
+	"gin-service/pkg/common"
+	"gin-service/pkg/database"
+)
+
+// variantEntity is the entity type variants are audited under
+const variantEntity = "variant"
+
+// variantService implements VariantService
+type variantService struct {
+	products   ProductRepository
+	variants   VariantRepository
+	txManager  database.TransactionManager
+	auditStore audit.Store
+}
+
+// NewVariantService creates a variant service. Variants can only be managed
+// while their product is live. Each change and its audit event are written
+// in a single transaction.
+func NewVariantService(products ProductRepository, variants VariantRepository, txManager database.TransactionManager, auditStore audit.Store) VariantService {
+	return &variantService{
+		products:   products,
+		variants:   variants,
+		txManager:  txManager,
+		auditStore: auditStore,
+	}
+}
+
+// CreateVariant adds a variant to a product
+func (s *variantService) CreateVariant(ctx context.Context, productID string, req *CreateVariantRequest) (*VariantResponse, error) {
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	variant := &Variant{
+		ProductID:  productID,
+		SKU:        strings.TrimSpace(req.SKU),
+		Attributes: req.Attributes,
+		Price:      req.Price,
+		Stock:      req.Stock,
+	}
+	if variant.Attributes == nil {
+		variant.Attributes = VariantAttributes{}
+	}
+	if err := validateVariant(variant); err != nil {
+		return nil, err
+	}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.variants.Create(ctx, variant); err != nil {
+			return err
+		}
+		return recordVariantChange(ctx, s.auditStore, audit.ActionCreate, variant.ID, nil, variant)
+	})
+	if err != nil {
+		return nil, repositoryError("failed to create variant", err)
+	}
+
+	return &VariantResponse{
+		Variant: variant,
+		Message: "Variant created successfully",
+	}, nil
+}
+
+// GetVariant retrieves one variant of a product
+func (s *variantService) GetVariant(ctx context.Context, productID, variantID string) (*VariantResponse, error) {
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	variant, err := s.variant(ctx, productID, variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VariantResponse{
+		Variant: variant,
+	}, nil
+}
+
+// GetVariants lists the variants of a product
+func (s *variantService) GetVariants(ctx context.Context, productID string) (*GetVariantsResponse, error) {
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	variants, err := s.variants.GetByProduct(ctx, productID)
+	if err != nil {
+		return nil, repositoryError("failed to get variants", err)
+	}
+
+	return &GetVariantsResponse{
+		Variants: variants,
+		Total:    len(variants),
+	}, nil
+}
+
+// UpdateVariant changes the fields of a variant that the request sets. A
+// non-zero expectedVersion must match the variant's current version.
+func (s *variantService) UpdateVariant(ctx context.Context, productID, variantID string, req *UpdateVariantRequest, expectedVersion int64) (*VariantResponse, error) {
+	if req.Price != nil && req.ClearPrice {
+		return nil, common.NewValidationError("price and clear_price cannot be combined")
+	}
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	variant, err := s.variant(ctx, productID, variantID)
+	if err != nil {
+		return nil, err
+	}
+	if expectedVersion != 0 && variant.Version != expectedVersion {
+		return nil, repositoryError("failed to update variant", ErrVariantVersionConflict)
+	}
+
+	before := cloneVariant(variant)
+	if req.SKU != nil {
+		variant.SKU = strings.TrimSpace(*req.SKU)
+	}
+	if req.Attributes != nil {
+		variant.Attributes = req.Attributes
+	}
+	if req.Price != nil {
+		variant.Price = req.Price
+	}
+	if req.ClearPrice {
+		variant.Price = nil
+	}
+	if req.Stock != nil {
+		variant.Stock = *req.Stock
+	}
+	if err := validateVariant(variant); err != nil {
+		return nil, err
+	}
+
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.variants.Update(ctx, variant); err != nil {
+			return err
+		}
+		return recordVariantChange(ctx, s.auditStore, audit.ActionUpdate, variant.ID, before, variant)
+	})
+	if err != nil {
+		return nil, repositoryError("failed to update variant", err)
+	}
+
+	return &VariantResponse{
+		Variant: variant,
+		Message: "Variant updated successfully",
+	}, nil
+}
+
+// DeleteVariant removes a variant from a product. A non-zero
+// expectedVersion must match the variant's current version.
+func (s *variantService) DeleteVariant(ctx context.Context, productID, variantID string, expectedVersion int64) error {
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return err
+	}
+
+	variant, err := s.variant(ctx, productID, variantID)
+	if err != nil {
+		return err
+	}
+
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.variants.Delete(ctx, variantID, expectedVersion); err != nil {
+			return err
+		}
+		return recordVariantChange(ctx, s.auditStore, audit.ActionDelete, variantID, variant, nil)
+	})
+	if err != nil {
+		return repositoryError("failed to delete variant", err)
+	}
+
+	return nil
+}
+
+// GetVariantHistory lists the audit events of a variant, newest first
+func (s *variantService) GetVariantHistory(ctx context.Context, productID, variantID string, req *GetProductHistoryRequest) (*ProductHistoryResponse, error) {
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	if _, err := s.variant(ctx, productID, variantID); err != nil {
+		return nil, err
+	}
+
+	response, err := listHistory(ctx, s.auditStore, variantEntity, variantID, req)
+	if err != nil {
+		return nil, common.NewInternalErrorWithErr("failed to get variant history", err)
+	}
+
+	return response, nil
+}
+
+// recordVariantChange stores an audit event for a variant change. Changes
+// are the fields that differ between before and after, apart from the
+// timestamp and version bookkeeping; either may be nil.
+func recordVariantChange(ctx context.Context, store audit.Store, action, id string, before, after *Variant) error {
+	return recordChange(ctx, store, variantEntity, action, id, before, after)
+}
+
+// checkProduct verifies that a live product has the given ID
+func (s *variantService) checkProduct(ctx context.Context, productID string) error {
+	if productID == "" {
+		return common.NewValidationError("product ID is required")
+	}
+
+	if _, err := s.products.GetByID(ctx, productID); err != nil {
+		return repositoryError("failed to get product", err)
+	}
+	return nil
+}
+
+// variant retrieves a variant, treating one of another product as not found
+func (s *variantService) variant(ctx context.Context, productID, variantID string) (*Variant, error) {
+	if variantID == "" {
+		return nil, common.NewValidationError("variant ID is required")
+	}
+
+	variant, err := s.variants.GetByID(ctx, variantID)
+	if err == nil && variant.ProductID != productID {
+		err = fmt.Errorf("%w: %s", ErrVariantNotFound, variantID)
+	}
+	if err != nil {
+		return nil, repositoryError("failed to get variant", err)
+	}
+
+	return variant, nil
+}
+
+// validateVariant checks a variant against business rules: a positive price
+// override, non-negative stock and attributes with unique names whose values
+// parse as their type
+func validateVariant(variant *Variant) error {
+	if variant.SKU == "" {
+		return common.NewValidationError("SKU is required")
+	}
+	if variant.Price != nil && !variant.Price.IsPositive() {
+		return common.NewValidationError("price must be greater than zero")
+	}
+	if variant.Stock < 0 {
+		return common.NewValidationError("stock cannot be negative")
+	}
+
+	seen := make(map[string]bool, len(variant.Attributes))
+	for i, attribute := range variant.Attributes {
+		name := strings.ToLower(strings.TrimSpace(attribute.Name))
+		if name == "" {
+			return common.NewValidationError(fmt.Sprintf("attribute %d has no name", i))
+		}
+		if seen[name] {
+			return common.NewValidationError(fmt.Sprintf("attribute %s appears more than once", attribute.Name))
+		}
+		seen[name] = true
+
+		var err error
+		switch attribute.Type {
+		case AttributeTypeString:
+		case AttributeTypeNumber:
+			_, err = strconv.ParseFloat(attribute.Value, 64)
+		case AttributeTypeBoolean:
+			_, err = strconv.ParseBool(attribute.Value)
+		default:
+			return common.NewValidationError(fmt.Sprintf("attribute %s has unknown type: %s", attribute.Name, attribute.Type))
+		}
+		if err != nil {
+			return common.NewValidationError(fmt.Sprintf("attribute %s must be a %s", attribute.Name, attribute.Type))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// variantRepository implements VariantRepository in memory
+type variantRepository struct {
+	variants map[string]*Variant
+	mutex    sync.RWMutex
+}
+
+// NewVariantRepository creates a new in-memory variant repository
+func NewVariantRepository() VariantRepository {
+	return &variantRepository{
+		variants: make(map[string]*Variant),
+	}
+}
+
+// Create stores a new variant
+func (r *variantRepository) Create(ctx context.Context, variant *Variant) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.skuTaken(variant.SKU, "") {
+		return fmt.Errorf("%w: %s", ErrSKUTaken, variant.SKU)
+	}
+
+	if variant.ID == "" {
+		variant.ID = uuid.New().String()
+	}
+
+	now := time.Now()
+	variant.CreatedAt = now
+	variant.UpdatedAt = now
+	variant.Version = 1
+
+	r.variants[variant.ID] = cloneVariant(variant)
+	return nil
+}
+
+// GetByID retrieves a variant by ID
+func (r *variantRepository) GetByID(ctx context.Context, id string) (*Variant, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	variant, exists := r.variants[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrVariantNotFound, id)
+	}
+
+	return cloneVariant(variant), nil
+}
+
+// GetByProduct lists a product's variants ordered by SKU
+func (r *variantRepository) GetByProduct(ctx context.Context, productID string) ([]*Variant, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	variants := make([]*Variant, 0)
+	for _, variant := range r.variants {
+		if variant.ProductID == productID {
+			variants = append(variants, cloneVariant(variant))
+		}
+	}
+
+	sortVariants(variants)
+	return variants, nil
+}
+
+// GetByProducts lists the variants of several products ordered by SKU
+func (r *variantRepository) GetByProducts(ctx context.Context, productIDs []string) ([]*Variant, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	wanted := make(map[string]bool, len(productIDs))
+	for _, productID := range productIDs {
+		wanted[productID] = true
+	}
+
+	variants := make([]*Variant, 0)
+	for _, variant := range r.variants {
+		if wanted[variant.ProductID] {
+			variants = append(variants, cloneVariant(variant))
+		}
+	}
+
+	sortVariants(variants)
+	return variants, nil
+}
+
+// Update replaces a stored variant if its version is still current
+func (r *variantRepository) Update(ctx context.Context, variant *Variant) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, exists := r.variants[variant.ID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrVariantNotFound, variant.ID)
+	}
+	if stored.Version != variant.Version {
+		return ErrVariantVersionConflict
+	}
+	if r.skuTaken(variant.SKU, variant.ID) {
+		return fmt.Errorf("%w: %s", ErrSKUTaken, variant.SKU)
+	}
+
+	variant.UpdatedAt = time.Now()
+	variant.Version++
+	r.variants[variant.ID] = cloneVariant(variant)
+	return nil
+}
+
+// Delete removes a variant, checking its version when one is expected
+func (r *variantRepository) Delete(ctx context.Context, id string, expectedVersion int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, exists := r.variants[id]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrVariantNotFound, id)
+	}
+	if expectedVersion != 0 && stored.Version != expectedVersion {
+		return ErrVariantVersionConflict
+	}
+
+	delete(r.variants, id)
+	return nil
+}
+
+// AdjustStock adds delta to a variant's stock unless it would go below zero
+func (r *variantRepository) AdjustStock(ctx context.Context, id string, delta int) (*Variant, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, exists := r.variants[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrVariantNotFound, id)
+	}
+	if stored.Stock+delta < 0 {
+		return nil, fmt.Errorf("%w: %s has %d", ErrInsufficientStock, id, stored.Stock)
+	}
+
+	adjusted := cloneVariant(stored)
+	adjusted.Stock += delta
+	adjusted.UpdatedAt = time.Now()
+	adjusted.Version++
+	r.variants[id] = adjusted
+
+	return cloneVariant(adjusted), nil
+}
+
+// DeleteByProducts removes every variant of the given products
+func (r *variantRepository) DeleteByProducts(ctx context.Context, productIDs []string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, productID := range productIDs {
+		for id, variant := range r.variants {
+			if variant.ProductID == productID {
+				delete(r.variants, id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// skuTaken reports whether a variant other than exceptID uses sku. The
+// caller must hold the lock.
+func (r *variantRepository) skuTaken(sku, exceptID string) bool {
+	for _, variant := range r.variants {
+		if variant.SKU == sku && variant.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// sortVariants orders variants by SKU
+func sortVariants(variants []*Variant) {
+	sort.Slice(variants, func(i, j int) bool {
+		return variants[i].SKU < variants[j].SKU
+	})
+}
+
+// cloneVariant returns a copy of variant that shares no attributes or price
+func cloneVariant(variant *Variant) *Variant {
+	clone := *variant
+	if variant.Attributes != nil {
+		clone.Attributes = append(VariantAttributes{}, variant.Attributes...)
+	}
+	if variant.Price != nil {
+		price := *variant.Price
+		clone.Price = &price
+	}
+	return &clone
+}
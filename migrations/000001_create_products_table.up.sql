@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS products (
+    id          VARCHAR(36)    PRIMARY KEY,
+    name        VARCHAR(255)   NOT NULL,
+    description TEXT           NOT NULL DEFAULT '',
+    price       NUMERIC(12, 2) NOT NULL CHECK (price > 0),
+    category    VARCHAR(255)   NOT NULL,
+    stock       INTEGER        NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    created_at  TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_products_category ON products (category);
+CREATE INDEX IF NOT EXISTS idx_products_created_at ON products (created_at, id);
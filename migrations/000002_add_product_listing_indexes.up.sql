@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS idx_products_lower_category ON products (LOWER(category));
+CREATE INDEX IF NOT EXISTS idx_products_price ON products (price, id);
+-- Listings sort names by byte order, so the index has to use the same collation
+CREATE INDEX IF NOT EXISTS idx_products_name ON products (name COLLATE "C", id);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    id            VARCHAR(36)  PRIMARY KEY,
+    entity_type   VARCHAR(64)  NOT NULL,
+    entity_id     VARCHAR(64)  NOT NULL,
+    action        VARCHAR(32)  NOT NULL,
+    actor         VARCHAR(255) NOT NULL,
+    -- X-Actor is not authenticated, so it is only kept as a claim next to the actor
+    claimed_actor VARCHAR(255) NOT NULL DEFAULT '',
+    request_id    VARCHAR(64)  NOT NULL DEFAULT '',
+    changes       JSONB        NOT NULL DEFAULT '{}',
+    occurred_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity ON audit_events (entity_type, entity_id, occurred_at DESC);
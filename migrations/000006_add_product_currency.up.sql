@@ -0,0 +1,4 @@
+-- Existing prices were entered without a currency and are taken to be USD.
+-- The wider scale leaves room for currencies with three minor unit digits.
+ALTER TABLE products ALTER COLUMN price TYPE NUMERIC(19, 4);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id         VARCHAR(36) PRIMARY KEY,
+    product_id VARCHAR(36) NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    -- Empty for reservations of a product's own stock. There is no foreign key
+    -- so that deleting a variant leaves its reservations to resolve without stock.
+    variant_id VARCHAR(36) NOT NULL DEFAULT '',
+    quantity   INTEGER     NOT NULL CHECK (quantity > 0),
+    status     VARCHAR(16) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_expiry ON stock_reservations (expires_at) WHERE status = 'active';
@@ -0,0 +1,11 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category VARCHAR(255);
+UPDATE products AS p SET category = c.name FROM categories AS c WHERE c.id = p.category_id;
+ALTER TABLE products ALTER COLUMN category SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_products_category_id;
+ALTER TABLE products DROP COLUMN IF EXISTS category_id;
+
+CREATE INDEX IF NOT EXISTS idx_products_category ON products (category);
+CREATE INDEX IF NOT EXISTS idx_products_lower_category ON products (LOWER(category));
+
+DROP TABLE IF EXISTS categories;
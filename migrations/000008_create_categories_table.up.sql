@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id          VARCHAR(36)  PRIMARY KEY,
+    name        VARCHAR(255) NOT NULL,
+    slug        VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT         NOT NULL DEFAULT '',
+    parent_id   VARCHAR(36)  REFERENCES categories (id) ON DELETE RESTRICT,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
+
+-- Turn every distinct free-form category, compared case-insensitively, into a
+-- top-level category. IDs are derived from the name so products can be matched
+-- to them below; slugs that collide get a numbered suffix.
+INSERT INTO categories (id, name, slug)
+SELECT id, name, CASE WHEN n = 1 THEN base ELSE base || '-' || n END
+FROM (
+    SELECT id, name, base, ROW_NUMBER() OVER (PARTITION BY base ORDER BY id) AS n
+    FROM (
+        SELECT md5(LOWER(category))::uuid::text AS id,
+               MIN(category) AS name,
+               COALESCE(NULLIF(TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(category), '[^a-z0-9]+', '-', 'g')), ''), 'category') AS base
+        FROM products
+        GROUP BY LOWER(category)
+    ) AS distinct_categories
+) AS numbered_categories
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category_id VARCHAR(36) REFERENCES categories (id) ON DELETE RESTRICT;
+UPDATE products SET category_id = md5(LOWER(category))::uuid::text;
+ALTER TABLE products ALTER COLUMN category_id SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_products_lower_category;
+DROP INDEX IF EXISTS idx_products_category;
+ALTER TABLE products DROP COLUMN IF EXISTS category;
+
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS product_variants (
+    id         VARCHAR(36)    PRIMARY KEY,
+    product_id VARCHAR(36)    NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    sku        VARCHAR(64)    NOT NULL UNIQUE,
+    attributes JSONB          NOT NULL DEFAULT '[]',
+    price      NUMERIC(19, 4) CHECK (price > 0),
+    currency   CHAR(3),
+    stock      INTEGER        NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    version    BIGINT         NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    CHECK ((price IS NULL) = (currency IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_variants_product_id ON product_variants (product_id);
@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Audit actions
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+)
+
+// Actors recorded for authenticated callers and for everyone else
+const (
+	AdminActor     = "admin"
+	AnonymousActor = "anonymous"
+)
+
+// Event records a single change to an entity. Actor is the authenticated
+// caller, AnonymousActor when the request was not authenticated;
+// ClaimedActor is the unverified name the caller gave for itself.
+type Event struct {
+	ID           string    `json:"id" db:"id"`
+	EntityType   string    `json:"entity_type" db:"entity_type"`
+	EntityID     string    `json:"entity_id" db:"entity_id"`
+	Action       string    `json:"action" db:"action"`
+	Actor        string    `json:"actor" db:"actor"`
+	ClaimedActor string    `json:"claimed_actor,omitempty" db:"claimed_actor"`
+	RequestID    string    `json:"request_id,omitempty" db:"request_id"`
+	Changes      Changes   `json:"changes,omitempty" db:"changes"`
+	OccurredAt   time.Time `json:"occurred_at" db:"occurred_at"`
+}
+
+// Change holds the value of a field before and after a change
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Changes maps JSON field names to their changes. It is stored as JSON.
+type Changes map[string]Change
+
+// Value implements driver.Valuer
+func (c Changes) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan implements sql.Scanner
+func (c *Changes) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into audit changes", src)
+	}
+
+	return json.Unmarshal(data, c)
+}
+
+// Query selects the events of one entity, newest first
+type Query struct {
+	EntityType string
+	EntityID   string
+	Limit      int
+	Offset     int
+}
+
+// Store persists audit events. Implementations backed by a database record
+// events in the transaction carried by ctx, if any, so an event commits or
+// rolls back with the change it describes.
+type Store interface {
+	Record(ctx context.Context, event *Event) error
+	List(ctx context.Context, query Query) ([]*Event, error)
+}
+
+// Diff compares the JSON forms of before and after and returns the fields
+// that differ, skipping the ignored field names. Either side may be nil.
+func Diff(before, after interface{}, ignore ...string) (Changes, error) {
+	from, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	skip := make(map[string]bool, len(ignore))
+	for _, field := range ignore {
+		skip[field] = true
+	}
+
+	changes := make(Changes)
+	for field, value := range from {
+		if !skip[field] && !reflect.DeepEqual(value, to[field]) {
+			changes[field] = Change{From: value, To: to[field]}
+		}
+	}
+	for field, value := range to {
+		if _, seen := from[field]; !seen && !skip[field] && value != nil {
+			changes[field] = Change{From: nil, To: value}
+		}
+	}
+
+	return changes, nil
+}
+
+// jsonFields decodes the JSON form of v into a field map
+func jsonFields(v interface{}) (map[string]interface{}, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return map[string]interface{}{}, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode audit snapshot: %w", err)
+	}
+
+	return fields, nil
+}
+
+// actorKey is the context key for the actor making a request
+type actorKey struct{}
+
+// ContextWithActor returns a context carrying actor
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by ctx, or AnonymousActor
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return AnonymousActor
+}
+
+// claimedActorKey is the context key for the actor a request claims to
+// come from
+type claimedActorKey struct{}
+
+// ContextWithClaimedActor returns a context carrying an unverified actor.
+// It is recorded next to the authenticated actor and never replaces it.
+func ContextWithClaimedActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, claimedActorKey{}, actor)
+}
+
+// ClaimedActorFromContext returns the claimed actor carried by ctx, or an
+// empty string
+func ClaimedActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(claimedActorKey{}).(string)
+	return actor
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+	Note  *string `json:"note,omitempty"`
+}
+
+func TestDiff(t *testing.T) {
+	changes, err := Diff(&item{Name: "Mug", Price: 5}, &item{Name: "Mug", Price: 7})
+	require.NoError(t, err)
+	assert.Equal(t, Changes{"price": {From: 5.0, To: 7.0}}, changes)
+
+	changes, err = Diff(nil, &item{Name: "Mug", Price: 5})
+	require.NoError(t, err)
+	assert.Equal(t, Changes{"name": {From: nil, To: "Mug"}, "price": {From: nil, To: 5.0}}, changes)
+
+	var missing *item
+	changes, err = Diff(&item{Name: "Mug"}, missing, "price")
+	require.NoError(t, err)
+	assert.Equal(t, Changes{"name": {From: "Mug", To: nil}}, changes)
+}
+
+func TestChangesRoundTrip(t *testing.T) {
+	value, err := Changes{"price": {From: 5.0, To: 7.0}}.Value()
+	require.NoError(t, err)
+
+	var scanned Changes
+	require.NoError(t, scanned.Scan(value))
+	assert.Equal(t, Changes{"price": {From: 5.0, To: 7.0}}, scanned)
+}
+
+func TestMemoryStore_ListsNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	for _, action := range []string{ActionCreate, ActionUpdate, ActionDelete} {
+		require.NoError(t, store.Record(ctx, &Event{EntityType: "product", EntityID: "p1", Action: action}))
+	}
+	require.NoError(t, store.Record(ctx, &Event{EntityType: "product", EntityID: "p2", Action: ActionCreate}))
+
+	events, err := store.List(ctx, Query{EntityType: "product", EntityID: "p1", Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, ActionUpdate, events[0].Action)
+	assert.Equal(t, ActionCreate, events[1].Action)
+	assert.NotEmpty(t, events[0].ID)
+}
+
+func TestActorFromContext(t *testing.T) {
+	assert.Equal(t, AnonymousActor, ActorFromContext(context.Background()))
+	assert.Equal(t, "alice", ActorFromContext(ContextWithActor(context.Background(), "alice")))
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps audit events in memory
+type memoryStore struct {
+	events []*Event
+	mutex  sync.RWMutex
+}
+
+// NewMemoryStore creates an in-memory audit store
+func NewMemoryStore() Store {
+	return &memoryStore{}
+}
+
+// Record appends an event
+func (s *memoryStore) Record(ctx context.Context, event *Event) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored := *event
+	s.events = append(s.events, &stored)
+	return nil
+}
+
+// List returns the entity's events, newest first
+func (s *memoryStore) List(ctx context.Context, query Query) ([]*Event, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	events := make([]*Event, 0)
+	skipped := 0
+	for i := len(s.events) - 1; i >= 0 && len(events) < query.Limit; i-- {
+		event := s.events[i]
+		if event.EntityType != query.EntityType || event.EntityID != query.EntityID {
+			continue
+		}
+		if skipped < query.Offset {
+			skipped++
+			continue
+		}
+		copied := *event
+		events = append(events, &copied)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gin-service/pkg/database/postgresql"
+
+	"github.com/google/uuid"
+)
+
+// eventsTable is the table audit events are persisted in
+const eventsTable = "audit_events"
+
+// postgreSQLStore persists audit events in PostgreSQL
+type postgreSQLStore struct {
+	conn    postgresql.Connection
+	base    postgresql.Repository[Event]
+	columns string
+}
+
+// NewPostgreSQLStore creates a PostgreSQL-backed audit store
+func NewPostgreSQLStore(conn postgresql.Connection) (Store, error) {
+	base, err := postgresql.NewPostgreSQLRepository[Event](conn, eventsTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit store: %w", err)
+	}
+
+	columns, err := postgresql.Columns[Event]()
+	if err != nil {
+		return nil, fmt.Errorf("failed to map audit columns: %w", err)
+	}
+
+	return &postgreSQLStore{
+		conn:    conn,
+		base:    base,
+		columns: strings.Join(columns, ", "),
+	}, nil
+}
+
+// Record inserts an event, joining the transaction carried by ctx
+func (s *postgreSQLStore) Record(ctx context.Context, event *Event) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+
+	if err := s.base.Create(ctx, event); err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the entity's events, newest first
+func (s *postgreSQLStore) List(ctx context.Context, query Query) ([]*Event, error) {
+	statement := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE entity_type = $1 AND entity_id = $2 ORDER BY occurred_at DESC, id DESC LIMIT $3 OFFSET $4",
+		s.columns, eventsTable,
+	)
+
+	rows, err := postgresql.ExecutorFromContext(ctx, s.conn.GetDB()).QueryContext(ctx, statement,
+		query.EntityType, query.EntityID, query.Limit, query.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*Event, 0)
+	for rows.Next() {
+		var event Event
+		if err := postgresql.ScanStruct(rows, &event); err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate audit events: %w", err)
+	}
+
+	return events, nil
+}
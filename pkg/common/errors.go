@@ -1,6 +1,7 @@
 package common
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -10,26 +11,40 @@ type ErrorCode string
 
 const (
 	// Common error codes
-	ErrorCodeValidation     ErrorCode = "VALIDATION_ERROR"
-	ErrorCodeNotFound       ErrorCode = "NOT_FOUND"
-	ErrorCodeUnauthorized   ErrorCode = "UNAUTHORIZED"
-	ErrorCodeForbidden      ErrorCode = "FORBIDDEN"
-	ErrorCodeInternal       ErrorCode = "INTERNAL_ERROR"
-	ErrorCodeBadRequest     ErrorCode = "BAD_REQUEST"
-	ErrorCodeConflict       ErrorCode = "CONFLICT"
-	ErrorCodeTimeout        ErrorCode = "TIMEOUT"
-	ErrorCodeDatabase       ErrorCode = "DATABASE_ERROR"
-	ErrorCodeExternalAPI    ErrorCode = "EXTERNAL_API_ERROR"
-	ErrorCodeRateLimit      ErrorCode = "RATE_LIMIT_EXCEEDED"
+	ErrorCodeValidation           ErrorCode = "VALIDATION_ERROR"
+	ErrorCodeNotFound             ErrorCode = "NOT_FOUND"
+	ErrorCodeUnauthorized         ErrorCode = "UNAUTHORIZED"
+	ErrorCodeForbidden            ErrorCode = "FORBIDDEN"
+	ErrorCodeInternal             ErrorCode = "INTERNAL_ERROR"
+	ErrorCodeBadRequest           ErrorCode = "BAD_REQUEST"
+	ErrorCodeConflict             ErrorCode = "CONFLICT"
+	ErrorCodeTimeout              ErrorCode = "TIMEOUT"
+	ErrorCodeDatabase             ErrorCode = "DATABASE_ERROR"
+	ErrorCodeExternalAPI          ErrorCode = "EXTERNAL_API_ERROR"
+	ErrorCodeRateLimit            ErrorCode = "RATE_LIMIT_EXCEEDED"
+	ErrorCodePrecondition         ErrorCode = "PRECONDITION_FAILED"
+	ErrorCodePreconditionRequired ErrorCode = "PRECONDITION_REQUIRED"
+	ErrorCodeDependency           ErrorCode = "FAILED_DEPENDENCY"
+	ErrorCodeMediaType            ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	ErrorCodeUnavailable          ErrorCode = "SERVICE_UNAVAILABLE"
 )
 
 // AppError represents a standardized application error
 type AppError struct {
-	Code       ErrorCode `json:"code"`
-	Message    string    `json:"message"`
-	Details    string    `json:"details,omitempty"`
-	HTTPStatus int       `json:"-"`
-	Err        error     `json:"-"`
+	Code       ErrorCode    `json:"code"`
+	Message    string       `json:"message"`
+	Details    string       `json:"details,omitempty"`
+	Fields     []FieldError `json:"fields,omitempty"`
+	HTTPStatus int          `json:"-"`
+	Err        error        `json:"-"`
+}
+
+// FieldError describes a single field that failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
 }
 
 // Error implements the error interface
@@ -83,10 +98,20 @@ func NewValidationErrorWithDetails(message, details string) *AppError {
 	return NewAppErrorWithDetails(ErrorCodeValidation, message, details, http.StatusBadRequest)
 }
 
+func NewValidationErrorWithFields(message string, fields []FieldError) *AppError {
+	err := NewAppError(ErrorCodeValidation, message, http.StatusBadRequest)
+	err.Fields = fields
+	return err
+}
+
 func NewNotFoundError(message string) *AppError {
 	return NewAppError(ErrorCodeNotFound, message, http.StatusNotFound)
 }
 
+func NewNotFoundErrorWithErr(message string, err error) *AppError {
+	return NewAppErrorWithErr(ErrorCodeNotFound, message, http.StatusNotFound, err)
+}
+
 func NewUnauthorizedError(message string) *AppError {
 	return NewAppError(ErrorCodeUnauthorized, message, http.StatusUnauthorized)
 }
@@ -111,6 +136,10 @@ func NewConflictError(message string) *AppError {
 	return NewAppError(ErrorCodeConflict, message, http.StatusConflict)
 }
 
+func NewConflictErrorWithErr(message string, err error) *AppError {
+	return NewAppErrorWithErr(ErrorCodeConflict, message, http.StatusConflict, err)
+}
+
 func NewTimeoutError(message string) *AppError {
 	return NewAppError(ErrorCodeTimeout, message, http.StatusRequestTimeout)
 }
@@ -131,15 +160,39 @@ func NewRateLimitError(message string) *AppError {
 	return NewAppError(ErrorCodeRateLimit, message, http.StatusTooManyRequests)
 }
 
-// IsAppError checks if an error is an AppError
+func NewPreconditionFailedError(message string) *AppError {
+	return NewAppError(ErrorCodePrecondition, message, http.StatusPreconditionFailed)
+}
+
+func NewPreconditionFailedErrorWithErr(message string, err error) *AppError {
+	return NewAppErrorWithErr(ErrorCodePrecondition, message, http.StatusPreconditionFailed, err)
+}
+
+func NewPreconditionRequiredError(message string) *AppError {
+	return NewAppError(ErrorCodePreconditionRequired, message, http.StatusPreconditionRequired)
+}
+
+func NewFailedDependencyError(message string) *AppError {
+	return NewAppError(ErrorCodeDependency, message, http.StatusFailedDependency)
+}
+
+func NewUnsupportedMediaTypeError(message string) *AppError {
+	return NewAppError(ErrorCodeMediaType, message, http.StatusUnsupportedMediaType)
+}
+
+func NewServiceUnavailableError(message string) *AppError {
+	return NewAppError(ErrorCodeUnavailable, message, http.StatusServiceUnavailable)
+}
+
+// IsAppError checks if an error is, or wraps, an AppError
 func IsAppError(err error) bool {
-	_, ok := err.(*AppError)
-	return ok
+	return GetAppError(err) != nil
 }
 
-// GetAppError extracts AppError from an error
+// GetAppError extracts the first AppError in an error's chain
 func GetAppError(err error) *AppError {
-	if appErr, ok := err.(*AppError); ok {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
 		return appErr
 	}
 	return nil
@@ -0,0 +1,209 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"gin-service/pkg/constants"
+)
+
+// Shape describes how a client asked for the resources of a response to be
+// rendered. Fields keeps only the named fields, Exclude drops fields and
+// Expand embeds related resources. The id field is always kept.
+type Shape struct {
+	Fields  []string
+	Exclude []string
+	Expand  []string
+}
+
+// ParseShape reads a shape from the fields, exclude and expand query
+// parameters, each a comma separated list. include is accepted as the
+// JSON:API spelling of expand.
+func ParseShape(query url.Values) Shape {
+	return Shape{
+		Fields:  splitList(query[constants.QueryParamFields]),
+		Exclude: splitList(query[constants.QueryParamExclude]),
+		Expand:  splitList(append(query[constants.QueryParamExpand], query[constants.QueryParamInclude]...)),
+	}
+}
+
+// IsZero reports whether the shape leaves responses unchanged
+func (s Shape) IsZero() bool {
+	return len(s.Fields) == 0 && len(s.Exclude) == 0 && len(s.Expand) == 0
+}
+
+// splitList splits comma separated query values into distinct trimmed items
+func splitList(values []string) []string {
+	var items []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" && !seen[item] {
+				seen[item] = true
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// Expansion embeds a related resource under Name in every resource whose
+// Key field holds a string. Resolve looks up the related resources of all
+// keys of a response at once; keys it leaves out are embedded as null.
+type Expansion struct {
+	Name    string
+	Key     string
+	Resolve func(ctx context.Context, keys []string) (map[string]interface{}, error)
+}
+
+// Shaper applies shapes to JSON responses. A response's resources are the
+// objects it holds at its top level, either directly or in an array, so an
+// envelope such as {"product": {...}, "message": "..."} or
+// {"products": [...], "total": 3} is shaped without knowing its type.
+type Shaper struct {
+	expansions map[string]Expansion
+}
+
+// NewShaper creates a shaper that can apply the given expansions
+func NewShaper(expansions ...Expansion) *Shaper {
+	shaper := &Shaper{expansions: make(map[string]Expansion, len(expansions))}
+	for _, expansion := range expansions {
+		shaper.expansions[expansion.Name] = expansion
+	}
+	return shaper
+}
+
+// Validate checks that every expansion the shape asks for is known
+func (s *Shaper) Validate(shape Shape) error {
+	var fields []FieldError
+	for _, name := range shape.Expand {
+		if _, ok := s.expansions[name]; !ok {
+			fields = append(fields, FieldError{
+				Field:   constants.QueryParamExpand,
+				Rule:    "oneof",
+				Param:   strings.Join(s.expansionNames(), " "),
+				Message: fmt.Sprintf("cannot expand unknown resource: %s", name),
+			})
+		}
+	}
+
+	if len(fields) > 0 {
+		return NewValidationErrorWithFields(constants.ErrMsgValidationFailed, fields)
+	}
+	return nil
+}
+
+// expansionNames returns the names of the known expansions in order
+func (s *Shaper) expansionNames() []string {
+	names := make([]string, 0, len(s.expansions))
+	for name := range s.expansions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply shapes the resources of a JSON document. Documents that are not
+// JSON objects are returned unchanged.
+func (s *Shaper) Apply(ctx context.Context, document []byte, shape Shape) ([]byte, error) {
+	if err := s.Validate(shape); err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+
+	var envelope map[string]interface{}
+	if err := decoder.Decode(&envelope); err != nil || envelope == nil {
+		return document, nil
+	}
+
+	resources := collectResources(envelope)
+	for _, name := range shape.Expand {
+		if err := s.expand(ctx, s.expansions[name], resources); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, resource := range resources {
+		trimResource(resource, shape)
+	}
+
+	return json.Marshal(envelope)
+}
+
+// expand embeds the related resources of an expansion in every resource
+func (s *Shaper) expand(ctx context.Context, expansion Expansion, resources []map[string]interface{}) error {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, resource := range resources {
+		if key, ok := resource[expansion.Key].(string); ok && key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	related := map[string]interface{}{}
+	if len(keys) > 0 {
+		var err error
+		if related, err = expansion.Resolve(ctx, keys); err != nil {
+			return NewInternalErrorWithErr(fmt.Sprintf("failed to expand %s", expansion.Name), err)
+		}
+	}
+
+	for _, resource := range resources {
+		if key, ok := resource[expansion.Key].(string); ok {
+			resource[expansion.Name] = related[key]
+		}
+	}
+	return nil
+}
+
+// collectResources returns the objects held at the top level of an envelope
+func collectResources(envelope map[string]interface{}) []map[string]interface{} {
+	var resources []map[string]interface{}
+	for _, value := range envelope {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			resources = append(resources, v)
+		case []interface{}:
+			for _, item := range v {
+				if resource, ok := item.(map[string]interface{}); ok {
+					resources = append(resources, resource)
+				}
+			}
+		}
+	}
+	return resources
+}
+
+// trimResource removes the fields of resource the shape does not keep.
+// Expanded resources survive a field selection that does not name them.
+func trimResource(resource map[string]interface{}, shape Shape) {
+	if len(shape.Fields) > 0 {
+		keep := map[string]bool{"id": true}
+		for _, field := range shape.Fields {
+			keep[field] = true
+		}
+		for _, name := range shape.Expand {
+			keep[name] = true
+		}
+		for field := range resource {
+			if !keep[field] {
+				delete(resource, field)
+			}
+		}
+	}
+
+	for _, field := range shape.Exclude {
+		if field != "id" {
+			delete(resource, field)
+		}
+	}
+}
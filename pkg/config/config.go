@@ -13,6 +13,45 @@ type Config struct {
 	Server   ServerConfig   `mapstructure:"server"`
 	Log      LogConfig      `mapstructure:"log"`
 	Database DatabaseConfig `mapstructure:"database"`
+	Products ProductsConfig `mapstructure:"products"`
+	Admin    AdminConfig    `mapstructure:"admin"`
+	Health   HealthConfig   `mapstructure:"health"`
+}
+
+// ProductsConfig holds product catalog configuration. Trashed products are
+// purged once they are older than TrashRetention; zero keeps them forever.
+// PriceFormat "number" renders prices in JSON responses as plain numbers
+// for clients that have not migrated to the {amount, currency} form. Stock
+// reservations expire after ReservationTTL and are swept every
+// ReservationSweepInterval.
+type ProductsConfig struct {
+	TrashRetention           time.Duration `mapstructure:"trash_retention" yaml:"trash_retention"`
+	PurgeInterval            time.Duration `mapstructure:"purge_interval" yaml:"purge_interval"`
+	DefaultCurrency          string        `mapstructure:"default_currency" yaml:"default_currency"`
+	PriceFormat              string        `mapstructure:"price_format" yaml:"price_format"`
+	ReservationTTL           time.Duration `mapstructure:"reservation_ttl" yaml:"reservation_ttl"`
+	ReservationSweepInterval time.Duration `mapstructure:"reservation_sweep_interval" yaml:"reservation_sweep_interval"`
+}
+
+// AdminConfig holds configuration for administrative endpoints. They are
+// disabled unless Token is set.
+type AdminConfig struct {
+	Token string `mapstructure:"token" yaml:"token"`
+}
+
+// HealthConfig holds health check configuration. Dependency checks are
+// polled every CheckInterval and abandoned as failing after CheckTimeout.
+// A check flips to failing after FailureThreshold failures in a row and
+// back after SuccessThreshold successes; while failing it is polled with
+// exponential backoff up to MaxBackoff. The last HistorySize status
+// transitions of every check are kept.
+type HealthConfig struct {
+	CheckTimeout     time.Duration `mapstructure:"check_timeout" yaml:"check_timeout"`
+	CheckInterval    time.Duration `mapstructure:"check_interval" yaml:"check_interval"`
+	SuccessThreshold int           `mapstructure:"success_threshold" yaml:"success_threshold"`
+	FailureThreshold int           `mapstructure:"failure_threshold" yaml:"failure_threshold"`
+	MaxBackoff       time.Duration `mapstructure:"max_backoff" yaml:"max_backoff"`
+	HistorySize      int           `mapstructure:"history_size" yaml:"history_size"`
 }
 
 // DatabaseConfig holds database configuration
@@ -27,12 +66,17 @@ type DatabaseConfig struct {
 	MaxConnections     int           `mapstructure:"max_connections" yaml:"max_connections"`
 	MaxIdleConnections int           `mapstructure:"max_idle_connections" yaml:"max_idle_connections"`
 	ConnectionTimeout  time.Duration `mapstructure:"connection_timeout" yaml:"connection_timeout"`
+	MigrationsPath     string        `mapstructure:"migrations_path" yaml:"migrations_path"`
+	AutoMigrate        bool          `mapstructure:"auto_migrate" yaml:"auto_migrate"`
 }
 
-// ServerConfig holds server configuration
+// ServerConfig holds server configuration. On shutdown the server reports
+// not ready and waits PreStopDelay before it stops accepting connections,
+// giving load balancers time to stop routing to it.
 type ServerConfig struct {
-	Port string `mapstructure:"port"`
-	Mode string `mapstructure:"mode"`
+	Port         string        `mapstructure:"port"`
+	Mode         string        `mapstructure:"mode"`
+	PreStopDelay time.Duration `mapstructure:"pre_stop_delay"`
 }
 
 // LogConfig holds logging configuration
@@ -59,6 +103,7 @@ func Load() (*Config, error) {
 	// Set default values
 	viper.SetDefault("server.port", "8080")
 	viper.SetDefault("server.mode", "debug")
+	viper.SetDefault("server.pre_stop_delay", "5s")
 
 	// Set default logging values
 	viper.SetDefault("log.level", "info")
@@ -83,6 +128,27 @@ func Load() (*Config, error) {
 	viper.SetDefault("database.max_connections", 10)
 	viper.SetDefault("database.max_idle_connections", 5)
 	viper.SetDefault("database.connection_timeout", "30s")
+	viper.SetDefault("database.migrations_path", "./migrations")
+	viper.SetDefault("database.auto_migrate", true)
+
+	// Set default product values
+	viper.SetDefault("products.trash_retention", "720h")
+	viper.SetDefault("products.purge_interval", "1h")
+	viper.SetDefault("products.default_currency", "USD")
+	viper.SetDefault("products.price_format", "object")
+	viper.SetDefault("products.reservation_ttl", "15m")
+	viper.SetDefault("products.reservation_sweep_interval", "1m")
+
+	// Set default admin values
+	viper.SetDefault("admin.token", "")
+
+	// Set default health values
+	viper.SetDefault("health.check_timeout", "2s")
+	viper.SetDefault("health.check_interval", "10s")
+	viper.SetDefault("health.success_threshold", 1)
+	viper.SetDefault("health.failure_threshold", 3)
+	viper.SetDefault("health.max_backoff", "1m")
+	viper.SetDefault("health.history_size", 20)
 
 	// Read environment variables
 	viper.AutomaticEnv()
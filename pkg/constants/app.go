@@ -4,7 +4,6 @@ package constants
 const (
 	// App information
 	AppName    = "gin-service"
-	AppVersion = "1.0.0"
 	AppAuthor  = "Your Name"
 	
 	// API constants
@@ -24,6 +23,9 @@ const (
 	DefaultPageSize  = 10
 	MaxPageSize      = 100
 	DefaultPage      = 1
+
+	// Batch constants
+	MaxBatchSize = 1000
 	
 	// Validation constants
 	MaxStringLength = 255
@@ -57,6 +59,7 @@ const (
 	DBTypePostgreSQL = "postgresql"
 	DBTypeMySQL      = "mysql"
 	DBTypeSQLite     = "sqlite"
+	DBTypeMemory     = "memory"
 )
 
 // Log levels
@@ -99,6 +102,10 @@ const (
 	ContentTypeTextPlain   = "text/plain"
 	ContentTypeTextHTML    = "text/html"
 	ContentTypeOctetStream = "application/octet-stream"
+	ContentTypeCSV         = "text/csv"
+	ContentTypeNDJSON      = "application/x-ndjson"
+	ContentTypeMergePatch  = "application/merge-patch+json"
+	ContentTypeJSONPatch   = "application/json-patch+json"
 )
 
 // Status messages
@@ -160,6 +167,7 @@ const (
 	HeaderXRequestID    = "X-Request-ID"
 	HeaderXAPIKey       = "X-API-Key"
 	HeaderXCorrelationID = "X-Correlation-ID"
+	HeaderXActor         = "X-Actor"
 )
 
 // Query parameters
@@ -0,0 +1,232 @@
+// Package dbtest provides a scriptable database/sql driver so that code
+// built on *sql.DB can be tested without a database server. Every statement
+// is handed to a Handler together with the session that ran it, and every
+// statement, begin, commit and rollback is recorded in order.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Session identifies the pooled connection a statement ran on and whether
+// that connection was inside a transaction
+type Session struct {
+	ID   int
+	InTx bool
+}
+
+// Event is one recorded interaction with the driver. Statement holds the SQL
+// text, or BEGIN, COMMIT or ROLLBACK.
+type Event struct {
+	Session   int
+	Statement string
+}
+
+// Handler scripts the behaviour of the driver. Nil functions accept every
+// statement, return no rows and commit successfully.
+type Handler struct {
+	Exec     func(session Session, query string, args []driver.Value) error
+	Query    func(session Session, query string, args []driver.Value) ([]string, [][]driver.Value, error)
+	Commit   func(session Session) error
+	Rollback func(session Session)
+}
+
+// DB is a *sql.DB backed by a Handler
+type DB struct {
+	*sql.DB
+
+	mu      sync.Mutex
+	handler Handler
+	events  []Event
+	nextID  int
+}
+
+// Open returns a DB whose statements are answered by handler
+func Open(handler Handler) *DB {
+	db := &DB{handler: handler}
+	db.DB = sql.OpenDB(connector{db: db})
+	return db
+}
+
+// Events returns the interactions recorded so far
+func (db *DB) Events() []Event {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]Event(nil), db.events...)
+}
+
+// Statements returns the recorded statements, begins, commits and rollbacks
+// without their sessions
+func (db *DB) Statements() []string {
+	events := db.Events()
+	statements := make([]string, len(events))
+	for i, event := range events {
+		statements[i] = event.Statement
+	}
+	return statements
+}
+
+// record appends an event
+func (db *DB) record(session int, statement string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.events = append(db.events, Event{Session: session, Statement: statement})
+}
+
+// connector opens connections to a DB
+type connector struct {
+	db *DB
+}
+
+// Connect opens a new session
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.nextID++
+	return &conn{db: c.db, id: c.db.nextID}, nil
+}
+
+// Driver returns the driver of the connector
+func (c connector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+// fakeDriver only exists to satisfy driver.Connector; connections are
+// opened through the connector
+type fakeDriver struct{}
+
+// Open is not supported
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("dbtest: open through dbtest.Open")
+}
+
+// conn is one session of a DB
+type conn struct {
+	db   *DB
+	id   int
+	inTx bool
+}
+
+// session describes the state of the connection
+func (c *conn) session() Session {
+	return Session{ID: c.id, InTx: c.inTx}
+}
+
+// Prepare is not supported; statements run through ExecContext and
+// QueryContext
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("dbtest: prepared statements are not supported: %s", query)
+}
+
+// Close closes the session
+func (c *conn) Close() error {
+	return nil
+}
+
+// Begin starts a transaction
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx starts a transaction
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if c.inTx {
+		return nil, errors.New("dbtest: transaction already in progress")
+	}
+	c.inTx = true
+	c.db.record(c.id, "BEGIN")
+	return &tx{conn: c}, nil
+}
+
+// ExecContext hands a statement to the handler
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.record(c.id, query)
+	if c.db.handler.Exec != nil {
+		if err := c.db.handler.Exec(c.session(), query, values(args)); err != nil {
+			return nil, err
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+// QueryContext hands a query to the handler
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.record(c.id, query)
+	if c.db.handler.Query == nil {
+		return &rows{}, nil
+	}
+	columns, data, err := c.db.handler.Query(c.session(), query, values(args))
+	if err != nil {
+		return nil, err
+	}
+	return &rows{columns: columns, data: data}, nil
+}
+
+// values drops the names of query arguments
+func values(args []driver.NamedValue) []driver.Value {
+	result := make([]driver.Value, len(args))
+	for i, arg := range args {
+		result[i] = arg.Value
+	}
+	return result
+}
+
+// tx is a transaction of a session
+type tx struct {
+	conn *conn
+}
+
+// Commit ends the transaction through the handler's Commit
+func (t *tx) Commit() error {
+	session := t.conn.session()
+	t.conn.inTx = false
+	t.conn.db.record(session.ID, "COMMIT")
+	if t.conn.db.handler.Commit != nil {
+		return t.conn.db.handler.Commit(session)
+	}
+	return nil
+}
+
+// Rollback ends the transaction through the handler's Rollback
+func (t *tx) Rollback() error {
+	session := t.conn.session()
+	t.conn.inTx = false
+	t.conn.db.record(session.ID, "ROLLBACK")
+	if t.conn.db.handler.Rollback != nil {
+		t.conn.db.handler.Rollback(session)
+	}
+	return nil
+}
+
+// rows iterates over the rows a handler returned
+type rows struct {
+	columns []string
+	data    [][]driver.Value
+	next    int
+}
+
+// Columns returns the column names
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+// Close closes the rows
+func (r *rows) Close() error {
+	return nil
+}
+
+// Next copies the next row into dest
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.data) {
+		return io.EOF
+	}
+	copy(dest, r.data[r.next])
+	r.next++
+	return nil
+}
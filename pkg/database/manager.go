@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"gin-service/pkg/config"
+	"gin-service/pkg/constants"
+	"gin-service/pkg/database/migrate"
 	"gin-service/pkg/database/postgresql"
 )
 
@@ -57,10 +59,9 @@ func (m *Manager) GetConnection() postgresql.Connection {
 	return m.conn
 }
 
-// GetRepository returns a repository for the specified table
-func (m *Manager) GetRepository(tableName string) postgresql.Repository {
-	pgConn := m.conn.(*postgresql.PostgreSQLConnection)
-	return postgresql.NewPostgreSQLRepository(pgConn, tableName)
+// GetRepository returns a repository for entities of type T stored in the specified table
+func GetRepository[T any](m *Manager, tableName string) (postgresql.Repository[T], error) {
+	return postgresql.NewPostgreSQLRepository[T](m.conn, tableName)
 }
 
 // IsHealthy checks if the database is healthy
@@ -68,10 +69,20 @@ func (m *Manager) IsHealthy(ctx context.Context) (bool, error) {
 	return m.conn.IsHealthy(ctx)
 }
 
-// Migrate runs database migrations
+// Migrator returns a migrator for the configured migrations directory
+func (m *Manager) Migrator() *migrate.Migrator {
+	path := m.config.MigrationsPath
+	if path == "" {
+		path = constants.MigrationsPath
+	}
+	return migrate.NewMigrator(m.conn, path)
+}
+
+// Migrate applies all pending database migrations
 func (m *Manager) Migrate(ctx context.Context) error {
-	// This would contain migration logic
-	// For now, just return nil
+	if _, err := m.Migrator().Up(ctx); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,400 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-service/pkg/database/postgresql"
+)
+
+const (
+	// DefaultTable is the bookkeeping table that records applied versions
+	DefaultTable = "schema_migrations"
+
+	// advisoryLockKey identifies the migration lock across all replicas
+	advisoryLockKey int64 = 7265391048
+)
+
+// fileNamePattern matches files such as 000001_create_products_table.up.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration represents a single versioned schema change
+type Migration struct {
+	Version int64
+	Name    string
+	UpSQL   string
+	DownSQL string
+}
+
+// Status describes whether a migration has been applied
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Migrator applies and reverts migrations found in a directory
+type Migrator struct {
+	conn  postgresql.Connection
+	dir   string
+	table string
+}
+
+// NewMigrator creates a new migrator reading migrations from dir
+func NewMigrator(conn postgresql.Connection, dir string) *Migrator {
+	return &Migrator{
+		conn:  conn,
+		dir:   dir,
+		table: DefaultTable,
+	}
+}
+
+// Load reads all migrations from dir ordered by version
+func Load(dir string) ([]*Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration version %d has conflicting names %q and %q", version, migration.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			migration.UpSQL = string(content)
+		} else {
+			migration.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.UpSQL) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Create writes an empty up/down migration pair with the next version number
+func Create(dir, name string) (string, string, error) {
+	name = normalizeName(name)
+	if name == "" {
+		return "", "", fmt.Errorf("migration name is required")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+
+	migrations, err := Load(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	var next int64 = 1
+	if len(migrations) > 0 {
+		next = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := fmt.Sprintf("%06d_%s", next, name)
+	upPath := filepath.Join(dir, base+".up.sql")
+	downPath := filepath.Join(dir, base+".down.sql")
+
+	if err := os.WriteFile(upPath, []byte("-- Write your up migration here\n"), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write up migration: %w", err)
+	}
+	if err := os.WriteFile(downPath, []byte("-- Write your down migration here\n"), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write down migration: %w", err)
+	}
+
+	return upPath, downPath, nil
+}
+
+// Up applies all pending migrations and returns the ones applied
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	migrations, err := Load(m.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []*Migration
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, done := versions[migration.Version]; done {
+				continue
+			}
+
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts the given number of most recently applied migrations
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("steps must be greater than zero")
+	}
+
+	migrations, err := Load(m.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []*Migration
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := migrations[i]
+			if _, done := versions[migration.Version]; !done {
+				continue
+			}
+
+			if strings.TrimSpace(migration.DownSQL) == "" {
+				return fmt.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+			}
+
+			if err := m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status reports every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	migrations, err := Load(m.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	db := m.conn.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("database connection not established")
+	}
+
+	if err := m.ensureTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	versions, err := m.appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(migrations))
+	for _, migration := range migrations {
+		status := Status{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+		if appliedAt, done := versions[migration.Version]; done {
+			at := appliedAt
+			status.Applied = true
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// withLock runs fn while holding a session-level advisory lock so that
+// replicas starting at the same time do not migrate concurrently. fn must
+// run all of its statements on the pinned connection it is given; taking a
+// second connection from the pool would deadlock a pool of size one.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	db := m.conn.GetDB()
+	if db == nil {
+		return fmt.Errorf("database connection not established")
+	}
+
+	// Advisory locks belong to a session, so pin a single connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockKey)
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// execer is satisfied by *sql.DB, *sql.Conn and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// querier is satisfied by *sql.DB, *sql.Conn and *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// ensureTable creates the bookkeeping table if it does not exist
+func (m *Migrator) ensureTable(ctx context.Context, db execer) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version    BIGINT PRIMARY KEY,
+		name       VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`, m.table)
+
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create %s table: %w", m.table, err)
+	}
+
+	return nil
+}
+
+// appliedVersions returns applied versions mapped to their apply time
+func (m *Migrator) appliedVersions(ctx context.Context, db querier) (map[int64]time.Time, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT version, applied_at FROM %s", m.table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		versions[version] = appliedAt
+	}
+
+	return versions, rows.Err()
+}
+
+// apply runs a migration script and its bookkeeping in a single transaction
+// on the connection that holds the migration lock
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration *Migration, up bool) (err error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			err = fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+	}()
+
+	script, bookkeeping := migration.UpSQL, fmt.Sprintf("INSERT INTO %s (version, name) VALUES ($1, $2)", m.table)
+	args := []interface{}{migration.Version, migration.Name}
+	if !up {
+		script, bookkeeping = migration.DownSQL, fmt.Sprintf("DELETE FROM %s WHERE version = $1", m.table)
+		args = args[:1]
+	}
+
+	if _, err = tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	committed = true
+
+	return nil
+}
+
+// normalizeName turns a free-form name into a snake_case file name segment
+func normalizeName(name string) string {
+	var builder strings.Builder
+	lastUnderscore := true
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			builder.WriteRune(r)
+			lastUnderscore = false
+		case !lastUnderscore:
+			builder.WriteRune('_')
+			lastUnderscore = true
+		}
+	}
+
+	return strings.TrimSuffix(builder.String(), "_")
+}
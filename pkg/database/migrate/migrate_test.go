@@ -0,0 +1,303 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gin-service/pkg/database/dbtest"
+	"gin-service/pkg/database/postgresql"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func TestLoad_OrdersAndPairsMigrations(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "000002_add_index.up.sql", "CREATE INDEX idx ON t (c);")
+	writeFile(t, dir, "000002_add_index.down.sql", "DROP INDEX idx;")
+	writeFile(t, dir, "000001_create_table.up.sql", "CREATE TABLE t (c INT);")
+	writeFile(t, dir, "000001_create_table.down.sql", "DROP TABLE t;")
+	writeFile(t, dir, "README.md", "ignored")
+
+	migrations, err := Load(dir)
+
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "create_table", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE t;", migrations[0].DownSQL)
+	assert.Equal(t, int64(2), migrations[1].Version)
+	assert.Equal(t, "CREATE INDEX idx ON t (c);", migrations[1].UpSQL)
+}
+
+func TestLoad_RejectsMissingUpScript(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "000001_orphan.down.sql", "DROP TABLE t;")
+
+	_, err := Load(dir)
+
+	assert.Error(t, err)
+}
+
+func TestLoad_RejectsConflictingNames(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "000001_first.up.sql", "SELECT 1;")
+	writeFile(t, dir, "000001_second.up.sql", "SELECT 2;")
+
+	_, err := Load(dir)
+
+	assert.Error(t, err)
+}
+
+func TestCreate_UsesNextVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "000007_existing.up.sql", "SELECT 1;")
+
+	upPath, downPath, err := Create(dir, "Add Products Table!")
+
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "000008_add_products_table.up.sql"), upPath)
+	assert.Equal(t, filepath.Join(dir, "000008_add_products_table.down.sql"), downPath)
+
+	migrations, err := Load(dir)
+	require.NoError(t, err)
+	assert.Len(t, migrations, 2)
+}
+
+// fakeSchema emulates the bookkeeping table and the effect of migration
+// scripts. Writes made inside a transaction only become visible on commit.
+type fakeSchema struct {
+	mu       sync.Mutex
+	applied  map[int64]time.Time
+	scripts  []string
+	pending  map[int][]func()
+	failOn   string
+	failNext bool
+}
+
+func newFakeSchema() *fakeSchema {
+	return &fakeSchema{applied: make(map[int64]time.Time), pending: make(map[int][]func())}
+}
+
+func (f *fakeSchema) handler() dbtest.Handler {
+	return dbtest.Handler{
+		Exec: func(session dbtest.Session, query string, args []driver.Value) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+
+			if f.failOn != "" && strings.Contains(query, f.failOn) {
+				return errors.New("syntax error")
+			}
+
+			var change func()
+			switch {
+			case strings.HasPrefix(query, "SELECT pg_advisory"), strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS"):
+				return nil
+			case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+				version := args[0].(int64)
+				change = func() { f.applied[version] = time.Now() }
+			case strings.HasPrefix(query, "DELETE FROM schema_migrations"):
+				version := args[0].(int64)
+				change = func() { delete(f.applied, version) }
+			default:
+				change = func() { f.scripts = append(f.scripts, query) }
+			}
+
+			if session.InTx {
+				f.pending[session.ID] = append(f.pending[session.ID], change)
+			} else {
+				change()
+			}
+			return nil
+		},
+		Query: func(session dbtest.Session, query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+
+			var rows [][]driver.Value
+			for version, appliedAt := range f.applied {
+				rows = append(rows, []driver.Value{version, appliedAt})
+			}
+			return []string{"version", "applied_at"}, rows, nil
+		},
+		Commit: func(session dbtest.Session) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+
+			changes := f.pending[session.ID]
+			delete(f.pending, session.ID)
+			if f.failNext {
+				f.failNext = false
+				return errors.New("could not serialize access")
+			}
+			for _, change := range changes {
+				change()
+			}
+			return nil
+		},
+		Rollback: func(session dbtest.Session) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			delete(f.pending, session.ID)
+		},
+	}
+}
+
+func (f *fakeSchema) versions() []int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var versions []int64
+	for version := range f.applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+// fakeConnection exposes a dbtest.DB as a postgresql.Connection
+type fakeConnection struct {
+	postgresql.Connection
+	db *sql.DB
+}
+
+func (c fakeConnection) GetDB() *sql.DB {
+	return c.db
+}
+
+// newTestMigrator returns a migrator for three migrations backed by a
+// single-connection pool, so that a second connection would deadlock
+func newTestMigrator(t *testing.T, schema *fakeSchema) (*Migrator, *dbtest.DB) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, name := range []string{"000001_create_a", "000002_create_b", "000003_create_c"} {
+		table := name[len(name)-1:]
+		writeFile(t, dir, name+".up.sql", "CREATE TABLE "+table+" ();")
+		writeFile(t, dir, name+".down.sql", "DROP TABLE "+table+";")
+	}
+
+	db := dbtest.Open(schema.handler())
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	return NewMigrator(fakeConnection{db: db.DB}, dir), db
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func migrationVersions(migrations []*Migration) []int64 {
+	var versions []int64
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+	return versions
+}
+
+func TestMigrator_UpAppliesPendingInOrder(t *testing.T) {
+	schema := newFakeSchema()
+	migrator, db := newTestMigrator(t, schema)
+	ctx := testContext(t)
+
+	applied, err := migrator.Up(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, migrationVersions(applied))
+	assert.Equal(t, []int64{1, 2, 3}, schema.versions())
+	assert.Equal(t, []string{"CREATE TABLE a ();", "CREATE TABLE b ();", "CREATE TABLE c ();"}, schema.scripts)
+
+	// Everything ran on the connection holding the lock
+	for _, event := range db.Events() {
+		assert.Equal(t, db.Events()[0].Session, event.Session, event.Statement)
+	}
+
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+func TestMigrator_DownRevertsNewestFirst(t *testing.T) {
+	schema := newFakeSchema()
+	migrator, _ := newTestMigrator(t, schema)
+	ctx := testContext(t)
+	_, err := migrator.Up(ctx)
+	require.NoError(t, err)
+
+	reverted, err := migrator.Down(ctx, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 2}, migrationVersions(reverted))
+	assert.Equal(t, []int64{1}, schema.versions())
+	assert.Equal(t, []string{"DROP TABLE c;", "DROP TABLE b;"}, schema.scripts[3:])
+
+	_, err = migrator.Down(ctx, 0)
+	assert.Error(t, err)
+}
+
+func TestMigrator_StatusListsEveryMigrationInOrder(t *testing.T) {
+	schema := newFakeSchema()
+	schema.applied[2] = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	migrator, _ := newTestMigrator(t, schema)
+
+	statuses, err := migrator.Status(testContext(t))
+
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	for i, status := range statuses {
+		assert.Equal(t, int64(i+1), status.Version)
+		assert.Equal(t, i == 1, status.Applied)
+		assert.Equal(t, i == 1, status.AppliedAt != nil)
+	}
+	assert.Equal(t, "create_b", statuses[1].Name)
+}
+
+func TestMigrator_FailedMigrationIsNotRecorded(t *testing.T) {
+	schema := newFakeSchema()
+	migrator, db := newTestMigrator(t, schema)
+	ctx := testContext(t)
+
+	// The bookkeeping insert of the second migration fails after its script ran
+	schema.failOn = "INSERT INTO schema_migrations"
+	schema.applied[1] = time.Now()
+
+	applied, err := migrator.Up(ctx)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to record migration 2_create_b")
+	assert.Empty(t, applied)
+	assert.Equal(t, []int64{1}, schema.versions())
+	assert.Empty(t, schema.scripts)
+	assert.Contains(t, db.Statements(), "ROLLBACK")
+}
+
+func TestMigrator_FailedCommitIsNotRecorded(t *testing.T) {
+	schema := newFakeSchema()
+	migrator, _ := newTestMigrator(t, schema)
+	schema.failNext = true
+
+	applied, err := migrator.Up(testContext(t))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to commit migration 1_create_a")
+	assert.NotContains(t, err.Error(), "rollback failed")
+	assert.Empty(t, applied)
+	assert.Empty(t, schema.versions())
+}
@@ -0,0 +1,137 @@
+package postgresql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// KeyColumn is the primary key column expected on mapped structs
+const KeyColumn = "id"
+
+// fieldMeta describes a struct field mapped to a column
+type fieldMeta struct {
+	column string
+	index  []int
+}
+
+// structMeta holds the column mapping for a struct type
+type structMeta struct {
+	typ     reflect.Type
+	fields  []fieldMeta
+	byName  map[string]fieldMeta
+	columns []string
+}
+
+// metaCache caches struct mappings by type
+var metaCache sync.Map
+
+// metaFor returns the cached column mapping for the struct type t
+func metaFor(t reflect.Type) (*structMeta, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if cached, ok := metaCache.Load(t); ok {
+		return cached.(*structMeta), nil
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot map non-struct type %s", t)
+	}
+
+	meta := &structMeta{
+		typ:    t,
+		byName: make(map[string]fieldMeta),
+	}
+	collectFields(t, nil, meta)
+
+	if len(meta.fields) == 0 {
+		return nil, fmt.Errorf("type %s has no db tagged fields", t)
+	}
+	if _, ok := meta.byName[KeyColumn]; !ok {
+		return nil, fmt.Errorf("type %s has no %q column", t, KeyColumn)
+	}
+
+	cached, _ := metaCache.LoadOrStore(t, meta)
+	return cached.(*structMeta), nil
+}
+
+// collectFields walks exported fields, descending into embedded structs
+func collectFields(t reflect.Type, parent []int, meta *structMeta) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+
+		tag := strings.Split(field.Tag.Get("db"), ",")[0]
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			collectFields(field.Type, index, meta)
+			continue
+		}
+
+		if tag == "" {
+			continue
+		}
+
+		if _, exists := meta.byName[tag]; exists {
+			continue
+		}
+
+		fm := fieldMeta{column: tag, index: index}
+		meta.fields = append(meta.fields, fm)
+		meta.byName[tag] = fm
+		meta.columns = append(meta.columns, tag)
+	}
+}
+
+// hasColumn reports whether column is mapped
+func (m *structMeta) hasColumn(column string) bool {
+	_, ok := m.byName[column]
+	return ok
+}
+
+// values returns the field values of v for the given columns
+func (m *structMeta) values(v reflect.Value, columns []string) []interface{} {
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		values[i] = v.FieldByIndex(m.byName[column].index).Interface()
+	}
+	return values
+}
+
+// pointers returns addressable pointers to the fields of v for every column
+func (m *structMeta) pointers(v reflect.Value) []interface{} {
+	pointers := make([]interface{}, len(m.fields))
+	for i, field := range m.fields {
+		pointers[i] = v.FieldByIndex(field.index).Addr().Interface()
+	}
+	return pointers
+}
+
+// Columns returns the db column names mapped on T in declaration order
+func Columns[T any]() ([]string, error) {
+	meta, err := metaFor(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return append([]string(nil), meta.columns...), nil
+}
+
+// Scanner is satisfied by *sql.Row and *sql.Rows
+type Scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// ScanStruct scans a row selected with Columns[T]() into dest
+func ScanStruct[T any](row Scanner, dest *T) error {
+	meta, err := metaFor(reflect.TypeOf(dest))
+	if err != nil {
+		return err
+	}
+	return row.Scan(meta.pointers(reflect.ValueOf(dest).Elem())...)
+}
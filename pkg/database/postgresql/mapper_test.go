@@ -0,0 +1,101 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gin-service/pkg/database/dbtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type auditFields struct {
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type testEntity struct {
+	ID       string `db:"id"`
+	Name     string `db:"name,omitempty"`
+	Internal string `db:"-"`
+	Untagged string
+	auditFields
+}
+
+type noKeyEntity struct {
+	Name string `db:"name"`
+}
+
+// fakeRow assigns values positionally to scan destinations
+type fakeRow struct {
+	values []interface{}
+}
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	for i, d := range dest {
+		switch p := d.(type) {
+		case *string:
+			*p = r.values[i].(string)
+		case *time.Time:
+			*p = r.values[i].(time.Time)
+		}
+	}
+	return nil
+}
+
+func TestColumns_FollowsDBTags(t *testing.T) {
+	columns, err := Columns[testEntity]()
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "created_at"}, columns)
+}
+
+func TestColumns_RequiresKeyColumn(t *testing.T) {
+	_, err := Columns[noKeyEntity]()
+
+	assert.Error(t, err)
+}
+
+func TestScanStruct_AssignsFieldsInColumnOrder(t *testing.T) {
+	now := time.Now()
+	var entity testEntity
+
+	err := ScanStruct(fakeRow{values: []interface{}{"abc", "widget", now}}, &entity)
+
+	require.NoError(t, err)
+	assert.Equal(t, "abc", entity.ID)
+	assert.Equal(t, "widget", entity.Name)
+	assert.Equal(t, now, entity.CreatedAt)
+}
+
+func TestWhereClause_RejectsUnknownColumns(t *testing.T) {
+	repo, err := NewPostgreSQLRepository[testEntity](nil, "things")
+	require.NoError(t, err)
+
+	_, _, err = repo.whereClause(map[string]interface{}{"name; DROP TABLE things": "x"})
+	assert.Error(t, err)
+
+	where, values, err := repo.whereClause(map[string]interface{}{"name": "x", "id": "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "id = $1 AND name = $2", where)
+	assert.Equal(t, []interface{}{"1", "x"}, values)
+}
+
+func TestWithTransaction_RejectsTransactionsWithoutSQLTx(t *testing.T) {
+	repo, err := NewPostgreSQLRepository[testEntity](nil, "things")
+	require.NoError(t, err)
+
+	_, err = repo.WithTransaction(foreignTransaction{})
+	assert.Error(t, err)
+
+	db := dbtest.Open(dbtest.Handler{})
+	defer db.Close()
+	sqlTx, err := db.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+	defer sqlTx.Rollback()
+
+	bound, err := repo.WithTransaction(&PostgreSQLTransaction{tx: sqlTx})
+	require.NoError(t, err)
+	assert.Same(t, sqlTx, bound.(*PostgreSQLRepository[testEntity]).executor(context.Background()))
+}
@@ -3,105 +3,170 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 )
 
-// Repository represents a generic repository interface
-type Repository interface {
+// ErrNotFound is returned when no row matches the requested key
+var ErrNotFound = errors.New("entity not found")
+
+// Repository represents a generic repository interface for struct type T.
+// Columns are derived from the `db` struct tags on T.
+type Repository[T any] interface {
 	// CRUD operations
-	Create(ctx context.Context, entity interface{}) error
-	GetByID(ctx context.Context, id string) (interface{}, error)
-	GetAll(ctx context.Context, limit, offset int) ([]interface{}, error)
-	Update(ctx context.Context, entity interface{}) error
+	Create(ctx context.Context, entity *T) error
+	CreateMany(ctx context.Context, entities []*T) error
+	GetByID(ctx context.Context, id string) (*T, error)
+	GetAll(ctx context.Context, limit, offset int) ([]*T, error)
+	Update(ctx context.Context, entity *T) error
 	Delete(ctx context.Context, id string) error
 
 	// Query operations
-	FindBy(ctx context.Context, filters map[string]interface{}) ([]interface{}, error)
+	FindBy(ctx context.Context, filters map[string]interface{}) ([]*T, error)
 	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
 	Exists(ctx context.Context, id string) (bool, error)
 
 	// Transaction support
-	WithTransaction(tx Transaction) Repository
+	WithTransaction(tx Transaction) (Repository[T], error)
 }
 
 // PostgreSQLRepository implements Repository for PostgreSQL
-type PostgreSQLRepository struct {
-	db        *sql.DB
+type PostgreSQLRepository[T any] struct {
+	conn      Connection
 	tableName string
-	conn      *PostgreSQLConnection
+	meta      *structMeta
+	tx        *sql.Tx
 }
 
-// NewPostgreSQLRepository creates a new PostgreSQL repository
-func NewPostgreSQLRepository(conn *PostgreSQLConnection, tableName string) *PostgreSQLRepository {
-	return &PostgreSQLRepository{
-		db:        conn.GetDB(),
-		tableName: tableName,
-		conn:      conn,
+// NewPostgreSQLRepository creates a new PostgreSQL repository for T stored in tableName
+func NewPostgreSQLRepository[T any](conn Connection, tableName string) (*PostgreSQLRepository[T], error) {
+	meta, err := metaFor(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
 	}
+
+	return &PostgreSQLRepository[T]{
+		conn:      conn,
+		tableName: tableName,
+		meta:      meta,
+	}, nil
 }
 
 // Create inserts a new entity into the database
-func (r *PostgreSQLRepository) Create(ctx context.Context, entity interface{}) error {
-	// For now, implement a simple version
-	query := fmt.Sprintf("INSERT INTO %s (id, name, created_at) VALUES ($1, $2, $3)", r.tableName)
+func (r *PostgreSQLRepository[T]) Create(ctx context.Context, entity *T) error {
+	placeholders := make([]string, len(r.meta.columns))
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
 
-	// This is a simplified implementation
-	// In a real implementation, you'd want to use reflection or a mapping library
-	_, err := r.db.ExecContext(ctx, query, "test-id", "test-name", "2024-01-01")
-	if err != nil {
+	query := fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)",
+		r.tableName,
+		strings.Join(r.meta.columns, ", "),
+		strings.Join(placeholders, ", "),
+	)
+
+	values := r.meta.values(reflect.ValueOf(entity).Elem(), r.meta.columns)
+	if _, err := r.executor(ctx).ExecContext(ctx, query, values...); err != nil {
 		return fmt.Errorf("failed to create entity: %w", err)
 	}
 
 	return nil
 }
 
-// GetByID retrieves an entity by its ID
-func (r *PostgreSQLRepository) GetByID(ctx context.Context, id string) (interface{}, error) {
-	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", r.tableName)
+// maxParameters is the largest number of bind parameters PostgreSQL accepts
+// in a single statement
+const maxParameters = 65535
 
-	row := r.db.QueryRowContext(ctx, query, id)
+// CreateMany inserts entities using multi-row INSERT statements, splitting
+// them so no statement exceeds the bind parameter limit
+func (r *PostgreSQLRepository[T]) CreateMany(ctx context.Context, entities []*T) error {
+	columnCount := len(r.meta.columns)
+	rowsPerStatement := maxParameters / columnCount
 
-	// For now, return a simple map
-	result := map[string]interface{}{
-		"id":   id,
-		"name": "test-product",
-	}
+	for start := 0; start < len(entities); start += rowsPerStatement {
+		end := start + rowsPerStatement
+		if end > len(entities) {
+			end = len(entities)
+		}
+
+		rows := make([]string, 0, end-start)
+		values := make([]interface{}, 0, (end-start)*columnCount)
+		for _, entity := range entities[start:end] {
+			placeholders := make([]string, columnCount)
+			for i := range placeholders {
+				placeholders[i] = fmt.Sprintf("$%d", len(values)+i+1)
+			}
+			rows = append(rows, "("+strings.Join(placeholders, ", ")+")")
+			values = append(values, r.meta.values(reflect.ValueOf(entity).Elem(), r.meta.columns)...)
+		}
 
-	// In a real implementation, you'd scan the row into a struct
-	_ = row.Scan() // Ignore scan errors for now
+		query := fmt.Sprintf(
+			"INSERT INTO %s (%s) VALUES %s",
+			r.tableName,
+			strings.Join(r.meta.columns, ", "),
+			strings.Join(rows, ", "),
+		)
+
+		if _, err := r.executor(ctx).ExecContext(ctx, query, values...); err != nil {
+			return fmt.Errorf("failed to create entities: %w", err)
+		}
+	}
 
-	return result, nil
+	return nil
 }
 
-// GetAll retrieves all entities with pagination
-func (r *PostgreSQLRepository) GetAll(ctx context.Context, limit, offset int) ([]interface{}, error) {
-	query := fmt.Sprintf("SELECT * FROM %s LIMIT $1 OFFSET $2", r.tableName)
+// GetByID retrieves an entity by its ID
+func (r *PostgreSQLRepository[T]) GetByID(ctx context.Context, id string) (*T, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1", r.selectList(), r.tableName, KeyColumn)
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	entity := new(T)
+	err := r.executor(ctx).QueryRowContext(ctx, query, id).Scan(r.meta.pointers(reflect.ValueOf(entity).Elem())...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all entities: %w", err)
-	}
-	defer rows.Close()
-
-	var results []interface{}
-	for rows.Next() {
-		// In a real implementation, you'd scan into a struct
-		result := map[string]interface{}{
-			"id":   "test-id",
-			"name": "test-product",
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
 		}
-		results = append(results, result)
+		return nil, fmt.Errorf("failed to get entity: %w", err)
 	}
 
-	return results, nil
+	return entity, nil
+}
+
+// GetAll retrieves all entities with pagination
+func (r *PostgreSQLRepository[T]) GetAll(ctx context.Context, limit, offset int) ([]*T, error) {
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s ORDER BY %s LIMIT $1 OFFSET $2",
+		r.selectList(), r.tableName, KeyColumn,
+	)
+
+	return r.query(ctx, query, limit, offset)
 }
 
 // Update updates an existing entity
-func (r *PostgreSQLRepository) Update(ctx context.Context, entity interface{}) error {
-	query := fmt.Sprintf("UPDATE %s SET name = $1 WHERE id = $2", r.tableName)
+func (r *PostgreSQLRepository[T]) Update(ctx context.Context, entity *T) error {
+	columns := make([]string, 0, len(r.meta.columns)-1)
+	assignments := make([]string, 0, len(r.meta.columns)-1)
+	for _, column := range r.meta.columns {
+		if column == KeyColumn {
+			continue
+		}
+		columns = append(columns, column)
+		assignments = append(assignments, fmt.Sprintf("%s = $%d", column, len(columns)))
+	}
 
-	result, err := r.db.ExecContext(ctx, query, "updated-name", "test-id")
+	query := fmt.Sprintf(
+		"UPDATE %s SET %s WHERE %s = $%d",
+		r.tableName,
+		strings.Join(assignments, ", "),
+		KeyColumn,
+		len(columns)+1,
+	)
+
+	values := r.meta.values(reflect.ValueOf(entity).Elem(), append(columns, KeyColumn))
+	result, err := r.executor(ctx).ExecContext(ctx, query, values...)
 	if err != nil {
 		return fmt.Errorf("failed to update entity: %w", err)
 	}
@@ -112,17 +177,17 @@ func (r *PostgreSQLRepository) Update(ctx context.Context, entity interface{}) e
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no entity found to update")
+		return ErrNotFound
 	}
 
 	return nil
 }
 
 // Delete removes an entity by its ID
-func (r *PostgreSQLRepository) Delete(ctx context.Context, id string) error {
-	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", r.tableName)
+func (r *PostgreSQLRepository[T]) Delete(ctx context.Context, id string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = $1", r.tableName, KeyColumn)
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.executor(ctx).ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete entity: %w", err)
 	}
@@ -133,80 +198,47 @@ func (r *PostgreSQLRepository) Delete(ctx context.Context, id string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no entity found to delete")
+		return ErrNotFound
 	}
 
 	return nil
 }
 
-// FindBy finds entities by filters
-func (r *PostgreSQLRepository) FindBy(ctx context.Context, filters map[string]interface{}) ([]interface{}, error) {
+// FindBy finds entities matching all of the given column filters
+func (r *PostgreSQLRepository[T]) FindBy(ctx context.Context, filters map[string]interface{}) ([]*T, error) {
 	if len(filters) == 0 {
 		return r.GetAll(ctx, 100, 0) // Default limit
 	}
 
-	// Generate WHERE clause
-	conditions := make([]string, 0, len(filters))
-	values := make([]interface{}, 0, len(filters))
-	placeholderIndex := 1
-
-	for field, value := range filters {
-		conditions = append(conditions, fmt.Sprintf("%s = $%d", field, placeholderIndex))
-		values = append(values, value)
-		placeholderIndex++
+	where, values, err := r.whereClause(filters)
+	if err != nil {
+		return nil, err
 	}
 
 	query := fmt.Sprintf(
-		"SELECT * FROM %s WHERE %s",
-		r.tableName,
-		strings.Join(conditions, " AND "),
+		"SELECT %s FROM %s WHERE %s ORDER BY %s",
+		r.selectList(), r.tableName, where, KeyColumn,
 	)
 
-	rows, err := r.db.QueryContext(ctx, query, values...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find entities: %w", err)
-	}
-	defer rows.Close()
-
-	var results []interface{}
-	for rows.Next() {
-		result := map[string]interface{}{
-			"id":   "test-id",
-			"name": "test-product",
-		}
-		results = append(results, result)
-	}
-
-	return results, nil
+	return r.query(ctx, query, values...)
 }
 
 // Count counts entities with optional filters
-func (r *PostgreSQLRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
-	var query string
-	var values []interface{}
+func (r *PostgreSQLRepository[T]) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s", r.tableName)
 
-	if len(filters) == 0 {
-		query = fmt.Sprintf("SELECT COUNT(*) FROM %s", r.tableName)
-	} else {
-		conditions := make([]string, 0, len(filters))
-		placeholderIndex := 1
-
-		for field, value := range filters {
-			conditions = append(conditions, fmt.Sprintf("%s = $%d", field, placeholderIndex))
-			values = append(values, value)
-			placeholderIndex++
+	var values []interface{}
+	if len(filters) > 0 {
+		where, filterValues, err := r.whereClause(filters)
+		if err != nil {
+			return 0, err
 		}
-
-		query = fmt.Sprintf(
-			"SELECT COUNT(*) FROM %s WHERE %s",
-			r.tableName,
-			strings.Join(conditions, " AND "),
-		)
+		query += " WHERE " + where
+		values = filterValues
 	}
 
 	var count int64
-	err := r.db.QueryRowContext(ctx, query, values...).Scan(&count)
-	if err != nil {
+	if err := r.executor(ctx).QueryRowContext(ctx, query, values...).Scan(&count); err != nil {
 		return 0, fmt.Errorf("failed to count entities: %w", err)
 	}
 
@@ -214,22 +246,91 @@ func (r *PostgreSQLRepository) Count(ctx context.Context, filters map[string]int
 }
 
 // Exists checks if an entity exists by ID
-func (r *PostgreSQLRepository) Exists(ctx context.Context, id string) (bool, error) {
-	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1)", r.tableName)
+func (r *PostgreSQLRepository[T]) Exists(ctx context.Context, id string) (bool, error) {
+	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE %s = $1)", r.tableName, KeyColumn)
 
 	var exists bool
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&exists)
-	if err != nil {
+	if err := r.executor(ctx).QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
 		return false, fmt.Errorf("failed to check existence: %w", err)
 	}
 
 	return exists, nil
 }
 
-// WithTransaction returns a repository that uses the provided transaction
-func (r *PostgreSQLRepository) WithTransaction(tx Transaction) Repository {
-	// This is a simplified implementation
-	// In a real implementation, you'd want to create a new repository instance
-	// that uses the transaction instead of the database connection
-	return r
+// WithTransaction returns a repository that uses the provided transaction.
+// It fails for transactions that are not backed by a *sql.Tx rather than
+// let the repository run outside of them.
+func (r *PostgreSQLRepository[T]) WithTransaction(tx Transaction) (Repository[T], error) {
+	sqlTx, ok := tx.GetUnderlyingTx().(*sql.Tx)
+	if !ok {
+		return nil, fmt.Errorf("unsupported transaction type %T", tx.GetUnderlyingTx())
+	}
+
+	return &PostgreSQLRepository[T]{
+		conn:      r.conn,
+		tableName: r.tableName,
+		meta:      r.meta,
+		tx:        sqlTx,
+	}, nil
+}
+
+// executor returns the bound transaction, the transaction carried by ctx,
+// or the connection pool, in that order of preference
+func (r *PostgreSQLRepository[T]) executor(ctx context.Context) Executor {
+	if r.tx != nil {
+		return r.tx
+	}
+	return ExecutorFromContext(ctx, r.conn.GetDB())
+}
+
+// selectList returns the comma separated column list for SELECT statements
+func (r *PostgreSQLRepository[T]) selectList() string {
+	return strings.Join(r.meta.columns, ", ")
+}
+
+// whereClause builds an AND-ed equality clause, rejecting unknown columns
+func (r *PostgreSQLRepository[T]) whereClause(filters map[string]interface{}) (string, []interface{}, error) {
+	fields := make([]string, 0, len(filters))
+	for field := range filters {
+		if !r.meta.hasColumn(field) {
+			return "", nil, fmt.Errorf("unknown filter column: %s", field)
+		}
+		fields = append(fields, field)
+	}
+
+	// Sort so the generated SQL is stable between calls
+	sort.Strings(fields)
+
+	conditions := make([]string, len(fields))
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		conditions[i] = fmt.Sprintf("%s = $%d", field, i+1)
+		values[i] = filters[field]
+	}
+
+	return strings.Join(conditions, " AND "), values, nil
+}
+
+// query runs a SELECT and scans every row into a new T
+func (r *PostgreSQLRepository[T]) query(ctx context.Context, query string, args ...interface{}) ([]*T, error) {
+	rows, err := r.executor(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entities: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]*T, 0)
+	for rows.Next() {
+		entity := new(T)
+		if err := rows.Scan(r.meta.pointers(reflect.ValueOf(entity).Elem())...); err != nil {
+			return nil, fmt.Errorf("failed to scan entity: %w", err)
+		}
+		results = append(results, entity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate entities: %w", err)
+	}
+
+	return results, nil
 }
@@ -0,0 +1,45 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Executor is the query surface shared by *sql.DB and *sql.Tx
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txContextKey is the context key under which the active transaction is stored
+type txContextKey struct{}
+
+// ContextWithTransaction returns a copy of ctx carrying tx
+func ContextWithTransaction(ctx context.Context, tx Transaction) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TransactionFromContext returns the transaction carried by ctx, if any
+func TransactionFromContext(ctx context.Context) (Transaction, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(Transaction)
+	return tx, ok
+}
+
+// ExecutorFromContext returns the *sql.Tx carried by ctx, falling back to db
+// when ctx carries no transaction. A carried transaction that is not backed
+// by a *sql.Tx is a programming error and panics, since falling back would
+// silently run statements outside of it.
+func ExecutorFromContext(ctx context.Context, db *sql.DB) Executor {
+	tx, ok := TransactionFromContext(ctx)
+	if !ok {
+		return db
+	}
+
+	sqlTx, ok := tx.GetUnderlyingTx().(*sql.Tx)
+	if !ok {
+		panic(fmt.Sprintf("postgresql: unsupported transaction type %T in context", tx.GetUnderlyingTx()))
+	}
+	return sqlTx
+}
@@ -0,0 +1,44 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"gin-service/pkg/database/dbtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// foreignTransaction is a Transaction that is not backed by a *sql.Tx
+type foreignTransaction struct{}
+
+func (foreignTransaction) Commit(ctx context.Context) error   { return nil }
+func (foreignTransaction) Rollback(ctx context.Context) error { return nil }
+func (foreignTransaction) GetUnderlyingTx() interface{}       { return "not a transaction" }
+
+func TestExecutorFromContext(t *testing.T) {
+	db := dbtest.Open(dbtest.Handler{})
+	defer db.Close()
+
+	t.Run("falls back to the pool without a transaction", func(t *testing.T) {
+		assert.Same(t, db.DB, ExecutorFromContext(context.Background(), db.DB))
+	})
+
+	t.Run("returns the carried transaction", func(t *testing.T) {
+		sqlTx, err := db.BeginTx(context.Background(), nil)
+		require.NoError(t, err)
+		defer sqlTx.Rollback()
+
+		ctx := ContextWithTransaction(context.Background(), &PostgreSQLTransaction{tx: sqlTx})
+		executor, ok := ExecutorFromContext(ctx, db.DB).(*sql.Tx)
+		assert.True(t, ok)
+		assert.Same(t, sqlTx, executor)
+	})
+
+	t.Run("panics on a transaction it cannot run statements on", func(t *testing.T) {
+		ctx := ContextWithTransaction(context.Background(), foreignTransaction{})
+		assert.Panics(t, func() { ExecutorFromContext(ctx, db.DB) })
+	})
+}
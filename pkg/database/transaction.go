@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"gin-service/pkg/database/postgresql"
+)
+
+// TransactionManager runs a unit of work inside a single database transaction.
+// Repositories pick the transaction up from the context passed to fn.
+type TransactionManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// WithinTx runs fn inside a transaction carried through its context. The
+// transaction is committed if fn returns nil and rolled back otherwise,
+// including when fn panics. Calls nested inside an existing transaction
+// join it rather than starting a new one.
+func (m *Manager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := postgresql.TransactionFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.conn.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	if err := fn(postgresql.ContextWithTransaction(ctx, tx)); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("transaction failed: %w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// noopTransactionManager runs units of work without a transaction
+type noopTransactionManager struct{}
+
+// NewNoopTransactionManager returns a TransactionManager for backends that
+// have no transactions, such as the in-memory repositories
+func NewNoopTransactionManager() TransactionManager {
+	return noopTransactionManager{}
+}
+
+// SupportsRollback reports whether m undoes the writes of a unit of work
+// that fails. The noop manager cannot.
+func SupportsRollback(m TransactionManager) bool {
+	_, noop := m.(noopTransactionManager)
+	return !noop
+}
+
+// WithinTx runs fn directly
+func (noopTransactionManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
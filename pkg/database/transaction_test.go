@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"gin-service/pkg/database/dbtest"
+	"gin-service/pkg/database/postgresql"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConnection opens transactions on a dbtest.DB
+type fakeConnection struct {
+	postgresql.Connection
+	db *sql.DB
+}
+
+func (c fakeConnection) BeginTx(ctx context.Context) (postgresql.Transaction, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return fakeTransaction{tx: tx}, nil
+}
+
+func (c fakeConnection) GetDB() *sql.DB {
+	return c.db
+}
+
+// fakeTransaction wraps a *sql.Tx
+type fakeTransaction struct {
+	tx *sql.Tx
+}
+
+func (t fakeTransaction) Commit(ctx context.Context) error   { return t.tx.Commit() }
+func (t fakeTransaction) Rollback(ctx context.Context) error { return t.tx.Rollback() }
+func (t fakeTransaction) GetUnderlyingTx() interface{}       { return t.tx }
+
+func newTestManager(t *testing.T) (*Manager, *dbtest.DB) {
+	db := dbtest.Open(dbtest.Handler{})
+	t.Cleanup(func() { db.Close() })
+	return &Manager{conn: fakeConnection{db: db.DB}}, db
+}
+
+// insert runs a statement on the executor the context selects
+func insert(ctx context.Context, m *Manager) error {
+	_, err := postgresql.ExecutorFromContext(ctx, m.conn.GetDB()).ExecContext(ctx, "INSERT INTO products DEFAULT VALUES")
+	return err
+}
+
+func TestManager_WithinTx_CommitsOnSuccess(t *testing.T) {
+	manager, db := newTestManager(t)
+
+	err := manager.WithinTx(context.Background(), func(ctx context.Context) error {
+		_, ok := postgresql.TransactionFromContext(ctx)
+		assert.True(t, ok)
+		return insert(ctx, manager)
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BEGIN", "INSERT INTO products DEFAULT VALUES", "COMMIT"}, db.Statements())
+
+	// The statement ran on the transaction's connection
+	events := db.Events()
+	assert.Equal(t, events[0].Session, events[1].Session)
+}
+
+func TestManager_WithinTx_RollsBackOnError(t *testing.T) {
+	manager, db := newTestManager(t)
+	failure := errors.New("stock would go negative")
+
+	err := manager.WithinTx(context.Background(), func(ctx context.Context) error {
+		require.NoError(t, insert(ctx, manager))
+		return failure
+	})
+
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, []string{"BEGIN", "INSERT INTO products DEFAULT VALUES", "ROLLBACK"}, db.Statements())
+}
+
+func TestManager_WithinTx_RollsBackOnPanic(t *testing.T) {
+	manager, db := newTestManager(t)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		manager.WithinTx(context.Background(), func(ctx context.Context) error {
+			require.NoError(t, insert(ctx, manager))
+			panic("boom")
+		})
+	})
+
+	assert.Equal(t, []string{"BEGIN", "INSERT INTO products DEFAULT VALUES", "ROLLBACK"}, db.Statements())
+}
+
+func TestManager_WithinTx_NestedCallsJoinTheTransaction(t *testing.T) {
+	manager, db := newTestManager(t)
+	failure := errors.New("inner failure")
+
+	err := manager.WithinTx(context.Background(), func(ctx context.Context) error {
+		outer, _ := postgresql.TransactionFromContext(ctx)
+		require.NoError(t, insert(ctx, manager))
+
+		return manager.WithinTx(ctx, func(ctx context.Context) error {
+			inner, _ := postgresql.TransactionFromContext(ctx)
+			assert.Equal(t, outer, inner)
+			require.NoError(t, insert(ctx, manager))
+			return failure
+		})
+	})
+
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, []string{
+		"BEGIN",
+		"INSERT INTO products DEFAULT VALUES",
+		"INSERT INTO products DEFAULT VALUES",
+		"ROLLBACK",
+	}, db.Statements())
+}
+
+func TestNoopTransactionManager_RunsFunctionDirectly(t *testing.T) {
+	failure := errors.New("failure")
+
+	err := NewNoopTransactionManager().WithinTx(context.Background(), func(ctx context.Context) error {
+		_, ok := postgresql.TransactionFromContext(ctx)
+		assert.False(t, ok)
+		return failure
+	})
+
+	assert.ErrorIs(t, err, failure)
+}
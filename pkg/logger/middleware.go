@@ -68,7 +68,7 @@ func RequestLogger(log Logger) gin.HandlerFunc {
 		}
 
 		// Add request ID to context
-		ctx := context.WithValue(c.Request.Context(), "request_id", requestID)
+		ctx := context.WithValue(c.Request.Context(), requestIDKey, requestID)
 		c.Request = c.Request.WithContext(ctx)
 
 		// Log request start
@@ -112,6 +112,16 @@ func RequestLogger(log Logger) gin.HandlerFunc {
 	}
 }
 
+// requestIDKey is the context key RequestLogger stores the request ID under
+const requestIDKey = "request_id"
+
+// RequestIDFromContext returns the request ID set by RequestLogger, or an
+// empty string outside a request
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
 // generateRequestID generates a simple request ID
 func generateRequestID() string {
 	return time.Now().Format("20060102150405") + "-" + randomString(8)
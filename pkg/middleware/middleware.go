@@ -1,9 +1,19 @@
 package middleware
 
 import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
+	"gin-service/pkg/audit"
+	"gin-service/pkg/common"
+	"gin-service/pkg/constants"
+	"gin-service/pkg/money"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -30,13 +40,293 @@ func Recovery() gin.HandlerFunc {
 	return gin.Recovery()
 }
 
+// ErrorHandler returns a gin.HandlerFunc that renders the last error attached
+// with c.Error as a common.Response envelope. AppErrors keep their status and
+// code; any other error becomes a generic internal error so that details of
+// unexpected failures are not leaked to clients.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		appErr := common.GetAppError(err)
+		if appErr == nil {
+			appErr = common.NewInternalErrorWithErr(constants.ErrMsgInternalServer, err)
+		}
+
+		common.SendError(c, appErr)
+	}
+}
+
+// AdminAuth returns a gin.HandlerFunc that only lets requests carrying
+// "Authorization: Bearer <token>" through and records them as made by the
+// admin for the audit trail. An empty token disables the routes it guards.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Error(common.NewForbiddenError("Admin API is disabled"))
+			c.Abort()
+			return
+		}
+
+		provided, ok := strings.CutPrefix(c.GetHeader(constants.HeaderAuthorization), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Error(common.NewUnauthorizedError(constants.ErrMsgUnauthorized))
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(audit.ContextWithActor(c.Request.Context(), audit.AdminActor))
+		c.Next()
+	}
+}
+
+// RequireStarted returns a gin.HandlerFunc that answers 503 Service
+// Unavailable until started reports true, so that routes backed by the
+// database are not served while migrations are still running
+func RequireStarted(started func() bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !started() {
+			c.Error(common.NewServiceUnavailableError("Service is starting"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Actor returns a gin.HandlerFunc that records the caller named in the
+// X-Actor header on the request context for the audit trail. Anyone can
+// send the header, so it is only kept as a claim next to the authenticated
+// actor.
+func Actor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if actor := strings.TrimSpace(c.GetHeader(constants.HeaderXActor)); actor != "" {
+			c.Request = c.Request.WithContext(audit.ContextWithClaimedActor(c.Request.Context(), actor))
+		}
+		c.Next()
+	}
+}
+
+// ShapeResponse returns a gin.HandlerFunc that trims and expands the
+// resources of successful JSON responses as requested by the fields,
+// exclude and expand query parameters. Responses of requests without those
+// parameters are written through untouched.
+func ShapeResponse(shaper *common.Shaper) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shape := common.ParseShape(c.Request.URL.Query())
+		if shape.IsZero() {
+			c.Next()
+			return
+		}
+
+		if err := shaper.Validate(shape); err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		body := writer.body.Bytes()
+		if writer.status >= http.StatusOK && writer.status < http.StatusMultipleChoices &&
+			strings.HasPrefix(writer.Header().Get(constants.HeaderContentType), constants.ContentTypeJSON) {
+			shaped, err := shaper.Apply(c.Request.Context(), body, shape)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			body = shaped
+		}
+
+		if writer.written {
+			c.Writer.WriteHeader(writer.status)
+			c.Writer.Write(body)
+		}
+	}
+}
+
+// bufferedWriter holds back a response so that it can be rewritten before
+// it is sent
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body    bytes.Buffer
+	status  int
+	written bool
+}
+
+// WriteHeader records the status code
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+	w.written = true
+}
+
+// WriteHeaderNow marks the response as written without sending anything
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+// Write buffers data
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+// WriteString buffers s
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+// Status returns the recorded status code
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+// Size returns the number of buffered bytes, or -1 before anything is written
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+// Written reports whether the handler wrote a response
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Flush is a no-op; the response is sent once the handler returns
+func (w *bufferedWriter) Flush() {}
+
+// NumberPrices returns a gin.HandlerFunc that renders the money values of
+// JSON responses as bare numbers in major units, for clients from before
+// prices carried a currency. Responses of other types, such as streamed
+// exports, are written through untouched.
+func NumberPrices() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &jsonBufferedWriter{bufferedWriter: bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.through || !writer.written {
+			return
+		}
+
+		body := writer.body.Bytes()
+		if writer.isJSON() && len(body) > 0 {
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.UseNumber()
+			var document interface{}
+			if err := decoder.Decode(&document); err == nil {
+				if rendered, err := json.Marshal(money.ToNumbers(document)); err == nil {
+					body = rendered
+				}
+			}
+		}
+
+		c.Writer.WriteHeader(writer.status)
+		c.Writer.Write(body)
+	}
+}
+
+// jsonBufferedWriter holds back JSON responses so that they can be
+// rewritten and writes every other response straight through. Whether a
+// response is JSON is decided on its first write, once the handler has set
+// its content type.
+type jsonBufferedWriter struct {
+	bufferedWriter
+	decided bool
+	through bool
+}
+
+// isJSON reports whether the response has a JSON content type
+func (w *jsonBufferedWriter) isJSON() bool {
+	return strings.HasPrefix(w.Header().Get(constants.HeaderContentType), constants.ContentTypeJSON)
+}
+
+// decide picks buffering or writing through for the response
+func (w *jsonBufferedWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	if !w.isJSON() {
+		w.through = true
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// WriteHeader records the status code
+func (w *jsonBufferedWriter) WriteHeader(code int) {
+	if w.through {
+		return
+	}
+	w.bufferedWriter.WriteHeader(code)
+}
+
+// WriteHeaderNow decides how the response is written
+func (w *jsonBufferedWriter) WriteHeaderNow() {
+	w.bufferedWriter.WriteHeaderNow()
+	w.decide()
+	if w.through {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// Write buffers JSON and writes anything else through
+func (w *jsonBufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	w.decide()
+	if w.through {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+// WriteString buffers JSON and writes anything else through
+func (w *jsonBufferedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Status returns the status code
+func (w *jsonBufferedWriter) Status() int {
+	if w.through {
+		return w.ResponseWriter.Status()
+	}
+	return w.bufferedWriter.Status()
+}
+
+// Size returns the number of bytes written or buffered
+func (w *jsonBufferedWriter) Size() int {
+	if w.through {
+		return w.ResponseWriter.Size()
+	}
+	return w.bufferedWriter.Size()
+}
+
+// Flush sends what was written through; buffered JSON waits for the handler
+func (w *jsonBufferedWriter) Flush() {
+	if w.through {
+		w.ResponseWriter.Flush()
+	}
+}
+
 // CORS returns a gin.HandlerFunc for CORS
 func CORS() gin.HandlerFunc {
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
 	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
-	config.ExposeHeaders = []string{"Content-Length"}
+	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "If-Match", constants.HeaderXActor, constants.HeaderXRequestID}
+	config.ExposeHeaders = []string{"Content-Length", "ETag"}
 	config.AllowCredentials = true
 	config.MaxAge = 12 * time.Hour
 
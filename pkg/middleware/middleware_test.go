@@ -0,0 +1,249 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gin-service/pkg/audit"
+	"gin-service/pkg/common"
+	"gin-service/pkg/constants"
+	"gin-service/pkg/money"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func performErrorRequest(t *testing.T, handlerErr error) (*httptest.ResponseRecorder, common.Response) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/test", func(c *gin.Context) {
+		c.Error(handlerErr)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	var response common.Response
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return recorder, response
+}
+
+func TestErrorHandler_RendersWrappedAppError(t *testing.T) {
+	err := fmt.Errorf("lookup failed: %w", common.NewNotFoundError("Product not found"))
+
+	recorder, response := performErrorRequest(t, err)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.False(t, response.Success)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, common.ErrorCodeNotFound, response.Error.Code)
+	assert.Equal(t, "/test", response.Path)
+}
+
+func TestErrorHandler_HidesUnexpectedErrors(t *testing.T) {
+	recorder, response := performErrorRequest(t, errors.New("pq: connection refused"))
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, common.ErrorCodeInternal, response.Error.Code)
+	assert.NotContains(t, recorder.Body.String(), "connection refused")
+}
+
+func TestAdminAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		status int
+	}{
+		{name: "disabled without token", token: "", header: "Bearer ", status: http.StatusForbidden},
+		{name: "missing header", token: "secret", header: "", status: http.StatusUnauthorized},
+		{name: "wrong token", token: "secret", header: "Bearer nope", status: http.StatusUnauthorized},
+		{name: "valid token", token: "secret", header: "Bearer secret", status: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ErrorHandler())
+			router.GET("/admin", AdminAuth(tt.token), func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.header != "" {
+				request.Header.Set("Authorization", tt.header)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.status, recorder.Code)
+		})
+	}
+}
+
+func TestRequireStarted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var started bool
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/products", RequireStarted(func() bool { return started }), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/products", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	started = true
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/products", nil))
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestActor_KeepsHeaderAsClaim(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		admin   bool
+		header  string
+		actor   string
+		claimed string
+	}{
+		{name: "anonymous", actor: audit.AnonymousActor},
+		{name: "claimed", header: "support@example.com", actor: audit.AnonymousActor, claimed: "support@example.com"},
+		{name: "admin", admin: true, header: "support@example.com", actor: audit.AdminActor, claimed: "support@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actor, claimed string
+			router := gin.New()
+			router.Use(Actor())
+			handlers := []gin.HandlerFunc{func(c *gin.Context) {
+				actor = audit.ActorFromContext(c.Request.Context())
+				claimed = audit.ClaimedActorFromContext(c.Request.Context())
+			}}
+			if tt.admin {
+				handlers = append([]gin.HandlerFunc{AdminAuth("secret")}, handlers...)
+			}
+			router.GET("/test", handlers...)
+
+			request := httptest.NewRequest(http.MethodGet, "/test", nil)
+			request.Header.Set("Authorization", "Bearer secret")
+			if tt.header != "" {
+				request.Header.Set(constants.HeaderXActor, tt.header)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), request)
+
+			assert.Equal(t, tt.actor, actor)
+			assert.Equal(t, tt.claimed, claimed)
+		})
+	}
+}
+
+func TestNumberPrices(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(ErrorHandler(), NumberPrices())
+	router.GET("/product", func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"name": "Mug", "price": money.New(1999, "EUR"), "stock": 12345678901234567})
+	})
+	router.GET("/export", func(c *gin.Context) {
+		c.Header(constants.HeaderContentType, constants.ContentTypeNDJSON)
+		c.Status(http.StatusOK)
+		c.Writer.WriteString(`{"price":{"amount":"19.99","currency":"EUR"}}` + "\n")
+		c.Writer.Flush()
+	})
+	router.GET("/missing", func(c *gin.Context) {
+		c.Error(common.NewNotFoundError("Product not found"))
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/product", nil))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.JSONEq(t, `{"name": "Mug", "price": 19.99, "stock": 12345678901234567}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/export", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, recorder.Flushed)
+	assert.Equal(t, `{"price":{"amount":"19.99","currency":"EUR"}}`+"\n", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func performShapedRequest(t *testing.T, target string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	shaper := common.NewShaper(common.Expansion{
+		Name: "category",
+		Key:  "category_id",
+		Resolve: func(ctx context.Context, keys []string) (map[string]interface{}, error) {
+			return map[string]interface{}{"kitchen": gin.H{"id": "kitchen", "name": "Kitchen"}}, nil
+		},
+	})
+
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/products", ShapeResponse(shaper), func(c *gin.Context) {
+		c.Header("ETag", `"1"`)
+		c.JSON(http.StatusOK, gin.H{
+			"products": []gin.H{
+				{"id": "a", "name": "Mug", "price": 12.5, "category_id": "kitchen"},
+				{"id": "b", "name": "Lamp", "price": 40, "category_id": "office"},
+			},
+			"total": 2,
+		})
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	return recorder, body
+}
+
+func TestShapeResponse_SelectsFieldsAndExpands(t *testing.T) {
+	recorder, body := performShapedRequest(t, "/products?fields=name&expand=category")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `"1"`, recorder.Header().Get("ETag"))
+	assert.Equal(t, float64(2), body["total"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "a", "name": "Mug", "category": map[string]interface{}{"id": "kitchen", "name": "Kitchen"}},
+		map[string]interface{}{"id": "b", "name": "Lamp", "category": nil},
+	}, body["products"])
+}
+
+func TestShapeResponse_ExcludesFields(t *testing.T) {
+	_, body := performShapedRequest(t, "/products?exclude=price,category_id,id")
+
+	assert.Equal(t, map[string]interface{}{"id": "a", "name": "Mug"}, body["products"].([]interface{})[0])
+}
+
+func TestShapeResponse_RejectsUnknownExpansion(t *testing.T) {
+	recorder, body := performShapedRequest(t, "/products?include=owner")
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "cannot expand unknown resource: owner")
+	assert.NotContains(t, body, "products")
+}
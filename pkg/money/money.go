@@ -0,0 +1,310 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Errors returned when parsing amounts
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrInvalidAmount   = errors.New("invalid amount")
+	ErrPrecision       = errors.New("amount has more decimal places than the currency allows")
+)
+
+// exponents holds the number of minor unit digits of known ISO 4217 currencies
+var exponents = map[string]int{
+	"AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CNY": 2, "DKK": 2,
+	"EUR": 2, "GBP": 2, "HKD": 2, "INR": 2, "JPY": 0, "KRW": 0, "KWD": 3,
+	"MXN": 2, "NOK": 2, "NZD": 2, "PLN": 2, "SEK": 2, "SGD": 2, "USD": 2,
+	"ZAR": 2,
+}
+
+// defaultExponent is assumed for money without a known currency
+const defaultExponent = 2
+
+// decimalPattern matches plain decimal amounts, optionally with a short
+// exponent as sent by clients that encode prices as JSON numbers
+var decimalPattern = regexp.MustCompile(`^[+-]?\d+(\.\d+)?([eE][+-]?\d{1,3})?$`)
+
+var (
+	mu              sync.RWMutex
+	defaultCurrency = "USD"
+)
+
+// Money is an amount of a currency counted in its minor units, so 12.34 USD
+// is Amount 1234 with Currency "USD"
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New creates money from an amount in minor units
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Parse creates money from a decimal amount in major units, such as "12.34".
+// Amounts that do not fit the currency's minor units are rejected rather
+// than rounded.
+func Parse(amount, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	exponent, ok := Exponent(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+
+	amount = strings.TrimSpace(amount)
+	if !decimalPattern.MatchString(amount) {
+		return Money{}, fmt.Errorf("%w %q", ErrInvalidAmount, amount)
+	}
+
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return Money{}, fmt.Errorf("%w %q", ErrInvalidAmount, amount)
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+	value.Mul(value, new(big.Rat).SetInt(scale))
+	if !value.IsInt() {
+		return Money{}, fmt.Errorf("%w: %s has at most %d", ErrPrecision, currency, exponent)
+	}
+	if !value.Num().IsInt64() {
+		return Money{}, fmt.Errorf("%w %q: out of range", ErrInvalidAmount, amount)
+	}
+
+	return Money{Amount: value.Num().Int64(), Currency: currency}, nil
+}
+
+// MustParse is like Parse but panics on error. It is meant for constants
+// and tests.
+func MustParse(amount, currency string) Money {
+	m, err := Parse(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Exponent returns the number of minor unit digits of a currency
+func Exponent(currency string) (int, bool) {
+	exponent, ok := exponents[strings.ToUpper(currency)]
+	return exponent, ok
+}
+
+// Currencies returns the supported ISO 4217 codes in alphabetical order
+func Currencies() []string {
+	codes := make([]string, 0, len(exponents))
+	for code := range exponents {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// IsKnownCurrency reports whether currency is a supported ISO 4217 code
+func IsKnownCurrency(currency string) bool {
+	_, ok := Exponent(currency)
+	return ok
+}
+
+// SetDefaultCurrency sets the currency assumed for amounts sent without one
+func SetDefaultCurrency(currency string) error {
+	currency = strings.ToUpper(currency)
+	if !IsKnownCurrency(currency) {
+		return fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+
+	mu.Lock()
+	defaultCurrency = currency
+	mu.Unlock()
+	return nil
+}
+
+// DefaultCurrency returns the currency assumed for amounts sent without one
+func DefaultCurrency() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultCurrency
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive reports whether the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// Decimal returns the amount in major units with every minor unit digit,
+// such as "12.30"
+func (m Money) Decimal() string {
+	exponent := m.exponent()
+
+	sign := ""
+	magnitude := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		magnitude = uint64(-(m.Amount + 1)) + 1
+	}
+
+	digits := strconv.FormatUint(magnitude, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+// Float64 returns the amount in major units as a float, for comparisons
+// with legacy float inputs. It is exact for amounts below 2^53 minor units.
+func (m Money) Float64() float64 {
+	return float64(m.Amount) / math.Pow10(m.exponent())
+}
+
+// CompareAmount compares the amounts of m and other in major units,
+// ignoring their currencies
+func (m Money) CompareAmount(other Money) int {
+	if m.exponent() == other.exponent() {
+		switch {
+		case m.Amount < other.Amount:
+			return -1
+		case m.Amount > other.Amount:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return m.rat().Cmp(other.rat())
+}
+
+// String returns the amount and currency, such as "12.34 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// ParseString parses the String form of money
+func ParseString(s string) (Money, error) {
+	amount, currency, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return Money{}, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+	}
+	return Parse(amount, currency)
+}
+
+// moneyJSON is the JSON form of money. The amount is a decimal string so it
+// survives clients that decode numbers as floats.
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// MarshalJSON encodes money as {"amount": "12.34", "currency": "USD"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	amount, err := json.Marshal(m.Decimal())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(moneyJSON{Amount: amount, Currency: m.Currency})
+}
+
+// UnmarshalJSON accepts the object form, whose amount may be a string or a
+// number, as well as a bare number or string in the default currency as
+// sent by clients from before amounts carried a currency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+
+	raw, currency := data, ""
+	if len(data) > 0 && data[0] == '{' {
+		var value moneyJSON
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		raw, currency = value.Amount, value.Currency
+	}
+	if currency == "" {
+		currency = DefaultCurrency()
+	}
+
+	amount := string(raw)
+	if len(raw) > 0 && raw[0] == '"' {
+		if err := json.Unmarshal(raw, &amount); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := Parse(amount, currency)
+	if err != nil {
+		return &json.UnmarshalTypeError{Value: err.Error(), Type: reflect.TypeOf(m).Elem()}
+	}
+
+	*m = parsed
+	return nil
+}
+
+// ToNumbers replaces every money value in a decoded JSON document with a
+// bare number in major units, the form prices had before they carried a
+// currency. It is meant for rendering responses to clients that cannot read
+// the object form yet, so the currency is dropped. The document should be
+// decoded with json.Decoder.UseNumber so other numbers keep their precision.
+func ToNumbers(document interface{}) interface{} {
+	switch value := document.(type) {
+	case map[string]interface{}:
+		if amount, ok := moneyAmount(value); ok {
+			return json.Number(amount)
+		}
+		for key, field := range value {
+			value[key] = ToNumbers(field)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = ToNumbers(item)
+		}
+	}
+	return document
+}
+
+// moneyAmount returns the amount of a decoded object that has exactly the
+// fields of money's JSON form
+func moneyAmount(object map[string]interface{}) (string, bool) {
+	if len(object) != 2 {
+		return "", false
+	}
+	amount, ok := object["amount"].(string)
+	if !ok || !decimalPattern.MatchString(amount) {
+		return "", false
+	}
+	currency, ok := object["currency"].(string)
+	return amount, ok && IsKnownCurrency(currency)
+}
+
+// exponent returns the minor unit digits of m's currency
+func (m Money) exponent() int {
+	if exponent, ok := Exponent(m.Currency); ok {
+		return exponent
+	}
+	return defaultExponent
+}
+
+// rat returns the amount in major units as an exact rational
+func (m Money) rat() *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(m.exponent())), nil)
+	return new(big.Rat).SetFrac(big.NewInt(m.Amount), scale)
+}
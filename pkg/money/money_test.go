@@ -0,0 +1,89 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	m, err := Parse("12.3", "usd")
+	require.NoError(t, err)
+	assert.Equal(t, Money{Amount: 1230, Currency: "USD"}, m)
+	assert.Equal(t, "12.30", m.Decimal())
+
+	m, err = Parse("1200", "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, "1200", m.Decimal())
+
+	m, err = Parse("-0.05", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, "-0.05 EUR", m.String())
+
+	m, err = Parse("12.3400", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1234), m.Amount)
+
+	_, err = Parse("12.345", "USD")
+	assert.True(t, errors.Is(err, ErrPrecision))
+	_, err = Parse("12", "XXX")
+	assert.True(t, errors.Is(err, ErrUnknownCurrency))
+	_, err = Parse("1/3", "USD")
+	assert.True(t, errors.Is(err, ErrInvalidAmount))
+	_, err = Parse("99999999999999999999", "USD")
+	assert.True(t, errors.Is(err, ErrInvalidAmount))
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(MustParse("0.1", "USD"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"0.10","currency":"USD"}`, string(data))
+
+	var m Money
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":"19.99","currency":"eur"}`), &m))
+	assert.Equal(t, New(1999, "EUR"), m)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":19.99}`), &m))
+	assert.Equal(t, New(1999, DefaultCurrency()), m)
+
+	// Legacy float clients send a bare number
+	require.NoError(t, json.Unmarshal([]byte(`29.99`), &m))
+	assert.Equal(t, New(2999, DefaultCurrency()), m)
+
+	var typeErr *json.UnmarshalTypeError
+	err = json.Unmarshal([]byte(`{"amount":"1.999","currency":"USD"}`), &m)
+	assert.True(t, errors.As(err, &typeErr))
+}
+
+func TestToNumbers(t *testing.T) {
+	data, err := json.Marshal(map[string]interface{}{
+		"price":    New(1999, "USD"),
+		"variants": []interface{}{map[string]interface{}{"price": New(500, "JPY"), "stock": 3}},
+		"fee":      map[string]interface{}{"amount": "1.00", "currency": "USD", "note": "kept"},
+	})
+	require.NoError(t, err)
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document interface{}
+	require.NoError(t, decoder.Decode(&document))
+
+	data, err = json.Marshal(ToNumbers(document))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"price": 19.99,
+		"variants": [{"price": 500, "stock": 3}],
+		"fee": {"amount": "1.00", "currency": "USD", "note": "kept"}
+	}`, string(data))
+}
+
+func TestCompareAmount(t *testing.T) {
+	assert.Equal(t, -1, New(999, "USD").CompareAmount(New(1000, "USD")))
+	assert.Equal(t, 0, New(1000, "JPY").CompareAmount(New(100000, "USD")))
+	assert.Equal(t, 1, New(1, "KWD").CompareAmount(New(0, "USD")))
+	assert.Equal(t, 19.99, New(1999, "USD").Float64())
+}
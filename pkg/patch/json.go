@@ -0,0 +1,357 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// JSON Patch operation names
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+// Operation is a single JSON Patch operation. Value is required by add,
+// replace and test; From by move and copy.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Apply applies a JSON Patch, an array of operations, to document. The
+// operations are applied in order and the patch fails as a whole when any
+// of them does; a failed test operation reports ErrTestFailed.
+func Apply(document, patch []byte) ([]byte, error) {
+	target, err := decode(document)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+
+	var operations []Operation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	for i, operation := range operations {
+		if target, err = applyOperation(target, operation); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, operation.Op, operation.Path, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+// applyOperation applies one operation to document and returns the result
+func applyOperation(document interface{}, operation Operation) (interface{}, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case OpAdd:
+		value, err := operationValue(operation)
+		if err != nil {
+			return nil, err
+		}
+		return add(document, path, value)
+	case OpRemove:
+		return remove(document, path)
+	case OpReplace:
+		value, err := operationValue(operation)
+		if err != nil {
+			return nil, err
+		}
+		return replace(document, path, value)
+	case OpMove, OpCopy:
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(document, from)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == OpCopy {
+			return add(document, path, deepCopy(value))
+		}
+		if isPrefix(from, path) && len(from) < len(path) {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+		if document, err = remove(document, from); err != nil {
+			return nil, err
+		}
+		return add(document, path, value)
+	case OpTest:
+		value, err := operationValue(operation)
+		if err != nil {
+			return nil, err
+		}
+		actual, err := get(document, path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrTestFailed, err)
+		}
+		if !equal(actual, value) {
+			return nil, ErrTestFailed
+		}
+		return document, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, operation.Op)
+	}
+}
+
+// operationValue decodes the value of an operation that requires one
+func operationValue(operation Operation) (interface{}, error) {
+	if operation.Value == nil {
+		return nil, fmt.Errorf("%w: %s requires a value", ErrInvalidPatch, operation.Op)
+	}
+	value, err := decode(operation.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return value, nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped reference
+// tokens. The empty pointer refers to the whole document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// isPrefix reports whether path starts with the tokens of prefix
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// get returns the value path refers to
+func get(document interface{}, path []string) (interface{}, error) {
+	value := document
+	for _, token := range path {
+		var err error
+		if value, err = child(value, token); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// add inserts value at path. Array members shift to make room, "-" appends
+// to an array and an existing object member is replaced.
+func add(document interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return updateParent(document, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			index := len(c)
+			if token != "-" {
+				var err error
+				if index, err = arrayIndex(token, len(c)+1); err != nil {
+					return nil, err
+				}
+			}
+			c = append(c, nil)
+			copy(c[index+1:], c[index:])
+			c[index] = value
+			return c, nil
+		default:
+			return nil, fmt.Errorf("%w: cannot add %q to a scalar", ErrInvalidPatch, token)
+		}
+	})
+}
+
+// remove deletes the value at path
+func remove(document interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+
+	return updateParent(document, path, func(container interface{}, token string) (interface{}, error) {
+		if _, err := child(container, token); err != nil {
+			return nil, err
+		}
+		switch c := container.(type) {
+		case map[string]interface{}:
+			delete(c, token)
+			return c, nil
+		default:
+			array := c.([]interface{})
+			index, _ := arrayIndex(token, len(array))
+			return append(array[:index], array[index+1:]...), nil
+		}
+	})
+}
+
+// replace swaps the existing value at path for value
+func replace(document interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return updateParent(document, path, func(container interface{}, token string) (interface{}, error) {
+		if _, err := child(container, token); err != nil {
+			return nil, err
+		}
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		default:
+			array := c.([]interface{})
+			index, _ := arrayIndex(token, len(array))
+			array[index] = value
+			return array, nil
+		}
+	})
+}
+
+// updateParent applies fn to the container holding the last token of path
+// and stores the container it returns back into the document
+func updateParent(node interface{}, path []string, fn func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+
+	next, err := child(node, path[0])
+	if err != nil {
+		return nil, err
+	}
+	updated, err := updateParent(next, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := node.(type) {
+	case map[string]interface{}:
+		c[path[0]] = updated
+	case []interface{}:
+		index, _ := arrayIndex(path[0], len(c))
+		c[index] = updated
+	}
+	return node, nil
+}
+
+// child returns the member of an object or array named by token
+func child(node interface{}, token string) (interface{}, error) {
+	switch c := node.(type) {
+	case map[string]interface{}:
+		value, ok := c[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q does not exist", ErrInvalidPatch, token)
+		}
+		return value, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(c))
+		if err != nil {
+			return nil, err
+		}
+		return c[index], nil
+	default:
+		return nil, fmt.Errorf("%w: cannot look up %q in a scalar", ErrInvalidPatch, token)
+	}
+}
+
+// arrayIndex parses an array index token that must be below limit
+func arrayIndex(token string, limit int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') || strings.HasPrefix(token, "+") {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	if index >= limit {
+		return 0, fmt.Errorf("%w: array index %d is out of range", ErrInvalidPatch, index)
+	}
+	return index, nil
+}
+
+// deepCopy copies a decoded JSON value so that it shares no containers
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for name, member := range v {
+			copied[name] = deepCopy(member)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = deepCopy(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+// equal reports whether two decoded JSON values are equal. Numbers are
+// compared by value, so 1 equals 1.0.
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for name, member := range x {
+			other, ok := y[name]
+			if !ok || !equal(member, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		xr, xok := new(big.Rat).SetString(x.String())
+		yr, yok := new(big.Rat).SetString(y.String())
+		return xok && yok && xr.Cmp(yr) == 0
+	default:
+		return a == b
+	}
+}
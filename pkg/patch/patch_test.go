@@ -0,0 +1,93 @@
+package patch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+		want     string
+	}{
+		{name: "replaces members", document: `{"a":"b","c":1}`, patch: `{"a":"z"}`, want: `{"a":"z","c":1}`},
+		{name: "null removes", document: `{"a":"b","c":1}`, patch: `{"c":null}`, want: `{"a":"b"}`},
+		{name: "merges objects", document: `{"p":{"x":1,"y":2}}`, patch: `{"p":{"y":null,"z":3}}`, want: `{"p":{"x":1,"z":3}}`},
+		{name: "replaces arrays", document: `{"a":[1,2]}`, patch: `{"a":[3]}`, want: `{"a":[3]}`},
+		{name: "keeps exact numbers", document: `{"n":12345678901234567890}`, patch: `{}`, want: `{"n":12345678901234567890}`},
+		{name: "non-object patch replaces", document: `{"a":1}`, patch: `["x"]`, want: `["x"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Merge([]byte(tt.document), []byte(tt.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+
+	_, err := Merge([]byte(`{}`), []byte(`{"a":`))
+	assert.True(t, errors.Is(err, ErrInvalidPatch))
+}
+
+func TestApply(t *testing.T) {
+	document := `{"name":"Mug","tags":["red","blue"],"price":{"amount":"5.00","currency":"USD"},"a/b":1}`
+
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{name: "add member", patch: `[{"op":"add","path":"/stock","value":3}]`,
+			want: `{"name":"Mug","tags":["red","blue"],"price":{"amount":"5.00","currency":"USD"},"a/b":1,"stock":3}`},
+		{name: "insert and append", patch: `[{"op":"add","path":"/tags/0","value":"green"},{"op":"add","path":"/tags/-","value":"gold"}]`,
+			want: `{"name":"Mug","tags":["green","red","blue","gold"],"price":{"amount":"5.00","currency":"USD"},"a/b":1}`},
+		{name: "remove and replace", patch: `[{"op":"remove","path":"/tags/0"},{"op":"replace","path":"/price/amount","value":"6.00"},{"op":"remove","path":"/a~1b"}]`,
+			want: `{"name":"Mug","tags":["blue"],"price":{"amount":"6.00","currency":"USD"}}`},
+		{name: "move and copy", patch: `[{"op":"copy","from":"/name","path":"/title"},{"op":"move","from":"/tags/1","path":"/tags/0"}]`,
+			want: `{"name":"Mug","title":"Mug","tags":["blue","red"],"price":{"amount":"5.00","currency":"USD"},"a/b":1}`},
+		{name: "passing test", patch: `[{"op":"test","path":"/a~1b","value":1.0},{"op":"test","path":"/tags","value":["red","blue"]}]`,
+			want: document},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(document), []byte(tt.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	document := []byte(`{"name":"Mug","tags":["red"]}`)
+
+	tests := []struct {
+		name  string
+		patch string
+		err   error
+	}{
+		{name: "failed test", patch: `[{"op":"replace","path":"/name","value":"Cup"},{"op":"test","path":"/name","value":"Mug"}]`, err: ErrTestFailed},
+		{name: "test of missing member", patch: `[{"op":"test","path":"/stock","value":1}]`, err: ErrTestFailed},
+		{name: "replace missing member", patch: `[{"op":"replace","path":"/stock","value":1}]`, err: ErrInvalidPatch},
+		{name: "index out of range", patch: `[{"op":"add","path":"/tags/2","value":"x"}]`, err: ErrInvalidPatch},
+		{name: "leading zero index", patch: `[{"op":"remove","path":"/tags/00"}]`, err: ErrInvalidPatch},
+		{name: "missing value", patch: `[{"op":"add","path":"/stock"}]`, err: ErrInvalidPatch},
+		{name: "unknown op", patch: `[{"op":"merge","path":"/name"}]`, err: ErrInvalidPatch},
+		{name: "relative path", patch: `[{"op":"remove","path":"name"}]`, err: ErrInvalidPatch},
+		{name: "move into itself", patch: `[{"op":"move","from":"/tags","path":"/tags/0"}]`, err: ErrInvalidPatch},
+		{name: "not an array", patch: `{"op":"remove","path":"/name"}`, err: ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply(document, []byte(tt.patch))
+			assert.True(t, errors.Is(err, tt.err), "got %v", err)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package search
+
+import "strings"
+
+// Highlight markers, matching the ts_headline options used with PostgreSQL
+const (
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
+
+// Highlight wraps the words of text that match a term of query in highlight
+// markers. When text is longer than maxWords words, only a window of
+// maxWords words around the first match is kept; maxWords of zero keeps
+// the whole text.
+func Highlight(text, query string, maxWords int) string {
+	terms := make(map[string]bool)
+	for _, term := range Terms(query) {
+		terms[term] = true
+	}
+
+	tokens := Tokens(text)
+	first, last := 0, len(tokens)
+	if maxWords > 0 && len(tokens) > maxWords {
+		match := 0
+		for i, token := range tokens {
+			if terms[token.Term] {
+				match = i
+				break
+			}
+		}
+		// Lead into the first match with a third of the window
+		first = match - maxWords/3
+		if first < 0 {
+			first = 0
+		}
+		last = first + maxWords
+		if last > len(tokens) {
+			last = len(tokens)
+			first = last - maxWords
+		}
+	}
+	if first == last {
+		return text
+	}
+
+	start, end := tokens[first].Start, tokens[last-1].End
+	if first == 0 {
+		start = 0
+	}
+	if last == len(tokens) {
+		end = len(text)
+	}
+
+	var b strings.Builder
+	position := start
+	for _, token := range tokens[first:last] {
+		if !terms[token.Term] || token.Term == "" {
+			continue
+		}
+		b.WriteString(text[position:token.Start])
+		b.WriteString(HighlightStart)
+		b.WriteString(token.Word)
+		b.WriteString(HighlightStop)
+		position = token.End
+	}
+	b.WriteString(text[position:end])
+
+	return strings.TrimSpace(b.String())
+}
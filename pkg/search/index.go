@@ -0,0 +1,139 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Field is a weighted piece of text of an indexed document. Matches in a
+// field with a higher weight rank a document higher.
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+// Hit is a document matching a query and its relevance score
+type Hit struct {
+	ID    string
+	Score float64
+}
+
+// Index is an in-memory inverted index from terms to the documents that
+// contain them. It is safe for concurrent use.
+type Index struct {
+	mutex sync.RWMutex
+	// postings maps a term to the weighted frequency of the term per document
+	postings map[string]map[string]float64
+	// documents maps a document to its distinct terms, for removal
+	documents map[string][]string
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{
+		postings:  make(map[string]map[string]float64),
+		documents: make(map[string][]string),
+	}
+}
+
+// Add indexes a document, replacing any previous version of it
+func (idx *Index) Add(id string, fields ...Field) {
+	frequencies := make(map[string]float64)
+	for _, field := range fields {
+		for _, term := range Terms(field.Text) {
+			frequencies[term] += field.Weight
+		}
+	}
+
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	idx.remove(id)
+	terms := make([]string, 0, len(frequencies))
+	for term, frequency := range frequencies {
+		if idx.postings[term] == nil {
+			idx.postings[term] = make(map[string]float64)
+		}
+		idx.postings[term][id] = frequency
+		terms = append(terms, term)
+	}
+	idx.documents[id] = terms
+}
+
+// Remove drops a document from the index
+func (idx *Index) Remove(id string) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	idx.remove(id)
+}
+
+// remove drops a document. The caller must hold the write lock.
+func (idx *Index) remove(id string) {
+	for _, term := range idx.documents[id] {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	delete(idx.documents, id)
+}
+
+// Search returns the documents containing every term of query that keep
+// reports true for, best match first. Scores weigh each term's frequency by
+// how rare the term is. A query without indexable terms matches nothing.
+func (idx *Index) Search(query string, keep func(id string) bool) []Hit {
+	terms := uniqueTerms(query)
+	if len(terms) == 0 {
+		return []Hit{}
+	}
+
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	// Start from the rarest term so the candidate set is as small as possible
+	sort.Slice(terms, func(i, j int) bool {
+		return len(idx.postings[terms[i]]) < len(idx.postings[terms[j]])
+	})
+
+	total := float64(len(idx.documents))
+	hits := make([]Hit, 0)
+	for id := range idx.postings[terms[0]] {
+		score := 0.0
+		for _, term := range terms {
+			frequency, ok := idx.postings[term][id]
+			if !ok {
+				score = -1
+				break
+			}
+			idf := math.Log(1 + total/float64(len(idx.postings[term])))
+			score += frequency * idf
+		}
+		if score < 0 || (keep != nil && !keep(id)) {
+			continue
+		}
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	return hits
+}
+
+// uniqueTerms returns the distinct indexable terms of query
+func uniqueTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range Terms(query) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,72 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStem(t *testing.T) {
+	cases := map[string]string{
+		"mugs":    "mug",
+		"glasses": "glass",
+		"ponies":  "poni",
+		"pony":    "poni",
+		"running": "run",
+		"ran":     "ran",
+		"hoped":   "hope",
+		"hopping": "hop",
+		"filing":  "file",
+		"agreed":  "agree",
+		"caress":  "caress",
+		"ceramic": "ceramic",
+		"go":      "go",
+	}
+	for word, stem := range cases {
+		assert.Equal(t, stem, Stem(word), word)
+	}
+}
+
+func TestTerms_SkipsStopWordsAndStems(t *testing.T) {
+	assert.Equal(t, []string{"red", "mug", "kitchen"}, Terms("The Red Mugs, for the kitchen!"))
+	assert.Empty(t, Terms("the and of"))
+}
+
+func TestIndex_RanksByWeightAndRarity(t *testing.T) {
+	idx := NewIndex()
+	idx.Add("lamp", Field{Text: "Desk Lamp", Weight: 1}, Field{Text: "Warm red light", Weight: 0.4})
+	idx.Add("mug", Field{Text: "Red Mug", Weight: 1}, Field{Text: "Ceramic mug", Weight: 0.4})
+	idx.Add("plate", Field{Text: "Blue Plate", Weight: 1}, Field{Text: "Ceramic", Weight: 0.4})
+
+	hits := idx.Search("red", nil)
+	if assert.Len(t, hits, 2) {
+		// A match in the name outranks one in the description
+		assert.Equal(t, "mug", hits[0].ID)
+		assert.Equal(t, "lamp", hits[1].ID)
+	}
+
+	// Every term must match
+	hits = idx.Search("ceramic mugs", nil)
+	if assert.Len(t, hits, 1) {
+		assert.Equal(t, "mug", hits[0].ID)
+	}
+
+	hits = idx.Search("ceramic", func(id string) bool { return id != "mug" })
+	if assert.Len(t, hits, 1) {
+		assert.Equal(t, "plate", hits[0].ID)
+	}
+
+	idx.Add("mug", Field{Text: "Green Cup", Weight: 1})
+	assert.Empty(t, idx.Search("red mug", nil))
+	idx.Remove("lamp")
+	assert.Empty(t, idx.Search("red", nil))
+	assert.Empty(t, idx.Search("the", nil))
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, "Red <mark>Mugs</mark>", Highlight("Red Mugs", "mug", 0))
+	assert.Equal(t, "No match here", Highlight("No match here", "mug", 0))
+
+	text := "one two three four five six seven eight nine mug ten eleven"
+	assert.Equal(t, "seven eight nine <mark>mug</mark> ten eleven", Highlight(text, "mug", 6))
+}
@@ -0,0 +1,112 @@
+package search
+
+import "strings"
+
+// Stem reduces a lower-case English word to its stem by removing plural and
+// verb suffixes, following the first step of the Porter algorithm so that
+// "mugs" matches "mug" and "running" matches "run". Only suffixes are
+// stripped, so irregular forms such as "ran" or "mice" keep their own stem.
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+
+	// Plurals
+	switch {
+	case strings.HasSuffix(word, "sses"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "ies"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"):
+	case strings.HasSuffix(word, "s") && len(word) > 3:
+		word = word[:len(word)-1]
+	}
+
+	// Past tense and progressive forms
+	switch {
+	case strings.HasSuffix(word, "eed"):
+		if measure(word[:len(word)-3]) > 0 {
+			word = word[:len(word)-1]
+		}
+	case strings.HasSuffix(word, "ed") && hasVowel(word[:len(word)-2]):
+		word = restoreStem(word[:len(word)-2])
+	case strings.HasSuffix(word, "ing") && hasVowel(word[:len(word)-3]):
+		word = restoreStem(word[:len(word)-3])
+	}
+
+	// A final y after a vowel-bearing stem becomes i, as in "ponies" and "pony"
+	if strings.HasSuffix(word, "y") && hasVowel(word[:len(word)-1]) {
+		word = word[:len(word)-1] + "i"
+	}
+
+	return word
+}
+
+// restoreStem tidies a stem after "ed" or "ing" was removed: it restores the
+// e of "hoped", undoubles the consonant of "hopping" and keeps "filing" whole
+func restoreStem(stem string) string {
+	switch {
+	case strings.HasSuffix(stem, "at"), strings.HasSuffix(stem, "bl"), strings.HasSuffix(stem, "iz"):
+		return stem + "e"
+	case doubleConsonant(stem) && !strings.HasSuffix(stem, "l") && !strings.HasSuffix(stem, "s") && !strings.HasSuffix(stem, "z"):
+		return stem[:len(stem)-1]
+	case measure(stem) == 1 && endsCVC(stem):
+		return stem + "e"
+	}
+	return stem
+}
+
+// isConsonant reports whether word[i] is a consonant in the Porter sense
+func isConsonant(word string, i int) bool {
+	switch word[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(word, i-1)
+	}
+	return true
+}
+
+// hasVowel reports whether word contains a vowel
+func hasVowel(word string) bool {
+	for i := range word {
+		if !isConsonant(word, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// measure counts the vowel-consonant sequences of word
+func measure(word string) int {
+	m := 0
+	previousVowel := false
+	for i := range word {
+		vowel := !isConsonant(word, i)
+		if previousVowel && !vowel {
+			m++
+		}
+		previousVowel = vowel
+	}
+	return m
+}
+
+// doubleConsonant reports whether word ends in the same consonant twice
+func doubleConsonant(word string) bool {
+	n := len(word)
+	return n >= 2 && word[n-1] == word[n-2] && isConsonant(word, n-1)
+}
+
+// endsCVC reports whether word ends consonant-vowel-consonant where the last
+// consonant is not w, x or y, as in "hop"
+func endsCVC(word string) bool {
+	n := len(word)
+	if n < 3 || !isConsonant(word, n-1) || isConsonant(word, n-2) || !isConsonant(word, n-3) {
+		return false
+	}
+	switch word[n-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are common English words that are not indexed, mirroring the
+// most frequent entries of PostgreSQL's english dictionary
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "from": true, "if": true,
+	"in": true, "into": true, "is": true, "it": true, "no": true, "not": true,
+	"of": true, "on": true, "or": true, "so": true, "such": true, "that": true,
+	"the": true, "their": true, "then": true, "there": true, "these": true,
+	"they": true, "this": true, "to": true, "was": true, "will": true, "with": true,
+}
+
+// Token is a word of a text together with the term it is indexed under.
+// Term is empty for stop words.
+type Token struct {
+	Word  string
+	Term  string
+	Start int
+	End   int
+}
+
+// Tokens splits text into words of letters and digits, in order, with the
+// byte offsets of each word
+func Tokens(text string) []Token {
+	var tokens []Token
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		}
+		if !isWord && start >= 0 {
+			tokens = append(tokens, newToken(text[start:i], start, i))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, newToken(text[start:], start, len(text)))
+	}
+	return tokens
+}
+
+// newToken builds the token of a word found at text[start:end]
+func newToken(word string, start, end int) Token {
+	token := Token{Word: word, Start: start, End: end}
+	lower := strings.ToLower(word)
+	if !stopWords[lower] {
+		token.Term = Stem(lower)
+	}
+	return token
+}
+
+// Terms returns the indexed terms of text, skipping stop words
+func Terms(text string) []string {
+	var terms []string
+	for _, token := range Tokens(text) {
+		if token.Term != "" {
+			terms = append(terms, token.Term)
+		}
+	}
+	return terms
+}
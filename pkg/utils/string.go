@@ -80,19 +80,12 @@ func (s *StringUtils) Slugify(str string) string {
 	// Convert to lowercase
 	str = strings.ToLower(str)
 	
-	// Replace spaces with hyphens
-	str = strings.ReplaceAll(str, " ", "-")
+	// Treat existing hyphens and underscores as word separators
+	str = strings.NewReplacer("-", " ", "_", " ").Replace(str)
 	
 	// Remove special characters
 	str = s.RemoveSpecialChars(str)
 	
-	// Remove multiple consecutive hyphens
-	for strings.Contains(str, "--") {
-		str = strings.ReplaceAll(str, "--", "-")
-	}
-	
-	// Remove leading and trailing hyphens
-	str = strings.Trim(str, "-")
-	
-	return str
+	// Join the remaining words with single hyphens
+	return strings.Join(strings.Fields(str), "-")
 }
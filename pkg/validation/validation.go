@@ -0,0 +1,249 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gin-service/pkg/common"
+	"gin-service/pkg/constants"
+	"gin-service/pkg/money"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// skuPattern accepts upper-case alphanumeric segments separated by dashes,
+// for example "MUG-RED-01"
+var skuPattern = regexp.MustCompile(`^[A-Z0-9]+(-[A-Z0-9]+)*$`)
+
+// DefaultCurrencies are the ISO 4217 codes accepted by the currency rule
+// until SetAllowedCurrencies replaces them: every currency money can parse
+var DefaultCurrencies = money.Currencies()
+
+var (
+	mu         sync.RWMutex
+	messages   = make(map[string]string)
+	currencies = toSet(DefaultCurrencies)
+)
+
+// Setup configures gin's validator engine to report JSON/form field names
+// and registers the built-in domain rules. It is safe to call more than once.
+func Setup() error {
+	engine, err := engine()
+	if err != nil {
+		return err
+	}
+
+	engine.RegisterTagNameFunc(fieldName)
+	engine.RegisterStructValidation(validateMoney, money.Money{})
+
+	if err := Register("sku", validateSKU, "must be upper-case letters and digits separated by dashes"); err != nil {
+		return err
+	}
+
+	return Register("currency", validateCurrency, "must be a supported ISO 4217 currency code")
+}
+
+// Register adds a custom rule to gin's validator engine. message is used as
+// the human readable text when a field fails the rule.
+func Register(tag string, fn validator.Func, message string) error {
+	engine, err := engine()
+	if err != nil {
+		return err
+	}
+
+	if err := engine.RegisterValidation(tag, fn); err != nil {
+		return fmt.Errorf("failed to register validation %q: %w", tag, err)
+	}
+
+	mu.Lock()
+	messages[tag] = message
+	mu.Unlock()
+
+	return nil
+}
+
+// SetAllowedCurrencies replaces the currency codes accepted by the currency rule
+func SetAllowedCurrencies(codes []string) {
+	mu.Lock()
+	currencies = toSet(codes)
+	mu.Unlock()
+}
+
+// IsAllowedCurrency reports whether code is accepted by the currency rule
+func IsAllowedCurrency(code string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	_, ok := currencies[code]
+	return ok
+}
+
+// Validate checks obj against its binding tags with gin's validator, for
+// input that does not arrive through request binding. It returns nil when
+// obj is valid.
+func Validate(obj interface{}) *common.AppError {
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return Translate(err)
+	}
+	return nil
+}
+
+// Translate converts a binding error into a validation AppError with one
+// machine-readable entry per failed field
+func Translate(err error) *common.AppError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]common.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, common.FieldError{
+				Field:   fieldPath(fe),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: message(fe),
+			})
+		}
+		return common.NewValidationErrorWithFields(constants.ErrMsgValidationFailed, fields)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := common.FieldError{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Type.String(),
+			Message: fmt.Sprintf("must be of type %s", typeErr.Type.String()),
+		}
+		// Money reports why its amount or currency was rejected as the value
+		if typeErr.Type == reflect.TypeOf(money.Money{}) {
+			field.Param = "money"
+			field.Message = typeErr.Value
+		}
+		return common.NewValidationErrorWithFields(constants.ErrMsgValidationFailed, []common.FieldError{field})
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return common.NewValidationErrorWithDetails("Malformed JSON body", syntaxErr.Error())
+	}
+
+	return common.NewValidationErrorWithDetails(constants.ErrMsgBadRequest, err.Error())
+}
+
+// engine returns gin's underlying go-playground validator
+func engine() (*validator.Validate, error) {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return nil, fmt.Errorf("unsupported validator engine %T", binding.Validator.Engine())
+	}
+	return engine, nil
+}
+
+// fieldName reports a struct field by its json name, falling back to its
+// form name and finally the Go field name
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// fieldPath drops the top-level struct name from a field's namespace, so
+// "CreateProductRequest.price" becomes "price"
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fe.Field()
+}
+
+// message returns the human readable text for a failed rule
+func message(fe validator.FieldError) string {
+	mu.RLock()
+	custom, ok := messages[fe.Tag()]
+	mu.RUnlock()
+	if ok {
+		return custom
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s", fe.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", fe.Param())
+	case "lte":
+		return fmt.Sprintf("must be less than or equal to %s", fe.Param())
+	case "min":
+		if isText(fe.Kind()) {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if isText(fe.Kind()) {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "len":
+		return fmt.Sprintf("must have length %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s]", fe.Param())
+	case "email":
+		return "must be a valid email address"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	default:
+		return fmt.Sprintf("failed the %q rule", fe.Tag())
+	}
+}
+
+// isText reports whether min/max apply to a length rather than a value
+func isText(kind reflect.Kind) bool {
+	return kind == reflect.String || kind == reflect.Slice || kind == reflect.Map || kind == reflect.Array
+}
+
+// validateSKU implements the sku rule
+func validateSKU(fl validator.FieldLevel) bool {
+	return skuPattern.MatchString(fl.Field().String())
+}
+
+// validateCurrency implements the currency rule
+func validateCurrency(fl validator.FieldLevel) bool {
+	return IsAllowedCurrency(fl.Field().String())
+}
+
+// validateMoney checks that money is in an allowed currency, reporting the
+// failure on its currency field. Unset money is left to required checks.
+func validateMoney(sl validator.StructLevel) {
+	m := sl.Current().Interface().(money.Money)
+	if m == (money.Money{}) {
+		return
+	}
+	if !IsAllowedCurrency(m.Currency) {
+		sl.ReportError(m.Currency, "currency", "Currency", "currency", "")
+	}
+}
+
+// toSet builds a lookup set of upper-cased codes
+func toSet(codes []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(codes))
+	for _, code := range codes {
+		set[strings.ToUpper(code)] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,100 @@
+package validation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gin-service/pkg/common"
+	"gin-service/pkg/money"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRequest struct {
+	Name     string  `json:"name" binding:"required,min=3"`
+	Price    float64 `json:"price" binding:"gt=0"`
+	SKU      string  `json:"sku" binding:"omitempty,sku"`
+	Currency string  `json:"currency" binding:"omitempty,currency"`
+}
+
+func bind(t *testing.T, body string) *common.AppError {
+	t.Helper()
+	require.NoError(t, Setup())
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	var req testRequest
+	err := c.ShouldBindJSON(&req)
+	if err == nil {
+		return nil
+	}
+	return Translate(err)
+}
+
+func TestTranslateReportsEveryField(t *testing.T) {
+	appErr := bind(t, `{"name":"ab","price":0}`)
+	require.NotNil(t, appErr)
+
+	assert.Equal(t, http.StatusBadRequest, appErr.HTTPStatus)
+	require.Len(t, appErr.Fields, 2)
+	assert.Equal(t, common.FieldError{Field: "name", Rule: "min", Param: "3", Message: "must be at least 3 characters long"}, appErr.Fields[0])
+	assert.Equal(t, common.FieldError{Field: "price", Rule: "gt", Param: "0", Message: "must be greater than 0"}, appErr.Fields[1])
+}
+
+func TestTranslateTypeMismatch(t *testing.T) {
+	appErr := bind(t, `{"name":"mug","price":"free"}`)
+	require.NotNil(t, appErr)
+
+	require.Len(t, appErr.Fields, 1)
+	assert.Equal(t, "price", appErr.Fields[0].Field)
+	assert.Equal(t, "type", appErr.Fields[0].Rule)
+}
+
+func TestDomainRules(t *testing.T) {
+	assert.Nil(t, bind(t, `{"name":"mug","price":1,"sku":"MUG-RED-01","currency":"EUR"}`))
+
+	appErr := bind(t, `{"name":"mug","price":1,"sku":"mug red","currency":"XXX"}`)
+	require.NotNil(t, appErr)
+	require.Len(t, appErr.Fields, 2)
+	assert.Equal(t, "sku", appErr.Fields[0].Rule)
+	assert.Equal(t, "currency", appErr.Fields[1].Rule)
+
+	SetAllowedCurrencies([]string{"xxx"})
+	defer SetAllowedCurrencies(DefaultCurrencies)
+	assert.Nil(t, bind(t, `{"name":"mug","price":1,"currency":"XXX"}`))
+}
+
+func TestMoney(t *testing.T) {
+	require.NoError(t, Setup())
+
+	type priced struct {
+		Cost money.Money `json:"cost"`
+	}
+
+	// Every currency money knows is allowed unless the list is narrowed
+	assert.Nil(t, Validate(&priced{Cost: money.New(100, "CHF")}))
+
+	SetAllowedCurrencies([]string{"EUR"})
+	defer SetAllowedCurrencies(DefaultCurrencies)
+	assert.Nil(t, Validate(&priced{Cost: money.New(100, "EUR")}))
+
+	appErr := Validate(&priced{Cost: money.New(100, "CHF")})
+	require.NotNil(t, appErr)
+	require.Len(t, appErr.Fields, 1)
+	assert.Equal(t, "cost.currency", appErr.Fields[0].Field)
+	assert.Equal(t, "currency", appErr.Fields[0].Rule)
+
+	var req priced
+	appErr = Translate(json.Unmarshal([]byte(`{"cost":{"amount":"1.999","currency":"USD"}}`), &req))
+	require.Len(t, appErr.Fields, 1)
+	assert.Equal(t, "money", appErr.Fields[0].Param)
+	assert.Contains(t, appErr.Fields[0].Message, "decimal places")
+}
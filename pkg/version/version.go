@@ -0,0 +1,91 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Build metadata injected at link time, for example with
+//
+//	go build -ldflags "-X gin-service/pkg/version.Version=v1.2.3 \
+//		-X gin-service/pkg/version.GitCommit=$(git rev-parse HEAD) \
+//		-X gin-service/pkg/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Values left empty are read from the build information the Go toolchain
+// embeds in the binary, where the commit time stands in for the build time.
+var (
+	Version   = ""
+	GitCommit = ""
+	BuildTime = ""
+)
+
+// Info describes the build of the running binary
+type Info struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Get returns the build metadata of the running binary
+func Get() Info {
+	once.Do(func() {
+		buildInfo, _ := debug.ReadBuildInfo()
+		info = resolve(buildInfo)
+	})
+	return info
+}
+
+// resolve fills in the metadata that was not injected at link time from
+// the embedded build information, which may be nil
+func resolve(buildInfo *debug.BuildInfo) Info {
+	resolved := Info{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+	if buildInfo == nil {
+		return withDefaults(resolved)
+	}
+
+	if resolved.Version == "" && buildInfo.Main.Version != "(devel)" {
+		resolved.Version = buildInfo.Main.Version
+	}
+	if buildInfo.GoVersion != "" {
+		resolved.GoVersion = buildInfo.GoVersion
+	}
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if resolved.GitCommit == "" {
+				resolved.GitCommit = setting.Value
+			}
+		case "vcs.time":
+			if resolved.BuildTime == "" {
+				resolved.BuildTime = setting.Value
+			}
+		}
+	}
+	return withDefaults(resolved)
+}
+
+// withDefaults marks metadata that could not be determined
+func withDefaults(resolved Info) Info {
+	if resolved.Version == "" {
+		resolved.Version = "dev"
+	}
+	if resolved.GitCommit == "" {
+		resolved.GitCommit = "unknown"
+	}
+	if resolved.BuildTime == "" {
+		resolved.BuildTime = "unknown"
+	}
+	return resolved
+}
@@ -0,0 +1,52 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	buildInfo := &debug.BuildInfo{
+		GoVersion: "go1.21.5",
+		Main:      debug.Module{Path: "gin-service", Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123abcd"},
+			{Key: "vcs.time", Value: "2024-01-01T12:00:00Z"},
+		},
+	}
+
+	t.Run("falls back to build information", func(t *testing.T) {
+		assert.Equal(t, Info{
+			Version:   "dev",
+			GitCommit: "0123abcd",
+			BuildTime: "2024-01-01T12:00:00Z",
+			GoVersion: "go1.21.5",
+		}, resolve(buildInfo))
+	})
+
+	t.Run("prefers values injected at link time", func(t *testing.T) {
+		defer func(version, commit, buildTime string) {
+			Version, GitCommit, BuildTime = version, commit, buildTime
+		}(Version, GitCommit, BuildTime)
+		Version, GitCommit, BuildTime = "v1.2.3", "fedc9876", "2024-02-02T08:00:00Z"
+
+		assert.Equal(t, Info{
+			Version:   "v1.2.3",
+			GitCommit: "fedc9876",
+			BuildTime: "2024-02-02T08:00:00Z",
+			GoVersion: "go1.21.5",
+		}, resolve(buildInfo))
+	})
+
+	t.Run("without build information", func(t *testing.T) {
+		assert.Equal(t, Info{
+			Version:   "dev",
+			GitCommit: "unknown",
+			BuildTime: "unknown",
+			GoVersion: runtime.Version(),
+		}, resolve(nil))
+	})
+}
@@ -1,4 +1,4 @@
 #!/bin/bash
 echo "Building gin-service..."
-go build -o bin/server cmd/server/main.go
+go build -o bin/server ./cmd/server
 echo "Build complete!"
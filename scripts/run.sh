@@ -1,3 +1,3 @@
 #!/bin/bash
 echo "Running gin-service..."
-go run cmd/server/main.go
+go run ./cmd/server